@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"github.com/Muoshu/myRadic/types"
+)
+
+// Token 分词结果里的一个词
+type Token struct {
+	Text     string
+	Start    int //在原文中的起始位置，按字节计算
+	End      int //在原文中的结束位置(不含)，按字节计算
+	Position int //第几个词。同义词跟原词的Position相同
+}
+
+// Tokenizer 把一段文本切成词
+type Tokenizer interface {
+	Tokenize(text string) []Token
+}
+
+// TokenFilter 对分词结果做变换，可以修改、删除、增加词
+type TokenFilter interface {
+	Filter(tokens []Token) []Token
+}
+
+// Analyzer 把一段文本转成建索引、检索用的词
+type Analyzer interface {
+	Analyze(text string) []Token
+}
+
+// pipeline 先分词，再依次经过各个filter。责任链模式
+type pipeline struct {
+	tokenizer Tokenizer
+	filters   []TokenFilter
+}
+
+// NewAnalyzer tokenizer分词之后，按顺序经过filters
+func NewAnalyzer(tokenizer Tokenizer, filters ...TokenFilter) Analyzer {
+	return &pipeline{tokenizer: tokenizer, filters: filters}
+}
+
+func (p *pipeline) Analyze(text string) []Token {
+	tokens := p.tokenizer.Tokenize(text)
+	for _, filter := range p.filters {
+		tokens = filter.Filter(tokens)
+	}
+	//filter可能把词变成空的
+	res := tokens[:0]
+	for _, token := range tokens {
+		if len(token.Text) > 0 {
+			res = append(res, token)
+		}
+	}
+	return res
+}
+
+// PerFieldAnalyzer 不同的field使用不同的Analyzer，没有单独配置的field使用默认的。建索引和检索时要用同一个PerFieldAnalyzer
+type PerFieldAnalyzer struct {
+	fallback  Analyzer
+	analyzers map[string]Analyzer
+}
+
+func NewPerFieldAnalyzer(fallback Analyzer) *PerFieldAnalyzer {
+	return &PerFieldAnalyzer{fallback: fallback, analyzers: make(map[string]Analyzer)}
+}
+
+// WithField builder模式，为field单独指定Analyzer
+func (a *PerFieldAnalyzer) WithField(field string, analyzer Analyzer) *PerFieldAnalyzer {
+	a.analyzers[field] = analyzer
+	return a
+}
+
+// Analyzer field使用的Analyzer
+func (a *PerFieldAnalyzer) Analyzer(field string) Analyzer {
+	if analyzer, exists := a.analyzers[field]; exists {
+		return analyzer
+	}
+	return a.fallback
+}
+
+func (a *PerFieldAnalyzer) Analyze(field string, text string) []Token {
+	return a.Analyzer(field).Analyze(text)
+}
+
+// Keywords 建索引时把field的文本转成Keyword，顺序跟分词结果相同
+func (a *PerFieldAnalyzer) Keywords(field string, text string) []*types.Keyword {
+	tokens := a.Analyze(field, text)
+	keywords := make([]*types.Keyword, 0, len(tokens))
+	for _, token := range tokens {
+		keywords = append(keywords, &types.Keyword{Field: field, Word: token.Text})
+	}
+	return keywords
+}
@@ -0,0 +1,93 @@
+package analysis
+
+import (
+	"strings"
+)
+
+// LowercaseFilter 转成小写
+type LowercaseFilter struct{}
+
+func (LowercaseFilter) Filter(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Text = strings.ToLower(tokens[i].Text)
+	}
+	return tokens
+}
+
+// WidthFilter 全角字符转成半角，如“ＧＯ１”转成“GO1”
+type WidthFilter struct{}
+
+func (WidthFilter) Filter(tokens []Token) []Token {
+	for i := range tokens {
+		tokens[i].Text = strings.Map(toHalfWidth, tokens[i].Text)
+	}
+	return tokens
+}
+
+func toHalfWidth(r rune) rune {
+	if r == '　' { //全角空格
+		return ' '
+	}
+	if r >= '！' && r <= '～' { //全角的ASCII字符
+		return r - 0xFEE0
+	}
+	return r
+}
+
+// DefaultStopWords 常见的中英文停用词
+var DefaultStopWords = []string{"的", "了", "和", "是", "在", "就", "都", "而", "及", "与", "着", "或", "一个", "没有", "我们", "你们", "他们",
+	"a", "an", "and", "are", "as", "at", "be", "by", "for", "in", "is", "it", "of", "on", "or", "the", "to", "with"}
+
+// StopFilter 去掉停用词。需要放在LowercaseFilter、WidthFilter后面
+type StopFilter struct {
+	words map[string]bool
+}
+
+func NewStopFilter(words ...string) *StopFilter {
+	filter := &StopFilter{words: make(map[string]bool, len(words))}
+	for _, word := range words {
+		filter.words[word] = true
+	}
+	return filter
+}
+
+func (f *StopFilter) Filter(tokens []Token) []Token {
+	res := tokens[:0]
+	for _, token := range tokens {
+		if !f.words[token.Text] {
+			res = append(res, token)
+		}
+	}
+	return res
+}
+
+// SynonymFilter 在每个词后面补上它的同义词，同义词的Position和在原文中的位置都跟原词相同
+type SynonymFilter struct {
+	synonyms map[string][]string
+}
+
+// NewSynonymFilter groups里的每一组词互为同义词
+func NewSynonymFilter(groups ...[]string) *SynonymFilter {
+	filter := &SynonymFilter{synonyms: make(map[string][]string)}
+	for _, group := range groups {
+		for _, word := range group {
+			for _, synonym := range group {
+				if synonym != word {
+					filter.synonyms[word] = append(filter.synonyms[word], synonym)
+				}
+			}
+		}
+	}
+	return filter
+}
+
+func (f *SynonymFilter) Filter(tokens []Token) []Token {
+	res := make([]Token, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, token)
+		for _, synonym := range f.synonyms[token.Text] {
+			res = append(res, Token{Text: synonym, Start: token.Start, End: token.End, Position: token.Position})
+		}
+	}
+	return res
+}
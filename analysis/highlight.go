@@ -0,0 +1,255 @@
+package analysis
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"html"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terms 查询里某个field上的词，用来判断原文里的哪些词命中了查询
+type Terms struct {
+	words     map[string]bool
+	prefixes  []string
+	wildcards []string
+	fuzzys    []fuzzyTerm
+}
+
+type fuzzyTerm struct {
+	word     []rune
+	maxEdits int
+}
+
+// QueryTerms 收集查询树上field的叶子节点里的词：Keyword、短语和邻近查询里的词，以及前缀、通配符和模糊查询的目标。MustNot里的词不算
+func QueryTerms(q *types.TermQuery, field string) *Terms {
+	terms := &Terms{words: make(map[string]bool)}
+	terms.collect(q, field)
+	return terms
+}
+
+func (terms *Terms) collect(q *types.TermQuery, field string) {
+	if q == nil {
+		return
+	}
+	add := func(keyword *types.Keyword) {
+		if keyword != nil && keyword.Field == field && len(keyword.Word) > 0 {
+			terms.words[keyword.Word] = true
+		}
+	}
+	add(q.Keyword)
+	for _, keyword := range q.Phrase {
+		add(keyword)
+	}
+	if q.Near != nil {
+		for _, keyword := range q.Near.Keywords {
+			add(keyword)
+		}
+	}
+	if q.Prefix != nil && q.Prefix.Field == field && len(q.Prefix.Word) > 0 {
+		terms.prefixes = append(terms.prefixes, q.Prefix.Word)
+	}
+	if q.Wildcard != nil && q.Wildcard.Field == field && len(q.Wildcard.Word) > 0 {
+		terms.wildcards = append(terms.wildcards, q.Wildcard.Word)
+	}
+	if fuzzy := q.Fuzzy; fuzzy != nil && fuzzy.Keyword != nil && fuzzy.Keyword.Field == field && len(fuzzy.Keyword.Word) > 0 {
+		terms.fuzzys = append(terms.fuzzys, fuzzyTerm{word: []rune(fuzzy.Keyword.Word), maxEdits: int(fuzzy.MaxEdits)})
+	}
+	for _, sub := range q.Must {
+		terms.collect(sub, field)
+	}
+	for _, sub := range q.Should {
+		terms.collect(sub, field)
+	}
+}
+
+func (terms *Terms) Empty() bool {
+	return len(terms.words) == 0 && len(terms.prefixes) == 0 && len(terms.wildcards) == 0 && len(terms.fuzzys) == 0
+}
+
+// Match word是否命中了查询里的词
+func (terms *Terms) Match(word string) bool {
+	if terms.words[word] {
+		return true
+	}
+	for _, prefix := range terms.prefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range terms.wildcards {
+		if matchWildcard(pattern, word) {
+			return true
+		}
+	}
+	if len(terms.fuzzys) > 0 {
+		runes := []rune(word)
+		for _, fuzzy := range terms.fuzzys {
+			if withinEdits(fuzzy.word, runes, fuzzy.maxEdits) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchWildcard 跟倒排索引展开通配符时的规则相同：*匹配任意个字符，?匹配一个字符，按字符(而不是字节)匹配
+func matchWildcard(pattern, s string) bool {
+	p, i := 0, 0
+	starP, starI := -1, 0
+	for i < len(s) {
+		if p < len(pattern) && pattern[p] == '*' {
+			starP, starI = p, i
+			p++
+			continue
+		}
+		if p < len(pattern) {
+			pc, pn := utf8.DecodeRuneInString(pattern[p:])
+			sc, sn := utf8.DecodeRuneInString(s[i:])
+			if pc == '?' || pc == sc {
+				p += pn
+				i += sn
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		_, sn := utf8.DecodeRuneInString(s[starI:])
+		starI += sn
+		p, i = starP+1, starI
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// withinEdits a和b的Levenshtein距离是否不超过maxEdits，按字符计算
+func withinEdits(a, b []rune, maxEdits int) bool {
+	if len(a)-len(b) > maxEdits || len(b)-len(a) > maxEdits {
+		return false
+	}
+	prev, row := make([]int, len(a)+1), make([]int, len(a)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for _, c := range b {
+		row[0] = prev[0] + 1
+		for j, t := range a {
+			cost := 1
+			if t == c {
+				cost = 0
+			}
+			row[j+1] = min(row[j]+1, prev[j+1]+1, prev[j]+cost)
+		}
+		prev, row = row, prev
+	}
+	return prev[len(a)] <= maxEdits
+}
+
+// Highlighter 用建索引时的Analyzer对原文分词，把命中查询的词用标签包起来。
+// 切分位置都在字符边界上，中文等多字节文字不会被截断；标签以外的文本做了HTML转义
+type Highlighter struct {
+	analyzer     Analyzer
+	preTag       string
+	postTag      string
+	fragmentSize int //每个片段最多几个字，为0时整段原文作为一个片段
+	maxFragments int //最多返回几个片段，为0时不限
+}
+
+// NewHighlighter analyzer要跟建索引时这个field用的一样。默认用<em></em>包住命中的词
+func NewHighlighter(analyzer Analyzer) *Highlighter {
+	return &Highlighter{analyzer: analyzer, preTag: "<em>", postTag: "</em>"}
+}
+
+// WithTags builder模式，指定包住命中词的标签
+func (h *Highlighter) WithTags(preTag, postTag string) *Highlighter {
+	h.preTag, h.postTag = preTag, postTag
+	return h
+}
+
+// WithFragmentSize builder模式，原文按字数切成片段，只返回含有命中词的片段
+func (h *Highlighter) WithFragmentSize(n int) *Highlighter {
+	h.fragmentSize = n
+	return h
+}
+
+// WithMaxFragments builder模式，最多返回n个片段
+func (h *Highlighter) WithMaxFragments(n int) *Highlighter {
+	h.maxFragments = n
+	return h
+}
+
+// Highlight 返回text里含有命中词的片段，按在原文中的先后顺序排列。没有命中的词时返回nil
+func (h *Highlighter) Highlight(text string, terms *Terms) []string {
+	if terms == nil || terms.Empty() {
+		return nil
+	}
+	spans := h.matchedSpans(text, terms)
+	if len(spans) == 0 {
+		return nil
+	}
+	if h.fragmentSize <= 0 {
+		return []string{h.mark(text, 0, len(text), spans)}
+	}
+
+	offsets := runeOffsets(text)
+	runeIndex := func(pos int) int { return sort.SearchInts(offsets, pos) } //pos一定在字符边界上
+	fragments := make([]string, 0, 2)
+	end := 0 //上一个片段的结束位置，按字计算
+	for i := 0; i < len(spans); {
+		if h.maxFragments > 0 && len(fragments) >= h.maxFragments {
+			break
+		}
+		//命中的词放在片段中间
+		first, last := runeIndex(spans[i][0]), runeIndex(spans[i][1])
+		from := max(end, first-max(h.fragmentSize-(last-first), 0)/2)
+		to := min(len(offsets)-1, from+h.fragmentSize)
+		from = max(end, min(from, to-h.fragmentSize))
+		//片段里放得下的词都放进来，跨过片段结尾的词延长片段
+		j := i
+		for j < len(spans) && runeIndex(spans[j][0]) < to {
+			to = max(to, runeIndex(spans[j][1]))
+			j++
+		}
+		fragments = append(fragments, h.mark(text, offsets[from], offsets[to], spans[i:j]))
+		end, i = to, j
+	}
+	return fragments
+}
+
+// 命中的词在原文中的[起始,结束)字节位置，相互重叠或紧挨着的合并成一段
+func (h *Highlighter) matchedSpans(text string, terms *Terms) [][2]int {
+	spans := make([][2]int, 0, 4)
+	for _, token := range h.analyzer.Analyze(text) {
+		if terms.Match(token.Text) {
+			spans = append(spans, [2]int{token.Start, token.End})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	merged := spans[:0]
+	for _, span := range spans {
+		if n := len(merged); n > 0 && span[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], span[1])
+		} else {
+			merged = append(merged, span)
+		}
+	}
+	return merged
+}
+
+// text[from:to]里的spans加上标签，spans都在[from,to)之内
+func (h *Highlighter) mark(text string, from, to int, spans [][2]int) string {
+	sb := strings.Builder{}
+	sb.Grow(to - from + len(spans)*(len(h.preTag)+len(h.postTag)))
+	for _, span := range spans {
+		sb.WriteString(html.EscapeString(text[from:span[0]]))
+		sb.WriteString(h.preTag)
+		sb.WriteString(html.EscapeString(text[span[0]:span[1]]))
+		sb.WriteString(h.postTag)
+		from = span[1]
+	}
+	sb.WriteString(html.EscapeString(text[from:to]))
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"github.com/Muoshu/myRadic/types"
+)
+
+// AnalyzeQuery 检索时用跟建索引相同的方式处理查询里的词，不修改原来的查询：
+//   - Keyword切成多个词时，各个词之间是Must；Position相同的词(同义词)之间是Should。全部被过滤掉时变成空节点
+//   - 短语和邻近查询里的词逐个处理，每个位置只取第一个词
+//   - 前缀、通配符、模糊查询的词处理后仍是一个词时才替换，否则保持原样
+func (a *PerFieldAnalyzer) AnalyzeQuery(q *types.TermQuery) *types.TermQuery {
+	if q == nil {
+		return nil
+	}
+	res := &types.TermQuery{Boost: q.Boost, Range: q.Range, MustNot: a.analyzeQuerys(q.MustNot)}
+	switch {
+	case q.Keyword != nil:
+		leaf := a.analyzeKeyword(q.Keyword, q.Boost)
+		if len(res.MustNot) == 0 {
+			return leaf
+		}
+		res.Must = []*types.TermQuery{leaf}
+		res.Boost = 0
+	case len(q.Phrase) > 0:
+		res.Phrase = a.analyzeKeywords(q.Phrase)
+	case q.Near != nil && len(q.Near.Keywords) > 0:
+		res.Near = &types.Near{Keywords: a.analyzeKeywords(q.Near.Keywords), Distance: q.Near.Distance}
+	case q.Prefix != nil:
+		res.Prefix = a.normalize(q.Prefix)
+	case q.Wildcard != nil:
+		res.Wildcard = a.normalize(q.Wildcard)
+	case q.Fuzzy != nil && q.Fuzzy.Keyword != nil:
+		res.Fuzzy = &types.Fuzzy{Keyword: a.normalize(q.Fuzzy.Keyword), MaxEdits: q.Fuzzy.MaxEdits}
+	default:
+		res.Must = a.analyzeQuerys(q.Must)
+		res.Should = a.analyzeQuerys(q.Should)
+	}
+	return res
+}
+
+func (a *PerFieldAnalyzer) analyzeQuerys(querys []*types.TermQuery) []*types.TermQuery {
+	if len(querys) == 0 {
+		return nil
+	}
+	res := make([]*types.TermQuery, 0, len(querys))
+	for _, q := range querys {
+		if analyzed := a.AnalyzeQuery(q); analyzed != nil && !analyzed.Empty() {
+			res = append(res, analyzed)
+		}
+	}
+	return res
+}
+
+// 一个词切成多个词后，按Position分组：组内是Should，组间是Must
+func (a *PerFieldAnalyzer) analyzeKeyword(keyword *types.Keyword, boost float32) *types.TermQuery {
+	tokens := a.Analyze(keyword.Field, keyword.Word)
+	var groups [][]*types.TermQuery
+	for i, token := range tokens {
+		leaf := types.NewTermQuery(keyword.Field, token.Text)
+		leaf.Boost = boost
+		if i > 0 && token.Position == tokens[i-1].Position {
+			groups[len(groups)-1] = append(groups[len(groups)-1], leaf)
+		} else {
+			groups = append(groups, []*types.TermQuery{leaf})
+		}
+	}
+	must := make([]*types.TermQuery, 0, len(groups))
+	for _, group := range groups {
+		if len(group) == 1 {
+			must = append(must, group[0])
+		} else {
+			must = append(must, &types.TermQuery{Should: group})
+		}
+	}
+	switch len(must) {
+	case 0:
+		return &types.TermQuery{}
+	case 1:
+		return must[0]
+	default:
+		return &types.TermQuery{Must: must}
+	}
+}
+
+// 短语里的词逐个处理，同一个Position只取第一个词
+func (a *PerFieldAnalyzer) analyzeKeywords(keywords []*types.Keyword) []*types.Keyword {
+	res := make([]*types.Keyword, 0, len(keywords))
+	for _, keyword := range keywords {
+		tokens := a.Analyze(keyword.Field, keyword.Word)
+		for i, token := range tokens {
+			if i == 0 || token.Position != tokens[i-1].Position {
+				res = append(res, &types.Keyword{Field: keyword.Field, Word: token.Text})
+			}
+		}
+	}
+	return res
+}
+
+// 处理后仍是一个词时才替换
+func (a *PerFieldAnalyzer) normalize(keyword *types.Keyword) *types.Keyword {
+	if tokens := a.Analyze(keyword.Field, keyword.Word); len(tokens) == 1 {
+		return &types.Keyword{Field: keyword.Field, Word: tokens[0].Text}
+	}
+	return keyword
+}
@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Dictionary 中文分词用的词典，每个词带一个词频
+type Dictionary struct {
+	freqs  map[string]int
+	total  int //所有词的词频之和
+	maxLen int //最长的词有几个字
+}
+
+func NewDictionary() *Dictionary {
+	return &Dictionary{freqs: make(map[string]int)}
+}
+
+// Add 添加一个词，freq小于1时按1算。重复添加时以最后一次为准
+func (dict *Dictionary) Add(word string, freq int) *Dictionary {
+	freq = max(freq, 1)
+	if old, exists := dict.freqs[word]; exists {
+		dict.total -= old
+	}
+	dict.freqs[word] = freq
+	dict.total += freq
+	dict.maxLen = max(dict.maxLen, utf8.RuneCountInString(word))
+	return dict
+}
+
+// LoadDictionary 从文件加载词典。每行一个词，后面可以跟空白和词频，空行和#开头的行忽略
+func LoadDictionary(path string) (*Dictionary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	dict := NewDictionary()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		freq := 1
+		if len(fields) > 1 {
+			if freq, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, err
+			}
+		}
+		dict.Add(fields[0], freq)
+	}
+	return dict, scanner.Err()
+}
+
+// 词在词典里出现的对数概率。不在词典里的单字给一个比所有词都低的概率
+func (dict *Dictionary) logProb(word string, single bool) (float64, bool) {
+	total := float64(dict.total + 1)
+	if freq, exists := dict.freqs[word]; exists {
+		return math.Log(float64(freq) / total), true
+	}
+	if single {
+		return math.Log(1 / total / total), true
+	}
+	return 0, false
+}
+
+// DictTokenizer 基于词典的中文分词。中日韩文字段用动态规划求概率最大的切分(各词的对数概率之和最大)，
+// 词典里没有的字单独成词；字母数字段整体作为一个词
+type DictTokenizer struct {
+	dict *Dictionary
+}
+
+func NewDictTokenizer(dict *Dictionary) *DictTokenizer {
+	return &DictTokenizer{dict: dict}
+}
+
+func (t *DictTokenizer) Tokenize(text string) []Token {
+	tokens := make([]Token, 0, len(text)/3)
+	for _, run := range splitRuns(text) {
+		if !run.cjk {
+			tokens = append(tokens, Token{Text: run.text, Start: run.start, End: run.start + len(run.text), Position: len(tokens)})
+			continue
+		}
+		for _, span := range t.segment(run.text) {
+			tokens = append(tokens, Token{Text: run.text[span[0]:span[1]], Start: run.start + span[0], End: run.start + span[1], Position: len(tokens)})
+		}
+	}
+	return tokens
+}
+
+// segment 返回每个词在text中的[起始,结束)字节位置
+func (t *DictTokenizer) segment(text string) [][2]int {
+	offsets := runeOffsets(text)
+	n := len(offsets) - 1
+	//best[i]是前i个字的最优切分的得分，from[i]是最后一个词的起始字
+	best := make([]float64, n+1)
+	from := make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(-1)
+		for j := max(0, i-max(t.dict.maxLen, 1)); j < i; j++ {
+			if prob, ok := t.dict.logProb(text[offsets[j]:offsets[i]], i-j == 1); ok && best[j]+prob > best[i] {
+				best[i] = best[j] + prob
+				from[i] = j
+			}
+		}
+	}
+	spans := make([][2]int, 0, n)
+	for i := n; i > 0; i = from[i] {
+		spans = append(spans, [2]int{offsets[from[i]], offsets[i]})
+	}
+	for l, r := 0, len(spans)-1; l < r; l, r = l+1, r-1 {
+		spans[l], spans[r] = spans[r], spans[l]
+	}
+	return spans
+}
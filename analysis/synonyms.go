@@ -0,0 +1,191 @@
+package analysis
+
+import (
+	"bufio"
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Synonyms 从文件加载的同义词表，文件变化后自动重新加载。检索前用Rewrite把Keyword展开成它的同义词
+type Synonyms struct {
+	path     string
+	synonyms atomic.Pointer[map[string][]string] //word -> 所在组的全部词(含自身)，重新加载时整体替换
+	mu       sync.Mutex                          //保护modTime、size
+	modTime  time.Time
+	size     int64
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// LoadSynonyms 从path加载同义词表，每行一组用逗号分隔的词，空行和#开头的行忽略。
+// reloadInterval大于0时，每隔reloadInterval检查一次文件，修改时间或大小变了就重新加载
+func LoadSynonyms(path string, reloadInterval time.Duration) (*Synonyms, error) {
+	s := &Synonyms{path: path, stop: make(chan struct{})}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	if reloadInterval > 0 {
+		go s.watch(reloadInterval)
+	}
+	return s, nil
+}
+
+// Reload 重新读取文件。读取失败时保留原来的同义词表
+func (s *Synonyms) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	synonyms, err := readSynonyms(s.path)
+	if err != nil {
+		return err
+	}
+	s.synonyms.Store(&synonyms)
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+func (s *Synonyms) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				util.Log.Printf("stat synonyms file %s failed: %s", s.path, err)
+				continue
+			}
+			s.mu.Lock()
+			unchanged := info.ModTime().Equal(s.modTime) && info.Size() == s.size
+			s.mu.Unlock()
+			if unchanged {
+				continue
+			}
+			if err := s.Reload(); err != nil {
+				util.Log.Printf("reload synonyms file %s failed: %s", s.path, err)
+			} else {
+				util.Log.Printf("reload synonyms file %s", s.path)
+			}
+		}
+	}
+}
+
+// Close 停止检查文件变化
+func (s *Synonyms) Close() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() { close(s.stop) })
+}
+
+func readSynonyms(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	synonyms := make(map[string][]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		group := make([]string, 0, 4)
+		for _, word := range strings.Split(line, ",") {
+			if word = strings.TrimSpace(word); len(word) > 0 {
+				group = append(group, word)
+			}
+		}
+		if len(group) < 2 {
+			continue
+		}
+		for _, word := range group {
+			synonyms[word] = group //一个词出现在多组里时以最后一组为准
+		}
+	}
+	return synonyms, scanner.Err()
+}
+
+// Synonyms word的同义词(含word自身)，没有同义词时返回nil
+func (s *Synonyms) Synonyms(word string) []string {
+	if s == nil {
+		return nil
+	}
+	return (*s.synonyms.Load())[word]
+}
+
+// Rewrite 把查询里的每个Keyword改写成它和它所有同义词之间的Should，field和Boost不变。
+// 不修改原来的查询，没有需要改写的地方时返回q本身。s为nil时不改写
+func (s *Synonyms) Rewrite(q *types.TermQuery) *types.TermQuery {
+	if s == nil || q == nil {
+		return q
+	}
+	synonyms := *s.synonyms.Load()
+	if len(synonyms) == 0 {
+		return q
+	}
+	return rewriteSynonyms(q, synonyms)
+}
+
+func rewriteSynonyms(q *types.TermQuery, synonyms map[string][]string) *types.TermQuery {
+	mustNot, changed := rewriteQuerys(q.MustNot, synonyms)
+	if q.Keyword != nil {
+		group := synonyms[q.Keyword.Word]
+		if len(group) == 0 {
+			if !changed {
+				return q
+			}
+			res := *q
+			res.MustNot = mustNot
+			return &res
+		}
+		should := make([]*types.TermQuery, 0, len(group))
+		for _, word := range group {
+			leaf := types.NewTermQuery(q.Keyword.Field, word)
+			leaf.Boost = q.Boost
+			should = append(should, leaf)
+		}
+		expanded := &types.TermQuery{Should: should}
+		if len(mustNot) == 0 {
+			return expanded
+		}
+		return &types.TermQuery{Must: []*types.TermQuery{expanded}, MustNot: mustNot}
+	}
+	must, mustChanged := rewriteQuerys(q.Must, synonyms)
+	should, shouldChanged := rewriteQuerys(q.Should, synonyms)
+	if !changed && !mustChanged && !shouldChanged {
+		return q
+	}
+	res := *q
+	res.Must, res.Should, res.MustNot = must, should, mustNot
+	return &res
+}
+
+func rewriteQuerys(querys []*types.TermQuery, synonyms map[string][]string) ([]*types.TermQuery, bool) {
+	var res []*types.TermQuery
+	for i, q := range querys {
+		rewritten := rewriteSynonyms(q, synonyms)
+		if rewritten != q && res == nil {
+			res = make([]*types.TermQuery, len(querys))
+			copy(res, querys[:i])
+		}
+		if res != nil {
+			res[i] = rewritten
+		}
+	}
+	if res == nil {
+		return querys, false
+	}
+	return res, true
+}
@@ -0,0 +1,108 @@
+package test
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 把分词结果写成 词[起始,结束)@Position，并检查起止位置确实对应原文里的这个词
+func tokensString(t *testing.T, text string, tokens []analysis.Token, checkOffsets bool) string {
+	parts := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if checkOffsets && text[token.Start:token.End] != token.Text {
+			t.Fatalf("%s的位置[%d,%d)对应的是%s", token.Text, token.Start, token.End, text[token.Start:token.End])
+		}
+		parts = append(parts, fmt.Sprintf("%s[%d,%d)@%d", token.Text, token.Start, token.End, token.Position))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestCJKBigramTokenizer(t *testing.T) {
+	text := "Go语言搜索引擎，从0到1！"
+	tokens := analysis.CJKBigramTokenizer{}.Tokenize(text)
+	expect := "Go[0,2)@0 语言[2,8)@1 言搜[5,11)@2 搜索[8,14)@3 索引[11,17)@4 引擎[14,20)@5 从[23,26)@6 0[26,27)@7 到[27,30)@8 1[30,31)@9"
+	if s := tokensString(t, text, tokens, true); s != expect {
+		t.Fatalf("分词结果错误\n期望%s\n实际%s", expect, s)
+	}
+}
+
+func TestDictTokenizer(t *testing.T) {
+	dict := analysis.NewDictionary().Add("搜索", 10).Add("搜索引擎", 5).Add("引擎", 10).Add("研究", 10).Add("研究生", 5).Add("生命", 10).Add("起源", 10)
+	tokenizer := analysis.NewDictTokenizer(dict)
+	for text, expect := range map[string]string{
+		"golang搜索引擎": "golang 搜索引擎",
+		"研究生命起源":     "研究 生命 起源", //概率最大的切分，而不是最长匹配
+		"搜索个引擎":      "搜索 个 引擎",  //词典里没有的字单独成词
+	} {
+		tokens := tokenizer.Tokenize(text)
+		words := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			words = append(words, token.Text)
+		}
+		tokensString(t, text, tokens, true)
+		if s := strings.Join(words, " "); s != expect {
+			t.Fatalf("%s 分词结果错误，期望%s，实际%s", text, expect, s)
+		}
+	}
+}
+
+func TestLoadDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.txt")
+	if err := os.WriteFile(path, []byte("# 词典\n搜索 10\n\n搜索引擎 10\n引擎 10\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dict, err := analysis.LoadDictionary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens := analysis.NewDictTokenizer(dict).Tokenize("搜索引擎"); len(tokens) != 1 {
+		t.Fatalf("应切成一个词，实际%v", tokens)
+	}
+	if err := os.WriteFile(path, []byte("搜索 x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := analysis.LoadDictionary(path); err == nil {
+		t.Fatal("词频不是数字时应该报错")
+	}
+}
+
+func TestFilters(t *testing.T) {
+	analyzer := analysis.NewAnalyzer(analysis.CJKBigramTokenizer{}, analysis.WidthFilter{}, analysis.LowercaseFilter{},
+		analysis.NewStopFilter(analysis.DefaultStopWords...), analysis.NewSynonymFilter([]string{"golang", "go"}))
+	text := "ＧＯＬＡＮＧ的 And 教程"
+	expect := "golang[0,18)@0 go[0,18)@0 教程[26,32)@3" //“的”和“and”是停用词
+	if s := tokensString(t, text, analyzer.Analyze(text), false); s != expect {
+		t.Fatalf("分词结果错误\n期望%s\n实际%s", expect, s)
+	}
+}
+
+func TestAnalyzeQuery(t *testing.T) {
+	keyword := analysis.NewAnalyzer(analysis.KeywordTokenizer{}, analysis.LowercaseFilter{})
+	analyzers := analysis.NewPerFieldAnalyzer(analysis.NewAnalyzer(analysis.CJKBigramTokenizer{}, analysis.LowercaseFilter{}, analysis.NewSynonymFilter([]string{"go", "golang"}))).
+		WithField("author", keyword)
+	kw := types.NewTermQuery
+	query := kw("title", "Go搜索引擎").And(kw("author", "Linux小楠")).Not(kw("title", "java"))
+	expect := &types.TermQuery{Must: []*types.TermQuery{
+		{Must: []*types.TermQuery{
+			{Must: []*types.TermQuery{kw("title", "go").Or(kw("title", "golang")), kw("title", "搜索"), kw("title", "索引"), kw("title", "引擎")}},
+			kw("author", "linux小楠"),
+		}},
+	}, MustNot: []*types.TermQuery{kw("title", "java")}}
+	if got := analyzers.AnalyzeQuery(query); got.ToString() != expect.ToString() {
+		t.Fatalf("查询处理错误\n期望%s\n实际%s", expect.ToString(), got.ToString())
+	}
+	if got := analyzers.AnalyzeQuery(types.NewPhraseQuery("title", "搜索引擎", "Go")); got.ToString() != types.NewPhraseQuery("title", "搜索", "索引", "引擎", "go").ToString() {
+		t.Fatalf("短语处理错误 %s", got.ToString())
+	}
+	if got := analyzers.AnalyzeQuery(types.NewPrefixQuery("author", "LINUX")); got.Prefix.Word != "linux" {
+		t.Fatalf("前缀处理错误 %s", got.ToString())
+	}
+	if query.Must[0].Must[0].Keyword.Word != "Go搜索引擎" {
+		t.Fatal("不应修改原来的查询")
+	}
+}
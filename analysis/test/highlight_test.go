@@ -0,0 +1,60 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/types"
+	"reflect"
+	"testing"
+)
+
+func TestHighlight(t *testing.T) {
+	analyzer := analysis.NewAnalyzer(analysis.CJKBigramTokenizer{}, analysis.LowercaseFilter{})
+	highlighter := analysis.NewHighlighter(analyzer)
+	text := "Go语言<搜索引擎>教程"
+	//搜索、索引、引擎相互重叠，合并成一段；MustNot和其他field的词不高亮
+	query := types.NewTermQuery("title", "搜索").And(types.NewTermQuery("title", "索引"), types.NewTermQuery("title", "引擎"), types.NewTermQuery("title", "go")).
+		Not(types.NewTermQuery("title", "教程")).
+		Or(types.NewTermQuery("content", "语言"))
+	terms := analysis.QueryTerms(query, "title")
+	expect := []string{"<em>Go</em>语言&lt;<em>搜索引擎</em>&gt;教程"}
+	if fragments := highlighter.Highlight(text, terms); !reflect.DeepEqual(fragments, expect) {
+		t.Fatalf("高亮结果错误\n期望%v\n实际%v", expect, fragments)
+	}
+	if fragments := highlighter.Highlight("Java入门", terms); fragments != nil {
+		t.Fatalf("没有命中的词时应返回nil，实际%v", fragments)
+	}
+
+	//自定义标签，前缀查询
+	highlighter = analysis.NewHighlighter(analyzer).WithTags("[", "]")
+	prefix := &types.TermQuery{Prefix: &types.Keyword{Field: "title", Word: "gola"}}
+	expect = []string{"学[Golang]"} //保留原文的大小写
+	if fragments := highlighter.Highlight("学Golang", analysis.QueryTerms(prefix, "title")); !reflect.DeepEqual(fragments, expect) {
+		t.Fatalf("前缀高亮结果错误\n期望%v\n实际%v", expect, fragments)
+	}
+
+	//通配符和模糊查询，按字符匹配
+	wildcard := types.NewWildcardQuery("title", "g?l*g").Or(types.NewFuzzyQuery("title", "搜锁", 1))
+	expect = []string{"[golang]的[搜索]引擎"} //引擎跟搜锁差两个字，不算命中
+	if fragments := highlighter.Highlight("golang的搜索引擎", analysis.QueryTerms(wildcard, "title")); !reflect.DeepEqual(fragments, expect) {
+		t.Fatalf("通配符和模糊查询高亮结果错误\n期望%v\n实际%v", expect, fragments)
+	}
+}
+
+func TestHighlightFragments(t *testing.T) {
+	analyzer := analysis.NewAnalyzer(analysis.CJKBigramTokenizer{})
+	terms := analysis.QueryTerms(types.NewTermQuery("title", "引擎").Or(types.NewTermQuery("title", "教程")), "title")
+	text := "一二三四五六七八九十引擎一二三四五六七八九十教程"
+	for _, c := range []struct {
+		size, max int
+		expect    []string
+	}{
+		{6, 0, []string{"九十<em>引擎</em>一二", "七八九十<em>教程</em>"}}, //命中的词放在片段中间，靠近结尾时往前延伸
+		{6, 1, []string{"九十<em>引擎</em>一二"}},
+		{30, 0, []string{"一二三四五六七八九十<em>引擎</em>一二三四五六七八九十<em>教程</em>"}}, //原文比片段短
+	} {
+		highlighter := analysis.NewHighlighter(analyzer).WithFragmentSize(c.size).WithMaxFragments(c.max)
+		if fragments := highlighter.Highlight(text, terms); !reflect.DeepEqual(fragments, c.expect) {
+			t.Fatalf("片段长度%d最多%d个\n期望%v\n实际%v", c.size, c.max, c.expect, fragments)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSynonymsRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(path, []byte("# 注释\n\ngolang, go语言 ,go\npython\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	synonyms, err := analysis.LoadSynonyms(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synonyms.Close()
+
+	leaf := types.NewTermQuery("content", "go语言")
+	leaf.Boost = 2
+	query := leaf.And(types.NewTermQuery("content", "python")).Not(types.NewTermQuery("content", "golang"))
+	before := query.ToString()
+	expanded := make([]*types.TermQuery, 0, 3)
+	for _, word := range []string{"golang", "go语言", "go"} {
+		q := types.NewTermQuery("content", word)
+		q.Boost = 2
+		expanded = append(expanded, q)
+	}
+	expect := (&types.TermQuery{Should: expanded}).And(types.NewTermQuery("content", "python")).
+		Not(types.NewTermQuery("content", "golang").Or(types.NewTermQuery("content", "go语言"), types.NewTermQuery("content", "go"))).ToString()
+	if s := synonyms.Rewrite(query).ToString(); s != expect {
+		t.Fatalf("改写结果错误\n期望%s\n实际%s", expect, s)
+	}
+	if query.ToString() != before {
+		t.Fatal("改写不能修改原来的查询")
+	}
+	//没有同义词时返回原查询，只有一个词的行被忽略
+	unchanged := types.NewTermQuery("content", "python").Or(types.NewTermQuery("title", "java"))
+	if synonyms.Rewrite(unchanged) != unchanged {
+		t.Fatal("没有同义词时应返回原查询")
+	}
+	var none *analysis.Synonyms
+	if none.Rewrite(leaf) != leaf {
+		t.Fatal("nil同义词表不应改写查询")
+	}
+}
+
+func TestSynonymsReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(path, []byte("golang,go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	synonyms, err := analysis.LoadSynonyms(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synonyms.Close()
+	if n := len(synonyms.Synonyms("go")); n != 2 {
+		t.Fatalf("go应有2个同义词，实际%d", n)
+	}
+	if err := os.WriteFile(path, []byte("golang,go,go语言\npython,py\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(synonyms.Synonyms("py")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("修改文件后没有重新加载")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := len(synonyms.Synonyms("go")); n != 3 {
+		t.Fatalf("重新加载后go应有3个同义词，实际%d", n)
+	}
+	//文件被删除时保留原来的同义词表
+	os.Remove(path)
+	time.Sleep(50 * time.Millisecond)
+	if n := len(synonyms.Synonyms("go")); n != 3 {
+		t.Fatalf("文件删除后应保留原来的同义词表，实际go有%d个同义词", n)
+	}
+}
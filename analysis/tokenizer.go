@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 是否为中日韩文字。这些文字之间没有空格，需要单独切分
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// 字母、数字(不含中日韩文字)连在一起组成一个词
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+}
+
+// textRun 一段连续的同类字符
+type textRun struct {
+	text  string
+	start int //在原文中的起始位置，按字节计算
+	cjk   bool
+}
+
+// splitRuns 把文本切成连续的字母数字段和中日韩文字段，其他字符(空白、标点等)作为分隔符丢掉
+func splitRuns(text string) []textRun {
+	runs := make([]textRun, 0, 4)
+	start, kind := -1, 0 //kind 1:字母数字 2:中日韩文字
+	flush := func(end int) {
+		if start >= 0 {
+			runs = append(runs, textRun{text: text[start:end], start: start, cjk: kind == 2})
+			start = -1
+		}
+	}
+	for i, r := range text {
+		k := 0
+		if isCJK(r) {
+			k = 2
+		} else if isWordRune(r) {
+			k = 1
+		}
+		if k != kind {
+			flush(i)
+			kind = k
+		}
+		if k > 0 && start < 0 {
+			start = i
+		}
+	}
+	flush(len(text))
+	return runs
+}
+
+// KeywordTokenizer 整段文本(去掉首尾空白)作为一个词，用于标签、作者名这类不需要切分的field
+type KeywordTokenizer struct{}
+
+func (KeywordTokenizer) Tokenize(text string) []Token {
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) == 0 {
+		return nil
+	}
+	start := strings.Index(text, trimmed)
+	return []Token{{Text: trimmed, Start: start, End: start + len(trimmed)}}
+}
+
+// CJKBigramTokenizer 字母数字段整体作为一个词；中日韩文字段切成相互重叠的二元组，如“搜索引擎”切成“搜索”、“索引”、“引擎”，只有一个字时就是这个字。
+// 不需要词典，对新词友好，但词会比较多
+type CJKBigramTokenizer struct{}
+
+func (CJKBigramTokenizer) Tokenize(text string) []Token {
+	tokens := make([]Token, 0, len(text)/2)
+	for _, run := range splitRuns(text) {
+		if !run.cjk {
+			tokens = append(tokens, Token{Text: run.text, Start: run.start, End: run.start + len(run.text), Position: len(tokens)})
+			continue
+		}
+		offsets := runeOffsets(run.text)
+		if len(offsets) == 2 { //只有一个字
+			tokens = append(tokens, Token{Text: run.text, Start: run.start, End: run.start + len(run.text), Position: len(tokens)})
+			continue
+		}
+		for i := 0; i+2 < len(offsets); i++ {
+			tokens = append(tokens, Token{Text: run.text[offsets[i]:offsets[i+2]], Start: run.start + offsets[i], End: run.start + offsets[i+2], Position: len(tokens)})
+		}
+	}
+	return tokens
+}
+
+// 每个字符在字符串中的起始位置，最后再加上字符串的长度
+func runeOffsets(s string) []int {
+	offsets := make([]int, 0, utf8.RuneCountInString(s)+1)
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(s))
+}
@@ -0,0 +1,53 @@
+package demo
+
+import (
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/types"
+)
+
+var (
+	//标签、作者名整体作为一个词
+	keywordAnalyzer = analysis.NewAnalyzer(analysis.KeywordTokenizer{}, analysis.WidthFilter{}, analysis.LowercaseFilter{})
+	//标题切成二元组，不依赖词典
+	titleAnalyzer = analysis.NewAnalyzer(analysis.CJKBigramTokenizer{}, analysis.WidthFilter{}, analysis.LowercaseFilter{}, analysis.NewStopFilter(analysis.DefaultStopWords...))
+
+	// Analyzers 建索引和检索时，各个field使用相同的分词方式
+	Analyzers = analysis.NewPerFieldAnalyzer(titleAnalyzer).
+			WithField("content", keywordAnalyzer).
+			WithField("author", keywordAnalyzer)
+)
+
+// VideoHit 一条搜索结果。序列化成json时BiliVideo的字段平铺在外层
+type VideoHit struct {
+	*BiliVideo
+	Highlights []string `json:"Highlights,omitempty"` //标题里含有命中词的片段，命中的词已经用标签包起来
+}
+
+// TitleHighlighter 跟建索引时一样对标题分词来找命中的词。preTag、postTag都为空时用<em></em>
+func TitleHighlighter(preTag, postTag string) *analysis.Highlighter {
+	highlighter := analysis.NewHighlighter(titleAnalyzer)
+	if len(preTag) > 0 || len(postTag) > 0 {
+		highlighter.WithTags(preTag, postTag)
+	}
+	return highlighter
+}
+
+// HighlightVideos 用实际执行的查询里title上的词给每个视频的标题加高亮
+func HighlightVideos(videos []*BiliVideo, query *types.TermQuery, highlighter *analysis.Highlighter) []*VideoHit {
+	terms := analysis.QueryTerms(query, "title")
+	hits := make([]*VideoHit, 0, len(videos))
+	for _, video := range videos {
+		hits = append(hits, &VideoHit{BiliVideo: video, Highlights: highlighter.Highlight(video.Title, terms)})
+	}
+	return hits
+}
+
+// KeywordQuery 用户输入的一个关键词，命中标签或标题都可以
+func KeywordQuery(word string) *types.TermQuery {
+	return Analyzers.AnalyzeQuery(types.NewTermQuery("content", word).Or(types.NewTermQuery("title", word)))
+}
+
+// AuthorQuery 作者名
+func AuthorQuery(author string) *types.TermQuery {
+	return Analyzers.AnalyzeQuery(types.NewTermQuery("author", author))
+}
@@ -20,6 +20,9 @@ const (
 	BIAN_CHENG
 )
 
+// ClassNames 各类别的名称，下标是该类别在BitsFeature上对应的bit
+var ClassNames = [...]string{"资讯", "社会", "热点", "生活", "知识", "环球", "游戏", "综合", "日常", "影视", "动画", "科技", "娱乐", "编程"}
+
 // GetClassBits 从Keywords中提取类型，用bits表示类别
 func GetClassBits(keywords []string) uint64 {
 	var bits uint64
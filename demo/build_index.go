@@ -28,6 +28,7 @@ func BuildIndexFromFile(csvFile string, indexer index_service.IIndexer, totalWor
 	loc, _ := time.LoadLocation("Asia/Shanghai")
 	reader := csv.NewReader(file)
 	progress := 0
+	batch := make([]types.Document, 0, buildBatchSize)
 	for {
 		record, err := reader.Read()
 		if err != nil {
@@ -81,31 +82,71 @@ func BuildIndexFromFile(csvFile string, indexer index_service.IIndexer, totalWor
 				}
 			}
 		}
-		AddVideo2Index(video, indexer) //构建好BiliVideo实体，写入索引
-		progress++
+		doc, err := VideoDocument(video) //构建好BiliVideo实体，攒够一批再写入索引
+		if err != nil {
+			log.Printf("serielize video failed: %s", err)
+			continue
+		}
+		batch = append(batch, doc)
+		if len(batch) >= buildBatchSize {
+			progress += addBatch(indexer, batch)
+			batch = batch[:0]
+		}
 	}
+	progress += addBatch(indexer, batch)
 	util.Log.Printf("add %d documents to index totally", progress)
 }
 
+// 建索引时每批写入的文档数
+const buildBatchSize = 1000
+
+func addBatch(indexer index_service.IIndexer, batch []types.Document) int {
+	if len(batch) == 0 {
+		return 0
+	}
+	n, errs := indexer.AddDocs(batch)
+	for i, err := range errs {
+		if err != nil {
+			log.Printf("add video %s failed: %s", batch[i].Id, err)
+		}
+	}
+	return n
+}
+
 // AddVideo2Index 把一条视频信息写入索引（可能是create，也可能是update）
 // 实时更新索引时可调该函数
 func AddVideo2Index(video *BiliVideo, indexer index_service.IIndexer) {
-	doc := types.Document{Id: video.Id}
-	bs, err := proto.Marshal(video)
+	doc, err := VideoDocument(video)
 	if err != nil {
 		log.Printf("serielize video failed: %s", err)
 		return
 	}
+	indexer.AddDoc(doc)
+}
+
+// VideoDocument 把视频转成索引里的文档
+func VideoDocument(video *BiliVideo) (types.Document, error) {
+	doc := types.Document{Id: video.Id}
+	bs, err := proto.Marshal(video)
+	if err != nil {
+		return doc, err
+	}
 	doc.Bytes = bs
-	keywords := make([]*types.Keyword, 0, len(video.Keywords))
+	keywords := make([]*types.Keyword, 0, len(video.Keywords)+len(video.Title)/3+1)
 	for _, word := range video.Keywords {
-		keywords = append(keywords, &types.Keyword{Field: "content", Word: strings.ToLower(word)})
-
+		keywords = append(keywords, Analyzers.Keywords("content", word)...)
 	}
+	keywords = append(keywords, Analyzers.Keywords("title", video.Title)...) //只出现在标题里的词也能搜到
+	keywords = append(keywords, Analyzers.Keywords("author", video.Author)...)
 	doc.Keywords = keywords
-	if len(video.Author) > 0 {
-		keywords = append(keywords, &types.Keyword{Field: "author", Word: strings.ToLower(strings.TrimSpace(video.Author))})
-	}
 	doc.BitsFeature = GetClassBits(video.Keywords)
-	indexer.AddDoc(doc)
+	doc.Numerics = map[string]int64{ //Range查询在倒排索引上直接过滤这些字段
+		"view":      int64(video.View),
+		"like":      int64(video.Like),
+		"coin":      int64(video.Coin),
+		"favorite":  int64(video.Favorite),
+		"share":     int64(video.Share),
+		"post_time": video.PostTime,
+	}
+	return doc, nil
 }
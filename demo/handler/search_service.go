@@ -28,6 +28,20 @@ func cleanKeyword(words []string) []string {
 	return keywords
 }
 
+// 把搜索请求翻译成倒排索引上的查询和类别过滤条件。查询语句有语法错误时返回error
+func buildQuery(request *demo.SearchRequest) (*types.TermQuery, []uint64, error) {
+	var base *types.TermQuery
+	if len(strings.TrimSpace(request.Query)) > 0 {
+		parsed, err := types.ParseQuery(request.Query, "content")
+		if err != nil {
+			return nil, nil, err
+		}
+		base = demo.Analyzers.AnalyzeQuery(parsed) //跟建索引时一样分词
+	}
+	query, orFlags := request.ToQuery(base, request.Author)
+	return query, orFlags, nil
+}
+
 // 搜索接口
 func Search(ctx *gin.Context) {
 	var request demo.SearchRequest
@@ -37,35 +51,35 @@ func Search(ctx *gin.Context) {
 		return
 	}
 
-	keywords := cleanKeyword(request.Keywords)
-	if len(keywords) == 0 && len(request.Author) == 0 {
-		ctx.String(http.StatusBadRequest, "关键词和作者不能同时为空")
+	request.Keywords = cleanKeyword(request.Keywords)
+	request.Excludes = cleanKeyword(request.Excludes)
+	if len(request.Keywords) == 0 && len(request.Author) == 0 && len(strings.TrimSpace(request.Query)) == 0 {
+		ctx.String(http.StatusBadRequest, "查询语句、关键词和作者不能同时为空")
 		return
 	}
-	query := new(types.TermQuery)
-	if len(keywords) > 0 {
-		for _, word := range keywords {
-			//满足关键词
-			query = query.And(types.NewTermQuery("content", word))
-		}
-	}
-	if len(request.Author) > 0 {
-		query = query.And(types.NewTermQuery("author", strings.ToLower(request.Author)))
+	query, orFlags, err := buildQuery(&request)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
 	}
-	//满足类别
-	orFlags := []uint64{demo.GetClassBits(request.Classes)}
-	docs := Indexer.Search(query, 0, 0, orFlags)
-	videos := make([]demo.BiliVideo, 0, len(docs))
-	for _, doc := range docs {
+	result := Indexer.SearchPage(&index_service.SearchRequest{
+		Query:   query,
+		OrFlags: orFlags,
+		Offset:  uint32(max(request.Offset, 0)),
+		Limit:   uint32(max(request.Limit, 0)),
+		SortBy:  &index_service.SortBy{Field: request.SortBy, Asc: request.Asc},
+	})
+	videos := make([]*demo.BiliVideo, 0, len(result.Result))
+	for _, doc := range result.Result {
 		var video demo.BiliVideo
 		if err := proto.Unmarshal(doc.Bytes, &video); err == nil {
-			if video.View >= int32(request.ViewFrom) && (request.ViewTo <= 0 || video.View <= int32(request.ViewTo)) { //满足播放量的区间范围
-				videos = append(videos, video)
-			}
+			videos = append(videos, &video)
 		}
 	}
 	util.Log.Printf("return %d videos", len(videos))
-	ctx.JSON(http.StatusOK, videos) //把搜索结果以json形式返回给前端
+	//按实际执行的查询高亮，通过同义词或模糊匹配命中的词也能标出来
+	hits := demo.HighlightVideos(videos, result.Query, demo.TitleHighlighter(request.PreTag, request.PostTag))
+	ctx.JSON(http.StatusOK, hits) //把搜索结果以json形式返回给前端
 }
 
 // 搜索全站视频
@@ -78,6 +92,7 @@ func SearchAll(ctx *gin.Context) {
 	}
 
 	request.Keywords = cleanKeyword(request.Keywords)
+	request.Excludes = cleanKeyword(request.Excludes)
 	if len(request.Keywords) == 0 && len(request.Author) == 0 {
 		ctx.String(http.StatusBadRequest, "关键词和作者不能同时为空")
 		return
@@ -103,6 +118,7 @@ func SearchByAuthor(ctx *gin.Context) {
 	}
 
 	request.Keywords = cleanKeyword(request.Keywords)
+	request.Excludes = cleanKeyword(request.Excludes)
 	if len(request.Keywords) == 0 {
 		ctx.String(http.StatusBadRequest, "关键词不能为空")
 		return
@@ -123,3 +139,33 @@ func SearchByAuthor(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, videos) //把搜索结果以json形式返回给前端
 }
+
+// 分面统计：检索结果在各个类别上、以及各个作者名下分别有多少个视频
+func Facet(ctx *gin.Context) {
+	var request demo.SearchRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		log.Printf("bind request parameter failed: %s", err)
+		ctx.String(http.StatusBadRequest, "invalid json")
+		return
+	}
+
+	request.Keywords = cleanKeyword(request.Keywords)
+	request.Excludes = cleanKeyword(request.Excludes)
+	if len(request.Keywords) == 0 && len(request.Author) == 0 && len(strings.TrimSpace(request.Query)) == 0 {
+		ctx.String(http.StatusBadRequest, "查询语句、关键词和作者不能同时为空")
+		return
+	}
+	query, orFlags, err := buildQuery(&request)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	result := Indexer.Facet(&index_service.FacetRequest{Query: query, OrFlags: orFlags, Field: "author", TopN: 10})
+	classes := make(map[string]int64, len(demo.ClassNames))
+	for bit, name := range demo.ClassNames {
+		if bit < len(result.BitCounts) && result.BitCounts[bit] > 0 {
+			classes[name] = result.BitCounts[bit]
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"total": result.Total, "classes": classes, "authors": result.Values})
+}
@@ -25,7 +25,7 @@ func GrpcIndexerInit() {
 	server := grpc.NewServer()
 	service = new(index_service.IndexServiceWorker)
 	//初始化索引
-	service.Init(50000, dbType, *dbPath+"_part"+strconv.Itoa(*workerIndex))
+	service.Init(50000, dbType, reverseIndexType, *dbPath+"_part"+strconv.Itoa(*workerIndex))
 	if *rebuildIndex {
 		util.Log.Printf("totalWorkers=%d, workerIndex=%d", *totalWorkers, *workerIndex)
 		demo.BuildIndexFromFile(csvFile, service.Indexer, *totalWorkers, *workerIndex) //重建索引
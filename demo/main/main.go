@@ -4,6 +4,7 @@ import (
 	"flag"
 	"github.com/Muoshu/myRadic/demo/handler"
 	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
 	"github.com/Muoshu/myRadic/util"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -20,9 +21,10 @@ var (
 )
 
 var (
-	dbType      = kvdb.BOLT                             //正排索引使用哪种KV数据库
-	csvFile     = util.RootPath + "data/bili_video.csv" //原始的数据文件，由它来创建索引
-	etcdServers = []string{"127.0.0.1:2379"}            //etcd集群的地址
+	dbType           = kvdb.BOLT                             //正排索引使用哪种KV数据库
+	reverseIndexType = reverseindex.SKIPLIST                 //倒排索引使用哪种实现。SEGMENT会把倒排索引持久化到磁盘，重启时不需要重建
+	csvFile          = util.RootPath + "data/bili_video.csv" //原始的数据文件，由它来创建索引
+	etcdServers      = []string{"127.0.0.1:2379"}            //etcd集群的地址
 )
 
 func StartGin() {
@@ -47,6 +49,7 @@ func StartGin() {
 	//engine.POST("/search", handler.Search)
 	engine.POST("/search", handler.SearchAll)
 	engine.POST("/up_search", handler.SearchByAuthor)
+	engine.POST("/facet", handler.Facet)
 	engine.Run("127.0.0.1:" + strconv.Itoa(*port))
 }
 
@@ -1,20 +1,35 @@
 package main
 
 import (
+	"github.com/Muoshu/myRadic/analysis"
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/demo/handler"
 	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/util"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+var synonymsFile = util.RootPath + "data/synonyms.txt" //同义词表，修改后自动重新加载
+
+// 加载同义词表，失败时不做同义词扩展
+func loadSynonyms() *analysis.Synonyms {
+	synonyms, err := analysis.LoadSynonyms(synonymsFile, 10*time.Second)
+	if err != nil {
+		util.Log.Printf("load synonyms file %s failed: %s", synonymsFile, err)
+		return nil
+	}
+	return synonyms
+}
+
 func WebServerInit(mode int) {
 	switch mode {
 	case 1:
 		//单机索引
-		standaloneIndexer := new(index_service.Indexer)
-		if err := standaloneIndexer.Init(50000, dbType, *dbPath); err != nil {
+		standaloneIndexer := new(index_service.Indexer).WithSynonyms(loadSynonyms())
+		if err := standaloneIndexer.Init(50000, dbType, reverseIndexType, *dbPath); err != nil {
 			panic(err)
 		}
 		if *rebuildIndex {
@@ -25,7 +40,7 @@ func WebServerInit(mode int) {
 		}
 		handler.Indexer = standaloneIndexer
 	case 3:
-		handler.Indexer = index_service.NewSentinel(etcdServers)
+		handler.Indexer = index_service.NewSentinel(etcdServers).WithSynonyms(loadSynonyms())
 	default:
 		panic("invalid mode")
 
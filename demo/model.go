@@ -1,9 +1,64 @@
 package demo
 
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math"
+)
+
 type SearchRequest struct {
+	Query    string //查询语句，如 golang AND (author:"linux小楠" OR go) -java，省略field时为content，语法见types.ParseQuery
 	Author   string
 	Classes  []string //类别，命中一个即可
 	Keywords []string //关键词，必须全部命中
+	Excludes []string //排除的关键词，一个都不能命中
 	ViewFrom int      //视频播放量下限
 	ViewTo   int      //视频播放量上限
+	Offset   int      //分页，跳过前面这么多个视频
+	Limit    int      //分页，最多返回这么多个视频，为0时不限
+	SortBy   string   //按哪个数值字段排序，如view、post_time，为空时按相关性
+	Asc      bool     //默认从大到小
+	PreTag   string   //标题里命中的词用PreTag和PostTag包起来，都为空时用<em></em>
+	PostTag  string
+}
+
+// ViewRange 播放量区间对应的Range查询，在倒排索引上过滤。上下限都没给、或者下限比上限还大时返回nil，即不限播放量
+func (req *SearchRequest) ViewRange() *types.TermQuery {
+	if req.ViewFrom <= 0 && req.ViewTo <= 0 {
+		return nil
+	}
+	max := int64(math.MaxInt64)
+	if req.ViewTo > 0 {
+		max = int64(req.ViewTo)
+	}
+	if int64(req.ViewFrom) > max {
+		return nil
+	}
+	return types.NewRangeQuery("view", int64(req.ViewFrom), max)
+}
+
+// ToQuery 把请求里的关键词、作者、播放量区间和排除词追加到base上(base为nil时从空查询开始)，同时给出类别对应的orFlags。
+// 作者由调用方给出，up主在后台搜索时用的是登录用户名而不是请求里的Author。关键词和排除词需事先去掉首尾空格
+func (req *SearchRequest) ToQuery(base *types.TermQuery, author string) (*types.TermQuery, []uint64) {
+	query := base
+	if query == nil {
+		query = new(types.TermQuery)
+	}
+	for _, word := range req.Keywords {
+		query = query.And(KeywordQuery(word)) //满足关键词
+	}
+	if len(author) > 0 {
+		query = query.And(AuthorQuery(author)) //满足作者
+	}
+	if viewRange := req.ViewRange(); viewRange != nil {
+		query = query.And(viewRange) //满足播放量的区间范围，在倒排索引上过滤，不满足的不用再从正排索引里取出来
+	}
+	//排除关键词
+	excludes := make([]*types.TermQuery, 0, len(req.Excludes))
+	for _, word := range req.Excludes {
+		excludes = append(excludes, KeywordQuery(word))
+	}
+	query = query.Not(excludes...)
+	//满足类别
+	orFlags := []uint64{GetClassBits(req.Classes)}
+	return query, orFlags
 }
@@ -0,0 +1,36 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/demo"
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"testing"
+)
+
+// 只出现在标题里的词、全角或大写的标签和作者名都能搜到
+func TestAnalyzedSearch(t *testing.T) {
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, t.TempDir()+"/db"); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Close()
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "1", Title: "从零开始的搜索引擎教程", Author: "Linux小楠", Keywords: []string{"Golang", "编程"}}, indexer)
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "2", Title: "Java入门", Author: "张三", Keywords: []string{"java"}}, indexer)
+	for _, c := range []struct {
+		name   string
+		expect int
+		docs   int
+	}{
+		{"搜索引擎", 1, len(indexer.Search(demo.KeywordQuery("搜索引擎"), 0, 0, nil))},
+		{"ＧＯＬＡＮＧ", 1, len(indexer.Search(demo.KeywordQuery("ＧＯＬＡＮＧ"), 0, 0, nil))},
+		{"java", 1, len(indexer.Search(demo.KeywordQuery("java"), 0, 0, nil))},
+		{"引擎教", 1, len(indexer.Search(demo.KeywordQuery("引擎教"), 0, 0, nil))},
+		{"搜索教程", 0, len(indexer.Search(demo.KeywordQuery("搜索教程"), 0, 0, nil))},
+		{"linux小楠", 1, len(indexer.Search(demo.AuthorQuery("linux小楠"), 0, 0, nil))},
+	} {
+		if c.docs != c.expect {
+			t.Fatalf("%s 应命中%d个视频，实际%d个", c.name, c.expect, c.docs)
+		}
+	}
+}
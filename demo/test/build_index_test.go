@@ -4,27 +4,27 @@ import (
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/index_service"
 	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
 	"github.com/Muoshu/myRadic/util"
-	"os"
+	"path/filepath"
 	"testing"
 )
 
 var (
 	dbType  = kvdb.BOLT
-	dbPath  = util.RootPath + "data/local_db/video_bolt"
 	indexer *index_service.Indexer
 )
 
-func Init() {
-	os.Remove(dbPath) //先删除原有的索引文件
+// 索引建在临时目录里，跑测试不会改动仓库里的文件
+func Init(t *testing.T) {
 	indexer = new(index_service.Indexer)
-	if err := indexer.Init(50000, dbType, dbPath); err != nil {
-		panic(err)
+	if err := indexer.Init(50000, dbType, reverseindex.SKIPLIST, filepath.Join(t.TempDir(), "video_bolt")); err != nil {
+		t.Fatal(err)
 	}
 }
 
 func TestBuildIndexFromFile(t *testing.T) {
-	Init()
+	Init(t)
 	defer indexer.Close()
 	csvFile := util.RootPath + "data/bili_video.csv"
 	demo.BuildIndexFromFile(csvFile, indexer, 0, 0)
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/demo"
+	"github.com/Muoshu/myRadic/demo/video_search"
+	"github.com/Muoshu/myRadic/demo/video_search/common"
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 搜索结果带上标题的高亮片段，命中的词来自召回时实际执行的查询
+func TestSearchHighlight(t *testing.T) {
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, t.TempDir()+"/db"); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Close()
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "1", Title: "从零开始的搜索引擎教程", Author: "张三", Keywords: []string{"golang"}}, indexer)
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "2", Title: "Golang入门", Author: "李四", Keywords: []string{"搜索引擎"}}, indexer)
+
+	searchCtx := &common.VideoSearchContext{
+		Ctx:     context.Background(),
+		Request: &demo.SearchRequest{Keywords: []string{"搜索引擎"}, PreTag: "<b>", PostTag: "</b>"},
+		Indexer: indexer,
+	}
+	hits := video_search.NewAllVideoSearcher().Search(searchCtx)
+	highlights := make(map[string][]string, len(hits))
+	for _, hit := range hits {
+		highlights[hit.Id] = hit.Highlights
+	}
+	expect := map[string][]string{
+		"1": {"从零开始的<b>搜索引擎</b>教程"},
+		"2": nil, //只有标签命中，标题里没有命中的词
+	}
+	if !reflect.DeepEqual(highlights, expect) {
+		t.Fatalf("高亮结果错误\n期望%v\n实际%v", expect, highlights)
+	}
+}
+
+// 通过同义词命中的标题也要高亮，高亮用的是索引实际执行的查询
+func TestSearchHighlightSynonyms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(path, []byte("教程,入门\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	synonyms, err := analysis.LoadSynonyms(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synonyms.Close()
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, t.TempDir()+"/db"); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Close()
+	indexer.WithSynonyms(synonyms)
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "1", Title: "搜索引擎教程", Author: "张三"}, indexer)
+	demo.AddVideo2Index(&demo.BiliVideo{Id: "2", Title: "Golang入门", Author: "李四"}, indexer)
+
+	searchCtx := &common.VideoSearchContext{
+		Ctx:     context.Background(),
+		Request: &demo.SearchRequest{Keywords: []string{"教程"}},
+		Indexer: indexer,
+	}
+	hits := video_search.NewAllVideoSearcher().Search(searchCtx)
+	highlights := make(map[string][]string, len(hits))
+	for _, hit := range hits {
+		highlights[hit.Id] = hit.Highlights
+	}
+	expect := map[string][]string{
+		"1": {"搜索引擎<em>教程</em>"},
+		"2": {"Golang<em>入门</em>"},
+	}
+	if !reflect.DeepEqual(highlights, expect) {
+		t.Fatalf("高亮结果错误\n期望%v\n实际%v", expect, highlights)
+	}
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/demo"
+	"math"
+	"testing"
+)
+
+func TestViewRange(t *testing.T) {
+	cases := []struct {
+		from, to int
+		min, max int64
+		ignored  bool
+	}{
+		{0, 0, 0, 0, true},
+		{100, 0, 100, math.MaxInt64, false},
+		{0, 100, 0, 100, false},
+		{100, 200, 100, 200, false},
+		{200, 100, 0, 0, true}, //下限比上限大，当作不限播放量
+	}
+	for _, c := range cases {
+		req := demo.SearchRequest{ViewFrom: c.from, ViewTo: c.to}
+		q := req.ViewRange()
+		if c.ignored {
+			if q != nil {
+				t.Fatalf("[%d,%d]不应生成Range查询 %s", c.from, c.to, q.ToString())
+			}
+			continue
+		}
+		if q == nil || q.Range == nil || q.Range.Min != c.min || q.Range.Max != c.max {
+			t.Fatalf("[%d,%d]生成的Range查询错误 %v", c.from, c.to, q)
+		}
+	}
+}
+
+func TestToQuery(t *testing.T) {
+	req := demo.SearchRequest{Keywords: []string{"go"}, Excludes: []string{"java"}, Classes: []string{"资讯"}, ViewFrom: 100, ViewTo: 200}
+	query, orFlags := req.ToQuery(nil, "linux小楠")
+	expect := demo.KeywordQuery("go").And(demo.AuthorQuery("linux小楠")).And(req.ViewRange()).Not(demo.KeywordQuery("java"))
+	if query.ToString() != expect.ToString() {
+		t.Fatalf("查询应为%s，实际为%s", expect.ToString(), query.ToString())
+	}
+	if len(orFlags) != 1 || orFlags[0] != demo.GetClassBits(req.Classes) {
+		t.Fatalf("orFlags错误 %v", orFlags)
+	}
+	//在base的基础上追加条件，作者为空时不限作者
+	base := demo.AuthorQuery("golang")
+	query, _ = req.ToQuery(base, "")
+	expect = base.And(demo.KeywordQuery("go")).And(req.ViewRange()).Not(demo.KeywordQuery("java"))
+	if query.ToString() != expect.ToString() {
+		t.Fatalf("查询应为%s，实际为%s", expect.ToString(), query.ToString())
+	}
+}
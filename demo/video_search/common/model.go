@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/types"
+	"sync"
 )
 
 type VideoSearchContext struct {
@@ -11,6 +13,27 @@ type VideoSearchContext struct {
 	Indexer index_service.IIndexer //索引。可能是本地的Indexer，也可能是分布式的Sentinel
 	Request *demo.SearchRequest    //搜索请求
 	Videos  []*demo.BiliVideo      //搜索结果
+	Hits    []*demo.VideoHit       //加上高亮之后的搜索结果
+
+	lock    sync.Mutex
+	queries []*types.TermQuery //各路召回实际执行的查询
+}
+
+// AddQuery 召回时记录实际执行的查询，用于高亮。各路召回并行执行，需要加锁
+func (ctx *VideoSearchContext) AddQuery(query *types.TermQuery) {
+	if query == nil {
+		return
+	}
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	ctx.queries = append(ctx.queries, query)
+}
+
+// Query 各路召回执行过的查询，任意一个命中即可
+func (ctx *VideoSearchContext) Query() *types.TermQuery {
+	ctx.lock.Lock()
+	defer ctx.lock.Unlock()
+	return new(types.TermQuery).Or(ctx.queries...)
 }
 
 type UN string
@@ -3,9 +3,8 @@ package recaller
 import (
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/demo/video_search/common"
-	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/index_service"
 	"github.com/gogo/protobuf/proto"
-	"strings"
 )
 
 type KeywordRecaller struct {
@@ -20,24 +19,11 @@ func (KeywordRecaller) Recall(ctx *common.VideoSearchContext) []*demo.BiliVideo
 	if indexer == nil {
 		return nil
 	}
-	keywords := req.Keywords
-	query := new(types.TermQuery)
-	if len(keywords) > 0 {
-		for _, word := range keywords {
-			//满足关键词
-			query = query.And(types.NewTermQuery("content", word))
-		}
-	}
-
-	if len(req.Author) > 0 {
-		// 满足作者
-		query = query.And(types.NewTermQuery("author", strings.ToLower(req.Author)))
-	}
-	//满足类别
-	orFlags := []uint64{demo.GetClassBits(req.Classes)}
-	docs := indexer.Search(query, 0, 0, orFlags)
-	videos := make([]*demo.BiliVideo, 0, len(docs))
-	for _, doc := range docs {
+	query, orFlags := req.ToQuery(nil, req.Author)
+	result := indexer.SearchPage(&index_service.SearchRequest{Query: query, OrFlags: orFlags})
+	ctx.AddQuery(result.Query) //同义词改写和展开之后的查询
+	videos := make([]*demo.BiliVideo, 0, len(result.Result))
+	for _, doc := range result.Result {
 		var video demo.BiliVideo
 		if err := proto.Unmarshal(doc.Bytes, &video); err == nil {
 			videos = append(videos, &video)
@@ -3,9 +3,8 @@ package recaller
 import (
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/demo/video_search/common"
-	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/index_service"
 	"github.com/gogo/protobuf/proto"
-	"strings"
 )
 
 type KeywordAuthorRecaller struct {
@@ -20,26 +19,15 @@ func (KeywordAuthorRecaller) Recall(ctx *common.VideoSearchContext) []*demo.Bili
 	if indexer == nil {
 		return nil
 	}
-	keywords := req.Keywords
-	query := new(types.TermQuery)
-	if len(keywords) > 0 {
-		for _, word := range keywords {
-			query = query.And(types.NewTermQuery("content", word)) //满足关键词
-		}
-	}
-
-	v := ctx.Ctx.Value(common.UN("user_name"))
-	if v != nil {
-		if author, ok := v.(string); ok {
-			if len(author) > 0 {
-				query = query.And(types.NewTermQuery("author", strings.ToLower(author)))
-			}
-		}
+	author := ""
+	if v, ok := ctx.Ctx.Value(common.UN("user_name")).(string); ok {
+		author = v //只搜登录用户自己的视频
 	}
-	orFlags := []uint64{demo.GetClassBits(req.Classes)} //满足类别
-	docs := indexer.Search(query, 0, 0, orFlags)
-	videos := make([]*demo.BiliVideo, 0, len(docs))
-	for _, doc := range docs {
+	query, orFlags := req.ToQuery(nil, author)
+	result := indexer.SearchPage(&index_service.SearchRequest{Query: query, OrFlags: orFlags})
+	ctx.AddQuery(result.Query) //同义词改写和展开之后的查询
+	videos := make([]*demo.BiliVideo, 0, len(result.Result))
+	for _, doc := range result.Result {
 		var video demo.BiliVideo
 		if err := proto.Unmarshal(doc.Bytes, &video); err == nil {
 			videos = append(videos, &video)
@@ -3,17 +3,15 @@ package video_search
 import (
 	"github.com/Muoshu/myRadic/demo"
 	"github.com/Muoshu/myRadic/demo/video_search/common"
-	"github.com/Muoshu/myRadic/demo/video_search/filter"
 	"github.com/Muoshu/myRadic/demo/video_search/recaller"
 	"github.com/Muoshu/myRadic/util"
-	"golang.org/x/exp/maps"
 	"reflect"
 	"sync"
 	"time"
 )
 
 type Recaller interface {
-	Recall(*common.VideoSearchContext) []*demo.BiliVideo
+	Recall(*common.VideoSearchContext) []*demo.BiliVideo //召回结果按相关性从高到低排序
 }
 
 type Filter interface {
@@ -39,40 +37,34 @@ func (searcher *VideoSearcher) Recall(searchContext *common.VideoSearchContext)
 		return
 	}
 
-	//并行多路召回
-	collection := make(chan *demo.BiliVideo, 1000)
+	//并行多路召回，每一路的结果单独存放，以保留各路召回内部的排序
+	results := make([][]*demo.BiliVideo, len(searcher.Recallers))
 	wg := sync.WaitGroup{}
 	wg.Add(len(searcher.Recallers))
-	for _, recaller := range searcher.Recallers {
-		go func(recaller Recaller) {
+	for i, recaller := range searcher.Recallers {
+		go func(i int, recaller Recaller) {
 			defer wg.Done()
 			rule := reflect.TypeOf(recaller).Name()
 			result := recaller.Recall(searchContext)
 			util.Log.Printf("recall %d docs by %s", len(result), rule)
-			for _, video := range result {
-				collection <- video
-			}
-		}(recaller)
+			results[i] = result
+		}(i, recaller)
 	}
+	wg.Wait()
 
-	//通过map合并多路找回的结果
-	videoMap := make(map[string]*demo.BiliVideo, 1000)
-	receiveFinish := make(chan struct{})
-	go func() {
-		for {
-			video, ok := <-collection
-			if !ok {
-				break
+	//按召回路的先后顺序合并结果，同一个视频只保留第一次出现的位置
+	videos := make([]*demo.BiliVideo, 0, 1000)
+	seen := make(map[string]struct{}, 1000)
+	for _, result := range results {
+		for _, video := range result {
+			if _, ok := seen[video.Id]; ok {
+				continue
 			}
-			videoMap[video.Id] = video
+			seen[video.Id] = struct{}{}
+			videos = append(videos, video)
 		}
-		receiveFinish <- struct{}{}
-	}()
-
-	wg.Wait()
-	close(collection)
-	<-receiveFinish
-	searchContext.Videos = maps.Values(videoMap)
+	}
+	searchContext.Videos = videos
 }
 
 // 顺序执行各个过滤规则
@@ -82,8 +74,17 @@ func (searcher *VideoSearcher) Filter(searchContext *common.VideoSearchContext)
 	}
 }
 
+// 用召回时实际执行的查询给标题加高亮
+func (searcher *VideoSearcher) Highlight(searchContext *common.VideoSearchContext) {
+	var preTag, postTag string
+	if req := searchContext.Request; req != nil {
+		preTag, postTag = req.PreTag, req.PostTag
+	}
+	searchContext.Hits = demo.HighlightVideos(searchContext.Videos, searchContext.Query(), demo.TitleHighlighter(preTag, postTag))
+}
+
 // 超类定义了一个算法的框架，在子类中重写特定的算法步骤（即recall和filter这2步）
-func (searcher *VideoSearcher) Search(searchContext *common.VideoSearchContext) []*demo.BiliVideo {
+func (searcher *VideoSearcher) Search(searchContext *common.VideoSearchContext) []*demo.VideoHit {
 	t1 := time.Now()
 
 	//召回
@@ -94,7 +95,9 @@ func (searcher *VideoSearcher) Search(searchContext *common.VideoSearchContext)
 	searcher.Filter(searchContext)
 	t3 := time.Now()
 	util.Log.Printf("after filter remain %d docs in %d ms", len(searchContext.Videos), t3.Sub(t2).Milliseconds())
-	return searchContext.Videos
+	//高亮
+	searcher.Highlight(searchContext)
+	return searchContext.Hits
 }
 
 // ALlVideoSearcher 子类
@@ -105,7 +108,6 @@ type ALlVideoSearcher struct {
 func NewAllVideoSearcher() *ALlVideoSearcher {
 	searcher := new(ALlVideoSearcher)
 	searcher.WithRecaller(recaller.KeywordRecaller{})
-	searcher.WithFilter(filter.ViewFilter{})
 	return searcher
 }
 
@@ -117,6 +119,5 @@ type UpVideoSearcher struct {
 func NewUpVideoSearcher() *UpVideoSearcher {
 	searcher := new(UpVideoSearcher)
 	searcher.WithRecaller(recaller.KeywordAuthorRecaller{})
-	searcher.WithFilter(filter.ViewFilter{})
 	return searcher
 }
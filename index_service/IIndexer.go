@@ -6,7 +6,13 @@ import "github.com/Muoshu/myRadic/types"
 type IIndexer interface {
 	AddDoc(doc types.Document) (int, error)
 	DeleteDoc(docId string) int
-	Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document
+	AddDocs(docs []types.Document) (int, []error) //返回成功写入的文档数，以及跟docs一一对应的错误
+	DeleteDocs(docIds []string) int
+	UpdateDoc(update *DocUpdate) (int, error)                                                         //按update.Mask修改已有文档的部分字段，IntId不变。返回修改的文档数，文档不存在时为0
+	Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document //按相关性得分从高到低排序
+	SearchPage(request *SearchRequest) *SearchResult                                                  //按request.SortBy排序，返回从第Offset个开始的Limit个，以及实际执行的查询
+	Facet(request *FacetRequest) *FacetResult                                                         //分面统计
+	CountMatches(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int         //命中的文档数，不读取文档
 	Count() int
 	Close() error
 }
@@ -0,0 +1,191 @@
+package index_service
+
+import (
+	"errors"
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+	"strings"
+	"sync/atomic"
+)
+
+// AddDocs 批量添加(亦是更新)文档，正排索引一次批量读、一次批量写，倒排索引同一个key只加一次锁。
+// 返回成功写入的文档数，errs[i]是docs[i]的错误，成功时为nil。同一批里docId重复时只写入最后一个
+func (indexer *Indexer) AddDocs(docs []types.Document) (int, []error) {
+	errs := make([]error, len(docs))
+	last := make(map[string]int, len(docs)) //每个docId最后一次出现的位置
+	for i := range docs {
+		docId := strings.TrimSpace(docs[i].Id)
+		switch {
+		case len(docId) == 0:
+			errs[i] = errors.New("doc id is empty")
+		case isMetaKey([]byte(docId)):
+			errs[i] = errors.New("doc id must not start with \\x00")
+		default:
+			last[docId] = i
+		}
+	}
+	keys := make([][]byte, 0, len(last))
+	indexes := make([]int, 0, len(last))
+	docIds := make([]string, 0, len(last))
+	for i := range docs {
+		if docId := strings.TrimSpace(docs[i].Id); errs[i] == nil && last[docId] == i {
+			keys = append(keys, []byte(docId))
+			indexes = append(indexes, i)
+			docIds = append(docIds, docId)
+		}
+	}
+	if len(keys) == 0 {
+		return 0, errs
+	}
+	defer indexer.docLocks.lock(docIds...)()
+
+	//先读出已有的文档，写入成功后再从倒排索引上删掉它们的posting
+	olds, err := indexer.forwardIndex.BatchGet(keys)
+	if err != nil {
+		return 0, fillErrors(errs, indexes, err)
+	}
+	news := make([]types.Document, 0, len(keys))
+	writeKeys := make([][]byte, 0, len(keys))
+	values := make([][]byte, 0, len(keys))
+	written := make([]int, 0, len(keys))
+	for j, i := range indexes {
+		doc := docs[i]
+		intId, err := indexer.nextIntId()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		doc.IntId = intId
+		value, err := indexer.codec.Encode(&doc)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		news = append(news, doc)
+		writeKeys = append(writeKeys, keys[j])
+		values = append(values, value)
+		written = append(written, j)
+	}
+	if len(writeKeys) == 0 {
+		return 0, errs
+	}
+	//整批修改一次写进日志
+	records := make([][]byte, 0, 2*len(written))
+	for k, j := range written {
+		if len(olds[j]) > 0 {
+			records = append(records, deleteRecord(string(keys[j]), olds[j]))
+		}
+		records = append(records, addRecord(values[k]))
+	}
+	done, err := indexer.logWrite(records...)
+	if err != nil {
+		for _, j := range written {
+			errs[indexes[j]] = err
+		}
+		return 0, errs
+	}
+	defer done()
+	if err := indexer.forwardIndex.BatchSet(writeKeys, values); err != nil {
+		indexer.abortWrite(records...)
+		for _, j := range written {
+			errs[indexes[j]] = err
+		}
+		return 0, errs
+	}
+
+	replaced := make([]*types.Document, 0, len(written))
+	for _, j := range written {
+		if len(olds[j]) == 0 {
+			continue
+		}
+		if old, err := indexer.codec.Decode(olds[j]); err == nil {
+			replaced = append(replaced, old)
+		}
+	}
+	atomic.AddInt64(&indexer.docCount, int64(len(written)-countNonEmpty(olds, written)))
+	indexer.reverseIndex.DeleteBatch(replaced)
+	indexer.reverseIndex.AddBatch(news)
+	return len(written), errs
+}
+
+// DeleteDocs 批量删除，返回实际删除的文档数
+func (indexer *Indexer) DeleteDocs(docIds []string) int {
+	keys := make([][]byte, 0, len(docIds))
+	unique := make([]string, 0, len(docIds))
+	seen := make(map[string]struct{}, len(docIds))
+	for _, docId := range docIds {
+		if _, exists := seen[docId]; exists || len(docId) == 0 || isMetaKey([]byte(docId)) {
+			continue
+		}
+		seen[docId] = struct{}{}
+		keys = append(keys, []byte(docId))
+		unique = append(unique, docId)
+	}
+	if len(keys) == 0 {
+		return 0
+	}
+	defer indexer.docLocks.lock(unique...)()
+	values, err := indexer.forwardIndex.BatchGet(keys)
+	if err != nil {
+		return 0
+	}
+	existing := make([][]byte, 0, len(keys))
+	records := make([][]byte, 0, len(keys))
+	olds := make([]*types.Document, 0, len(keys))
+	for i, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+		existing = append(existing, keys[i])
+		records = append(records, deleteRecord(string(keys[i]), value))
+		if old, err := indexer.codec.Decode(value); err == nil {
+			olds = append(olds, old)
+		}
+	}
+	if len(existing) == 0 {
+		return 0
+	}
+	done, err := indexer.logWrite(records...)
+	if err != nil {
+		util.Log.Printf("write wal failed: %s", err)
+		return 0
+	}
+	defer done()
+	if err := indexer.forwardIndex.BatchDelete(existing); err != nil {
+		util.Log.Printf("delete %d docs from forward index failed: %s", len(existing), err)
+		indexer.abortWrite(records...)
+		return 0
+	}
+	atomic.AddInt64(&indexer.docCount, -int64(len(existing)))
+	indexer.reverseIndex.DeleteBatch(olds)
+	return len(existing)
+}
+
+func fillErrors(errs []error, indexes []int, err error) []error {
+	for _, i := range indexes {
+		errs[i] = err
+	}
+	return errs
+}
+
+// written里有多少个位置在olds里有值，即被覆盖的已有文档数
+func countNonEmpty(olds [][]byte, written []int) int {
+	n := 0
+	for _, j := range written {
+		if len(olds[j]) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// 错误转成字符串，nil对应空字符串。grpc传输用
+func errorStrings(errs []error) []string {
+	res := make([]string, len(errs))
+	for i, err := range errs {
+		if err != nil {
+			res[i] = err.Error()
+		}
+	}
+	return res
+}
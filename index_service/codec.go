@@ -0,0 +1,146 @@
+package index_service
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+)
+
+// 正排索引里文档的格式版本，写在每条记录的第1个字节。老的gob记录没有这个字节
+const (
+	FORMAT_GOB   byte = 0 //没有版本字节的gob
+	FORMAT_PROTO byte = 1
+)
+
+// DocumentCodec 文档在正排索引里的序列化方式。策略模式，Indexer.WithCodec可以替换。
+// Encode写出的第1个字节是格式版本，Decode除了自己的格式，还要能读出老格式的记录
+type DocumentCodec interface {
+	Version() byte //Encode写出的格式版本
+	Encode(doc *types.Document) ([]byte, error)
+	Decode(data []byte) (*types.Document, error)
+}
+
+// ProtoCodec 默认的格式：1个字节的版本号加上Document的protobuf编码。解码时也能读老的gob记录
+type ProtoCodec struct{}
+
+func (ProtoCodec) Version() byte {
+	return FORMAT_PROTO
+}
+
+func (ProtoCodec) Encode(doc *types.Document) ([]byte, error) {
+	data := make([]byte, 1+doc.Size())
+	data[0] = FORMAT_PROTO
+	if _, err := doc.MarshalTo(data[1:]); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (ProtoCodec) Decode(data []byte) (*types.Document, error) {
+	return decodeDoc(data)
+}
+
+// GobCodec 老的格式，整条记录就是gob编码，没有版本字节。只用于读写老数据
+type GobCodec struct{}
+
+func (GobCodec) Version() byte {
+	return FORMAT_GOB
+}
+
+func (GobCodec) Encode(doc *types.Document) ([]byte, error) {
+	var value bytes.Buffer
+	if err := gob.NewEncoder(&value).Encode(doc); err != nil {
+		return nil, err
+	}
+	return value.Bytes(), nil
+}
+
+func (GobCodec) Decode(data []byte) (*types.Document, error) {
+	return decodeDoc(data)
+}
+
+// 按第1个字节识别格式。gob记录的第1个字节是类型定义的长度，不可能是1
+func decodeDoc(data []byte) (*types.Document, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty document")
+	}
+	if data[0] == FORMAT_PROTO {
+		var doc types.Document
+		if err := doc.Unmarshal(data[1:]); err != nil {
+			return nil, err
+		}
+		return &doc, nil
+	}
+	var doc types.Document
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// data是否已经是version格式。gob没有版本字节，只能排除掉其他格式
+func isFormat(data []byte, version byte) bool {
+	if version == FORMAT_GOB {
+		return len(data) > 0 && data[0] != FORMAT_PROTO
+	}
+	return len(data) > 0 && data[0] == version
+}
+
+const (
+	docFormatKey     = metaKeyPrefix + "doc_format" //正排索引里全部文档的格式版本，跟codec不一致时需要迁移
+	migrateBatchSize = 1000                         //迁移时每次批量写入的文档数
+)
+
+// 打开正排索引时，如果记录的格式跟codec不一致，就把老记录重写一遍
+func (indexer *Indexer) migrateIfNeeded() error {
+	version := indexer.codec.Version()
+	if value, err := indexer.forwardIndex.Get([]byte(docFormatKey)); err == nil && len(value) == 1 && value[0] == version {
+		return nil
+	}
+	n, err := indexer.Migrate()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		util.Log.Printf("migrate %d documents to format %d", n, version)
+	}
+	return indexer.forwardIndex.Set([]byte(docFormatKey), []byte{version})
+}
+
+// Migrate 把正排索引里不是当前codec格式的记录解码后用当前codec重写，返回重写的文档数。
+// 遍历完再写入，避免在读事务里嵌套写事务
+func (indexer *Indexer) Migrate() (int, error) {
+	version := indexer.codec.Version()
+	var keys, values [][]byte
+	var encodeErr error
+	indexer.forwardIndex.IterDB(func(k, v []byte) error {
+		if isMetaKey(k) || isFormat(v, version) {
+			return nil
+		}
+		doc, err := indexer.codec.Decode(v)
+		if err != nil { //损坏的记录保持原样，读取时会被跳过
+			util.Log.Printf("decode document %s failed: %s", k, err)
+			return nil
+		}
+		value, err := indexer.codec.Encode(doc)
+		if err != nil {
+			encodeErr = err
+			return err
+		}
+		keys = append(keys, append([]byte{}, k...)) //k只在遍历期间有效
+		values = append(values, value)
+		return nil
+	})
+	if encodeErr != nil {
+		return 0, encodeErr
+	}
+	for begin := 0; begin < len(keys); begin += migrateBatchSize {
+		end := min(begin+migrateBatchSize, len(keys))
+		if err := indexer.forwardIndex.BatchSet(keys[begin:end], values[begin:end]); err != nil {
+			return begin, err
+		}
+	}
+	return len(keys), nil
+}
@@ -0,0 +1,99 @@
+package index_service
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/types"
+	"sort"
+)
+
+const maxReportSamples = 100 //报告里最多列出这么多条不一致的posting
+
+// ConsistencyReport 正排索引和倒排索引的比对结果
+type ConsistencyReport struct {
+	ForwardDocs     int      //正排索引里的文档数
+	ReverseDocs     int      //倒排索引上出现过的不同IntId个数。没有keyword的文档不在倒排索引上
+	CorruptDocs     []string //正排索引里解码失败的docId
+	MissingPostings int      //正排索引里的文档有这个keyword，倒排索引上却没有
+	ExtraPostings   int      //倒排索引上有，正排索引里却没有对应的文档或keyword
+	Samples         []string //不一致的posting举例，最多maxReportSamples条
+}
+
+// Consistent 两边是否完全一致
+func (report *ConsistencyReport) Consistent() bool {
+	return len(report.CorruptDocs) == 0 && report.MissingPostings == 0 && report.ExtraPostings == 0
+}
+
+func (report *ConsistencyReport) sample(format string, args ...any) {
+	if len(report.Samples) < maxReportSamples {
+		report.Samples = append(report.Samples, fmt.Sprintf(format, args...))
+	}
+}
+
+// CheckConsistency 逐个keyword比对正排索引和倒排索引：正排索引里每篇文档的每个keyword都要能在倒排索引上检索到，
+// 倒排索引上的每条posting都要对应正排索引里的一篇文档。比对期间阻塞写操作。
+// 只检查正排索引里出现过的field，会把所有posting读进内存，适合离线排查问题
+func (indexer *Indexer) CheckConsistency() *ConsistencyReport {
+	indexer.walLock.Lock()
+	defer indexer.walLock.Unlock()
+
+	report := &ConsistencyReport{}
+	expected := make(map[string]map[uint64]string) //key -> IntId -> docId
+	keywords := make(map[string]*types.Keyword)
+	fields := make(map[string]bool)
+	indexer.forwardIndex.IterDB(func(k, v []byte) error {
+		if isMetaKey(k) {
+			return nil
+		}
+		report.ForwardDocs++
+		doc, err := indexer.codec.Decode(v)
+		if err != nil {
+			report.CorruptDocs = append(report.CorruptDocs, string(k))
+			return nil
+		}
+		for _, keyword := range doc.Keywords {
+			key := keyword.ToString()
+			if expected[key] == nil {
+				expected[key] = make(map[uint64]string)
+			}
+			expected[key][doc.IntId] = doc.Id
+			keywords[key] = keyword
+			fields[keyword.Field] = true
+		}
+		return nil
+	})
+
+	//倒排索引上的key可能比正排索引多，也可能少，两边取并集
+	for field := range fields {
+		for _, word := range indexer.reverseIndex.Terms(field, "", 0) {
+			keyword := &types.Keyword{Field: field, Word: word}
+			keywords[keyword.ToString()] = keyword
+		}
+	}
+	keys := make([]string, 0, len(keywords))
+	for key := range keywords {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	reverseDocs := make(map[uint64]bool, report.ForwardDocs)
+	for _, key := range keys {
+		postings, keyword := expected[key], keywords[key]
+		found := make(map[uint64]bool, len(postings))
+		for _, hit := range indexer.reverseIndex.Search(types.NewTermQuery(keyword.Field, keyword.Word), 0, 0, nil) {
+			reverseDocs[hit.IntId] = true
+			found[hit.IntId] = true
+			if docId, ok := postings[hit.IntId]; !ok || docId != hit.Id {
+				report.ExtraPostings++
+				report.sample("extra posting %s:%s on doc %s(%d)", keyword.Field, keyword.Word, hit.Id, hit.IntId)
+			}
+		}
+		for intId, docId := range postings {
+			if !found[intId] {
+				report.MissingPostings++
+				report.sample("missing posting %s:%s on doc %s(%d)", keyword.Field, keyword.Word, docId, intId)
+			}
+		}
+	}
+	report.ReverseDocs = len(reverseDocs)
+	return report
+}
@@ -2,7 +2,9 @@ package index_service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/Muoshu/myRadic/analysis"
 	"github.com/Muoshu/myRadic/types"
 	"github.com/Muoshu/myRadic/util"
 	"google.golang.org/grpc"
@@ -17,6 +19,7 @@ type Sentinel struct {
 	// 从Hub上获取IndexServiceWorker集合。可能是直接访问ServiceHub，也可能是走代理
 	hub      IServiceHub
 	connPool sync.Map
+	synonyms *analysis.Synonyms
 }
 
 func NewSentinel(etcdServers []string) *Sentinel {
@@ -26,6 +29,12 @@ func NewSentinel(etcdServers []string) *Sentinel {
 	}
 }
 
+// WithSynonyms builder模式，分发给各个worker之前把查询里的Keyword展开成同义词
+func (sentinel *Sentinel) WithSynonyms(synonyms *analysis.Synonyms) *Sentinel {
+	sentinel.synonyms = synonyms
+	return sentinel
+}
+
 func (sentinel *Sentinel) GetGrpcConn(endpoint string) *grpc.ClientConn {
 	if v, ok := sentinel.connPool.Load(endpoint); ok {
 		conn := v.(*grpc.ClientConn)
@@ -109,52 +118,244 @@ func (sentinel *Sentinel) DeleteDoc(docId string) int {
 	return int(atomic.LoadInt32(&n))
 }
 
-func (sentinel *Sentinel) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document {
+// AddDocs 按负载均衡策略为每个文档选一台worker，发往同一台worker的文档合并成一次请求，各个worker并行写入
+func (sentinel *Sentinel) AddDocs(docs []types.Document) (int, []error) {
+	errs := make([]error, len(docs))
+	groups := make(map[string][]int) //endpoint -> 发往它的文档在docs里的下标
+	for i := range docs {
+		endpoint := sentinel.hub.GetServiceEndpoint(INDEX_SERVICE)
+		if len(endpoint) == 0 {
+			errs[i] = fmt.Errorf("there is no alive index worker")
+			continue
+		}
+		groups[endpoint] = append(groups[endpoint], i)
+	}
+	var n int32
+	wg := sync.WaitGroup{}
+	wg.Add(len(groups))
+	for endpoint, indexes := range groups {
+		go func(endpoint string, indexes []int) {
+			defer wg.Done()
+			conn := sentinel.GetGrpcConn(endpoint)
+			if conn == nil {
+				fillErrors(errs, indexes, fmt.Errorf("connect to worker %s failed", endpoint))
+				return
+			}
+			request := &Documents{Docs: make([]*types.Document, 0, len(indexes))}
+			for _, i := range indexes {
+				request.Docs = append(request.Docs, &docs[i])
+			}
+			client := NewIndexServiceClient(conn)
+			result, err := client.AddDocs(context.Background(), request)
+			if err != nil {
+				util.Log.Printf("add docs to worker %s failed: %s", endpoint, err)
+				fillErrors(errs, indexes, err)
+				return
+			}
+			for j, msg := range result.Errors { //各个goroutine写errs的不同下标，不需要加锁
+				if j < len(indexes) && len(msg) > 0 {
+					errs[indexes[j]] = errors.New(msg)
+				}
+			}
+			atomic.AddInt32(&n, result.Count)
+			util.Log.Printf("add %d docs to worker %s", result.Count, endpoint)
+		}(endpoint, indexes)
+	}
+	wg.Wait()
+	return int(n), errs
+}
+
+// DeleteDocs 不知道文档在哪台worker上，所以每台worker都删一遍
+func (sentinel *Sentinel) DeleteDocs(docIds []string) int {
 	endpoints := sentinel.hub.GetServiceEndpoints(INDEX_SERVICE)
-	if len(endpoints) == 0 {
-		return nil
+	if len(endpoints) == 0 || len(docIds) == 0 {
+		return 0
 	}
-	docs := make([]*types.Document, 0, 1000)
-	resultCh := make(chan *types.Document, 1000)
+	var n int32
+	request := &DocIds{DocIds: docIds}
 	wg := sync.WaitGroup{}
 	wg.Add(len(endpoints))
+	for _, endpoint := range endpoints {
+		go func(endpoint string) {
+			defer wg.Done()
+			conn := sentinel.GetGrpcConn(endpoint)
+			if conn != nil {
+				client := NewIndexServiceClient(conn)
+				affected, err := client.DeleteDocs(context.Background(), request)
+				if err != nil {
+					util.Log.Printf("delete docs from worker %s failed: %s", endpoint, err)
+				} else if affected.Count > 0 {
+					atomic.AddInt32(&n, affected.Count)
+					util.Log.Printf("delete %d docs from worker %s", affected.Count, endpoint)
+				}
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	return int(n)
+}
 
+// UpdateDoc 不知道文档在哪台worker上，所以发给每台worker，只有存有该文档的worker会修改
+func (sentinel *Sentinel) UpdateDoc(update *DocUpdate) (int, error) {
+	endpoints := sentinel.hub.GetServiceEndpoints(INDEX_SERVICE)
+	if len(endpoints) == 0 {
+		return 0, fmt.Errorf("there is no alive index worker")
+	}
+	var n int32
+	var lastErr error
+	errLock := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(len(endpoints))
 	for _, endpoint := range endpoints {
 		go func(endpoint string) {
+			defer wg.Done()
+			conn := sentinel.GetGrpcConn(endpoint)
+			if conn == nil {
+				return
+			}
+			client := NewIndexServiceClient(conn)
+			affected, err := client.UpdateDoc(context.Background(), update)
+			if err != nil {
+				util.Log.Printf("update doc %s on worker %s failed: %s", update.Id, endpoint, err)
+				errLock.Lock()
+				lastErr = err
+				errLock.Unlock()
+			} else if affected.Count > 0 {
+				atomic.AddInt32(&n, affected.Count)
+				util.Log.Printf("update %d doc on worker %s", affected.Count, endpoint)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	if n == 0 && lastErr != nil {
+		return 0, lastErr
+	}
+	return int(n), nil
+}
+
+func (sentinel *Sentinel) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document {
+	return sentinel.SearchPage(&SearchRequest{Query: query, OnFlag: onFlag, OffFlag: offFlag, OrFlags: orFlags}).Result
+}
+
+// SearchPage 各个worker返回自己排在前面的Offset+Limit个文档，多路归并之后再取全局的那一页。
+// 各个worker的字典不同，展开后的查询也不同，合并起来返回
+func (sentinel *Sentinel) SearchPage(request *SearchRequest) *SearchResult {
+	endpoints := sentinel.hub.GetServiceEndpoints(INDEX_SERVICE)
+	if len(endpoints) == 0 {
+		return &SearchResult{}
+	}
+	//全局的第Offset个文档可能在任何一个worker上，所以每个worker都不能跳过前Offset个
+	shardRequest := &SearchRequest{Query: sentinel.synonyms.Rewrite(request.Query), OnFlag: request.OnFlag, OffFlag: request.OffFlag, OrFlags: request.OrFlags, SortBy: request.SortBy}
+	if request.Limit > 0 {
+		shardRequest.Limit = request.Offset + request.Limit
+	}
+	lists := make([][]*types.Document, len(endpoints)) //每个worker的结果单独存放，以保留worker内部的排序
+	querys := make([]*types.TermQuery, len(endpoints))
+	wg := sync.WaitGroup{}
+	wg.Add(len(endpoints))
+	for i, endpoint := range endpoints {
+		go func(i int, endpoint string) {
 			defer wg.Done()
 			conn := sentinel.GetGrpcConn(endpoint)
 			if conn != nil {
 				client := NewIndexServiceClient(conn)
-				result, err := client.Search(context.Background(), &SearchRequest{query, onFlag, offFlag, orFlags})
+				result, err := client.Search(context.Background(), shardRequest)
 				if err != nil {
 					util.Log.Printf("search from cluster failed: %s", err)
 				} else {
+					querys[i] = result.Query
 					if len(result.Result) > 0 {
 						util.Log.Printf("search %d doc from worker %s", len(result.Result), endpoint)
-						for _, doc := range result.Result {
-							resultCh <- doc
-						}
+						lists[i] = result.Result
 					}
 				}
 			}
-		}(endpoint)
+		}(i, endpoint)
 	}
+	wg.Wait()
+	return &SearchResult{Result: MergeDocs(lists, request.SortBy, int(request.Offset), int(request.Limit)), Query: MergeQuerys(querys)}
+}
 
-	receiveFinish := make(chan struct{})
-	go func() {
-		for {
-			doc, ok := <-resultCh
-			if !ok {
-				break
-			}
-			docs = append(docs, doc)
+// MergeQuerys 合并各个worker实际执行的查询，相同的只保留一个，不同的用Should连起来
+func MergeQuerys(querys []*types.TermQuery) *types.TermQuery {
+	distinct := make([]*types.TermQuery, 0, len(querys))
+	seen := make(map[string]struct{}, len(querys))
+	for _, query := range querys {
+		if query == nil || query.Empty() {
+			continue
 		}
-		receiveFinish <- struct{}{}
-	}()
+		key := query.ToString()
+		if _, exists := seen[key]; !exists {
+			seen[key] = struct{}{}
+			distinct = append(distinct, query)
+		}
+	}
+	switch len(distinct) {
+	case 0:
+		return nil
+	case 1:
+		return distinct[0]
+	default:
+		return &types.TermQuery{Should: distinct}
+	}
+}
+
+// Facet 各个worker返回全部word的统计，加起来之后再取前TopN个
+func (sentinel *Sentinel) Facet(request *FacetRequest) *FacetResult {
+	endpoints := sentinel.hub.GetServiceEndpoints(INDEX_SERVICE)
+	if len(endpoints) == 0 {
+		return MergeFacets(nil, 0)
+	}
+	shardRequest := &FacetRequest{Query: sentinel.synonyms.Rewrite(request.Query), OnFlag: request.OnFlag, OffFlag: request.OffFlag, OrFlags: request.OrFlags, Field: request.Field}
+	results := make([]*FacetResult, len(endpoints))
+	wg := sync.WaitGroup{}
+	wg.Add(len(endpoints))
+	for i, endpoint := range endpoints {
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			conn := sentinel.GetGrpcConn(endpoint)
+			if conn != nil {
+				client := NewIndexServiceClient(conn)
+				result, err := client.Facet(context.Background(), shardRequest)
+				if err != nil {
+					util.Log.Printf("facet from worker %s failed: %s", endpoint, err)
+				} else {
+					results[i] = result
+				}
+			}
+		}(i, endpoint)
+	}
 	wg.Wait()
-	close(resultCh)
-	<-receiveFinish
-	return docs
+	return MergeFacets(results, int(request.TopN))
+}
+
+// CountMatches 各个worker上命中的文档数加起来
+func (sentinel *Sentinel) CountMatches(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int {
+	var n int32
+	endpoints := sentinel.hub.GetServiceEndpoints(INDEX_SERVICE)
+	if len(endpoints) == 0 {
+		return 0
+	}
+	request := &SearchRequest{Query: sentinel.synonyms.Rewrite(query), OnFlag: onFlag, OffFlag: offFlag, OrFlags: orFlags}
+	wg := sync.WaitGroup{}
+	wg.Add(len(endpoints))
+	for _, endpoint := range endpoints {
+		go func(endpoint string) {
+			defer wg.Done()
+			conn := sentinel.GetGrpcConn(endpoint)
+			if conn != nil {
+				client := NewIndexServiceClient(conn)
+				affected, err := client.CountMatches(context.Background(), request)
+				if err != nil {
+					util.Log.Printf("count matches from worker %s failed: %s", endpoint, err)
+				} else {
+					atomic.AddInt32(&n, affected.Count)
+				}
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	return int(n)
 }
 
 func (sentinel *Sentinel) Count() int {
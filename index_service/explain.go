@@ -0,0 +1,28 @@
+package index_service
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+)
+
+// 倒排索引上的执行情况转成Explanation，逐层转换子节点
+func newExplanation(explanation *reverseindex.Explanation) *Explanation {
+	if explanation == nil {
+		return nil
+	}
+	result := &Explanation{
+		Op:            explanation.Op,
+		Query:         explanation.Query,
+		Negated:       explanation.Negated,
+		PostingLength: int64(explanation.PostingLength),
+		FilteredRows:  int64(explanation.FilteredRows),
+		Rows:          int64(explanation.Rows),
+		TimeNs:        explanation.Time.Nanoseconds(),
+		Matched:       explanation.Matched,
+		Score:         explanation.Score,
+		Children:      make([]*Explanation, 0, len(explanation.Children)),
+	}
+	for _, child := range explanation.Children {
+		result.Children = append(result.Children, newExplanation(child))
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package index_service
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"sort"
+)
+
+// 倒排索引上的分面统计结果转成FacetResult，word只保留命中最多的topN个
+func newFacetResult(facets *reverseindex.Facets, topN int) *FacetResult {
+	result := &FacetResult{Total: int64(facets.Total), BitCounts: make([]int64, len(facets.Bits))}
+	for i, count := range facets.Bits {
+		result.BitCounts[i] = int64(count)
+	}
+	counts := make(map[string]int64, len(facets.Values))
+	for value, count := range facets.Values {
+		counts[value] = int64(count)
+	}
+	result.Values = topFacetValues(counts, topN)
+	return result
+}
+
+// 按Count从大到小排序，Count相同时按Value排序，只保留前topN个。topN为0时全部保留
+func topFacetValues(counts map[string]int64, topN int) []*FacetValue {
+	values := make([]*FacetValue, 0, len(counts))
+	for value, count := range counts {
+		values = append(values, &FacetValue{Value: value, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if topN > 0 && len(values) > topN {
+		values = values[:topN]
+	}
+	return values
+}
+
+// MergeFacets 把各个分片的分面统计加起来。各分片必须返回全部的word(TopN为0)，否则某个word在一个分片上没进前N名就会被少算
+func MergeFacets(results []*FacetResult, topN int) *FacetResult {
+	merged := &FacetResult{BitCounts: make([]int64, 64)}
+	counts := make(map[string]int64, 1000)
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		merged.Total += result.Total
+		for i, count := range result.BitCounts {
+			if i < len(merged.BitCounts) {
+				merged.BitCounts[i] += count
+			}
+		}
+		for _, value := range result.Values {
+			counts[value.Value] += value.Count
+		}
+	}
+	merged.Values = topFacetValues(counts, topN)
+	return merged
+}
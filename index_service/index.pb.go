@@ -5,6 +5,7 @@ package index_service
 
 import (
 	context "context"
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	types "github.com/Muoshu/myRadic/types"
 	proto "github.com/gogo/protobuf/proto"
@@ -27,6 +28,38 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+// DocUpdate.Mask的取值，可以按位或。Keywords的增删由AddKeywords、RemoveKeywords决定，不需要Mask
+type UpdateMask int32
+
+const (
+	UpdateMask_UPDATE_NONE         UpdateMask = 0
+	UpdateMask_UPDATE_BITS_FEATURE UpdateMask = 1
+	UpdateMask_UPDATE_BYTES        UpdateMask = 2
+	UpdateMask_UPDATE_NUMERICS     UpdateMask = 4
+)
+
+var UpdateMask_name = map[int32]string{
+	0: "UPDATE_NONE",
+	1: "UPDATE_BITS_FEATURE",
+	2: "UPDATE_BYTES",
+	4: "UPDATE_NUMERICS",
+}
+
+var UpdateMask_value = map[string]int32{
+	"UPDATE_NONE":         0,
+	"UPDATE_BITS_FEATURE": 1,
+	"UPDATE_BYTES":        2,
+	"UPDATE_NUMERICS":     4,
+}
+
+func (x UpdateMask) String() string {
+	return proto.EnumName(UpdateMask_name, int32(x))
+}
+
+func (UpdateMask) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{0}
+}
+
 type DocId struct {
 	DocId string `protobuf:"bytes,1,opt,name=DocId,proto3" json:"DocId,omitempty"`
 }
@@ -115,18 +148,308 @@ func (m *AffectedCount) GetCount() int32 {
 	return 0
 }
 
+type DocIds struct {
+	DocIds []string `protobuf:"bytes,1,rep,name=DocIds,proto3" json:"DocIds,omitempty"`
+}
+
+func (m *DocIds) Reset()         { *m = DocIds{} }
+func (m *DocIds) String() string { return proto.CompactTextString(m) }
+func (*DocIds) ProtoMessage()    {}
+func (*DocIds) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{2}
+}
+func (m *DocIds) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DocIds) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DocIds.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DocIds) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DocIds.Merge(m, src)
+}
+func (m *DocIds) XXX_Size() int {
+	return m.Size()
+}
+func (m *DocIds) XXX_DiscardUnknown() {
+	xxx_messageInfo_DocIds.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DocIds proto.InternalMessageInfo
+
+func (m *DocIds) GetDocIds() []string {
+	if m != nil {
+		return m.DocIds
+	}
+	return nil
+}
+
+type Documents struct {
+	Docs []*types.Document `protobuf:"bytes,1,rep,name=Docs,proto3" json:"Docs,omitempty"`
+}
+
+func (m *Documents) Reset()         { *m = Documents{} }
+func (m *Documents) String() string { return proto.CompactTextString(m) }
+func (*Documents) ProtoMessage()    {}
+func (*Documents) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{3}
+}
+func (m *Documents) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Documents) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Documents.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Documents) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Documents.Merge(m, src)
+}
+func (m *Documents) XXX_Size() int {
+	return m.Size()
+}
+func (m *Documents) XXX_DiscardUnknown() {
+	xxx_messageInfo_Documents.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Documents proto.InternalMessageInfo
+
+func (m *Documents) GetDocs() []*types.Document {
+	if m != nil {
+		return m.Docs
+	}
+	return nil
+}
+
+// 批量写入的结果
+type BatchResult struct {
+	Count  int32    `protobuf:"varint,1,opt,name=Count,proto3" json:"Count,omitempty"`
+	Errors []string `protobuf:"bytes,2,rep,name=Errors,proto3" json:"Errors,omitempty"`
+}
+
+func (m *BatchResult) Reset()         { *m = BatchResult{} }
+func (m *BatchResult) String() string { return proto.CompactTextString(m) }
+func (*BatchResult) ProtoMessage()    {}
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{4}
+}
+func (m *BatchResult) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BatchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BatchResult.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BatchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResult.Merge(m, src)
+}
+func (m *BatchResult) XXX_Size() int {
+	return m.Size()
+}
+func (m *BatchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResult proto.InternalMessageInfo
+
+func (m *BatchResult) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *BatchResult) GetErrors() []string {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+// 修改已有文档的部分字段，IntId保持不变。Mask里没有指明的字段保持原样
+type DocUpdate struct {
+	Id             string           `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	Mask           uint32           `protobuf:"varint,2,opt,name=Mask,proto3" json:"Mask,omitempty"`
+	BitsFeature    uint64           `protobuf:"varint,3,opt,name=BitsFeature,proto3" json:"BitsFeature,omitempty"`
+	Bytes          []byte           `protobuf:"bytes,4,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	Numerics       map[string]int64 `protobuf:"bytes,5,rep,name=Numerics,proto3" json:"Numerics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	AddKeywords    []*types.Keyword `protobuf:"bytes,6,rep,name=AddKeywords,proto3" json:"AddKeywords,omitempty"`
+	RemoveKeywords []*types.Keyword `protobuf:"bytes,7,rep,name=RemoveKeywords,proto3" json:"RemoveKeywords,omitempty"`
+}
+
+func (m *DocUpdate) Reset()         { *m = DocUpdate{} }
+func (m *DocUpdate) String() string { return proto.CompactTextString(m) }
+func (*DocUpdate) ProtoMessage()    {}
+func (*DocUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{5}
+}
+func (m *DocUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DocUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DocUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *DocUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DocUpdate.Merge(m, src)
+}
+func (m *DocUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *DocUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_DocUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DocUpdate proto.InternalMessageInfo
+
+func (m *DocUpdate) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *DocUpdate) GetMask() uint32 {
+	if m != nil {
+		return m.Mask
+	}
+	return 0
+}
+
+func (m *DocUpdate) GetBitsFeature() uint64 {
+	if m != nil {
+		return m.BitsFeature
+	}
+	return 0
+}
+
+func (m *DocUpdate) GetBytes() []byte {
+	if m != nil {
+		return m.Bytes
+	}
+	return nil
+}
+
+func (m *DocUpdate) GetNumerics() map[string]int64 {
+	if m != nil {
+		return m.Numerics
+	}
+	return nil
+}
+
+func (m *DocUpdate) GetAddKeywords() []*types.Keyword {
+	if m != nil {
+		return m.AddKeywords
+	}
+	return nil
+}
+
+func (m *DocUpdate) GetRemoveKeywords() []*types.Keyword {
+	if m != nil {
+		return m.RemoveKeywords
+	}
+	return nil
+}
+
+// 排序方式。Field为空时按相关性得分排序
+type SortBy struct {
+	Field string `protobuf:"bytes,1,opt,name=Field,proto3" json:"Field,omitempty"`
+	Asc   bool   `protobuf:"varint,2,opt,name=Asc,proto3" json:"Asc,omitempty"`
+}
+
+func (m *SortBy) Reset()         { *m = SortBy{} }
+func (m *SortBy) String() string { return proto.CompactTextString(m) }
+func (*SortBy) ProtoMessage()    {}
+func (*SortBy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{6}
+}
+func (m *SortBy) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SortBy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SortBy.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SortBy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SortBy.Merge(m, src)
+}
+func (m *SortBy) XXX_Size() int {
+	return m.Size()
+}
+func (m *SortBy) XXX_DiscardUnknown() {
+	xxx_messageInfo_SortBy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SortBy proto.InternalMessageInfo
+
+func (m *SortBy) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *SortBy) GetAsc() bool {
+	if m != nil {
+		return m.Asc
+	}
+	return false
+}
+
 type SearchRequest struct {
 	Query   *types.TermQuery `protobuf:"bytes,1,opt,name=Query,proto3" json:"Query,omitempty"`
 	OnFlag  uint64           `protobuf:"varint,2,opt,name=OnFlag,proto3" json:"OnFlag,omitempty"`
 	OffFlag uint64           `protobuf:"varint,3,opt,name=OffFlag,proto3" json:"OffFlag,omitempty"`
 	OrFlags []uint64         `protobuf:"varint,4,rep,packed,name=OrFlags,proto3" json:"OrFlags,omitempty"`
+	Offset  uint32           `protobuf:"varint,5,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	Limit   uint32           `protobuf:"varint,6,opt,name=Limit,proto3" json:"Limit,omitempty"`
+	SortBy  *SortBy          `protobuf:"bytes,7,opt,name=SortBy,proto3" json:"SortBy,omitempty"`
 }
 
 func (m *SearchRequest) Reset()         { *m = SearchRequest{} }
 func (m *SearchRequest) String() string { return proto.CompactTextString(m) }
 func (*SearchRequest) ProtoMessage()    {}
 func (*SearchRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_f750e0f7889345b5, []int{2}
+	return fileDescriptor_f750e0f7889345b5, []int{7}
 }
 func (m *SearchRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -183,15 +506,37 @@ func (m *SearchRequest) GetOrFlags() []uint64 {
 	return nil
 }
 
+func (m *SearchRequest) GetOffset() uint32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *SearchRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *SearchRequest) GetSortBy() *SortBy {
+	if m != nil {
+		return m.SortBy
+	}
+	return nil
+}
+
 type SearchResult struct {
 	Result []*types.Document `protobuf:"bytes,1,rep,name=Result,proto3" json:"Result,omitempty"`
+	Query  *types.TermQuery  `protobuf:"bytes,2,opt,name=Query,proto3" json:"Query,omitempty"`
 }
 
 func (m *SearchResult) Reset()         { *m = SearchResult{} }
 func (m *SearchResult) String() string { return proto.CompactTextString(m) }
 func (*SearchResult) ProtoMessage()    {}
 func (*SearchResult) Descriptor() ([]byte, []int) {
-	return fileDescriptor_f750e0f7889345b5, []int{3}
+	return fileDescriptor_f750e0f7889345b5, []int{8}
 }
 func (m *SearchResult) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -227,6 +572,13 @@ func (m *SearchResult) GetResult() []*types.Document {
 	return nil
 }
 
+func (m *SearchResult) GetQuery() *types.TermQuery {
+	if m != nil {
+		return m.Query
+	}
+	return nil
+}
+
 type CountRequest struct {
 }
 
@@ -234,7 +586,7 @@ func (m *CountRequest) Reset()         { *m = CountRequest{} }
 func (m *CountRequest) String() string { return proto.CompactTextString(m) }
 func (*CountRequest) ProtoMessage()    {}
 func (*CountRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_f750e0f7889345b5, []int{4}
+	return fileDescriptor_f750e0f7889345b5, []int{9}
 }
 func (m *CountRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -263,505 +615,3594 @@ func (m *CountRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_CountRequest proto.InternalMessageInfo
 
-func init() {
-	proto.RegisterType((*DocId)(nil), "index_service.DocId")
-	proto.RegisterType((*AffectedCount)(nil), "index_service.AffectedCount")
-	proto.RegisterType((*SearchRequest)(nil), "index_service.SearchRequest")
-	proto.RegisterType((*SearchResult)(nil), "index_service.SearchResult")
-	proto.RegisterType((*CountRequest)(nil), "index_service.CountRequest")
+type FacetRequest struct {
+	Query   *types.TermQuery `protobuf:"bytes,1,opt,name=Query,proto3" json:"Query,omitempty"`
+	OnFlag  uint64           `protobuf:"varint,2,opt,name=OnFlag,proto3" json:"OnFlag,omitempty"`
+	OffFlag uint64           `protobuf:"varint,3,opt,name=OffFlag,proto3" json:"OffFlag,omitempty"`
+	OrFlags []uint64         `protobuf:"varint,4,rep,packed,name=OrFlags,proto3" json:"OrFlags,omitempty"`
+	Field   string           `protobuf:"bytes,5,opt,name=Field,proto3" json:"Field,omitempty"`
+	TopN    uint32           `protobuf:"varint,6,opt,name=TopN,proto3" json:"TopN,omitempty"`
 }
 
-func init() { proto.RegisterFile("index.proto", fileDescriptor_f750e0f7889345b5) }
-
-var fileDescriptor_f750e0f7889345b5 = []byte{
-	// 359 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0x4f, 0x4f, 0xfa, 0x40,
-	0x14, 0x64, 0xf9, 0xd3, 0x5f, 0x78, 0xc0, 0x4f, 0xb2, 0x21, 0xa6, 0xa9, 0xda, 0x34, 0x4d, 0x54,
-	0x4e, 0x3d, 0xe0, 0x81, 0xa3, 0x01, 0x1a, 0x13, 0x4e, 0xc4, 0xe2, 0x9d, 0x60, 0xfb, 0xaa, 0x24,
-	0xd0, 0x85, 0xed, 0xd6, 0xc8, 0xd9, 0x2f, 0xa0, 0xdf, 0xca, 0x23, 0x47, 0x8f, 0x06, 0xbe, 0x88,
-	0xe9, 0x6e, 0x49, 0xa4, 0x89, 0x7a, 0x9b, 0x79, 0x33, 0x2f, 0x9d, 0x37, 0x5d, 0xa8, 0xcd, 0xa2,
-	0x00, 0x9f, 0x9d, 0x25, 0x67, 0x82, 0xd1, 0x86, 0x24, 0x93, 0x18, 0xf9, 0xd3, 0xcc, 0x47, 0xa3,
-	0x1a, 0x30, 0x5f, 0x29, 0x46, 0x53, 0x20, 0x5f, 0x4c, 0x56, 0x09, 0xf2, 0xb5, 0x9a, 0xd8, 0x67,
-	0x50, 0x71, 0x99, 0x3f, 0x0c, 0x68, 0x2b, 0x03, 0x3a, 0xb1, 0x48, 0xbb, 0xea, 0x29, 0x62, 0x9f,
-	0x43, 0xa3, 0x17, 0x86, 0xe8, 0x0b, 0x0c, 0x06, 0x2c, 0x89, 0x44, 0x6a, 0x93, 0x40, 0xda, 0x2a,
-	0x9e, 0x22, 0xf6, 0x0b, 0x81, 0xc6, 0x18, 0xa7, 0xdc, 0x7f, 0xf4, 0x70, 0x95, 0x60, 0x2c, 0xe8,
-	0x05, 0x54, 0x6e, 0xd3, 0xcf, 0x48, 0x5f, 0xad, 0xd3, 0x74, 0xc4, 0x7a, 0x89, 0xb1, 0x73, 0x87,
-	0x7c, 0x21, 0xe7, 0x9e, 0x92, 0xe9, 0x31, 0x68, 0xa3, 0xe8, 0x66, 0x3e, 0x7d, 0xd0, 0x8b, 0x16,
-	0x69, 0x97, 0xbd, 0x8c, 0x51, 0x1d, 0xfe, 0x8d, 0xc2, 0x50, 0x0a, 0x25, 0x29, 0xec, 0xa9, 0x54,
-	0x78, 0x8a, 0x62, 0xbd, 0x6c, 0x95, 0xa4, 0xa2, 0xa8, 0xdd, 0x85, 0xfa, 0x3e, 0x44, 0x9c, 0xcc,
-	0x05, 0xbd, 0x04, 0x4d, 0x21, 0x9d, 0x58, 0xa5, 0x76, 0xad, 0x73, 0x94, 0x85, 0x70, 0x99, 0x9f,
-	0x2c, 0x30, 0x12, 0x5e, 0x26, 0xdb, 0xff, 0xa1, 0x2e, 0xef, 0xc8, 0xc2, 0x77, 0xde, 0x8a, 0x50,
-	0x1f, 0xa6, 0x1d, 0x8e, 0x55, 0x85, 0xf4, 0x1a, 0xaa, 0x2e, 0xce, 0x51, 0xa0, 0xcb, 0x7c, 0xda,
-	0x72, 0x0e, 0xfa, 0x75, 0x64, 0x53, 0xc6, 0x69, 0x6e, 0x7a, 0x58, 0x5b, 0x17, 0xb4, 0x5e, 0x10,
-	0xa4, 0xdb, 0xf9, 0x10, 0x7f, 0x2c, 0x0e, 0x40, 0x53, 0x37, 0xd1, 0xbc, 0xef, 0xa0, 0x6f, 0xe3,
-	0xe4, 0x07, 0x55, 0x16, 0xd1, 0xcf, 0x7e, 0x1a, 0xcd, 0xbb, 0xbe, 0x5f, 0xfd, 0x7b, 0x90, 0xbe,
-	0xfe, 0xbe, 0x35, 0xc9, 0x66, 0x6b, 0x92, 0xcf, 0xad, 0x49, 0x5e, 0x77, 0x66, 0x61, 0xb3, 0x33,
-	0x0b, 0x1f, 0x3b, 0xb3, 0x70, 0xaf, 0xc9, 0x97, 0x74, 0xf5, 0x15, 0x00, 0x00, 0xff, 0xff, 0xfb,
-	0xde, 0x59, 0x47, 0x84, 0x02, 0x00, 0x00,
+func (m *FacetRequest) Reset()         { *m = FacetRequest{} }
+func (m *FacetRequest) String() string { return proto.CompactTextString(m) }
+func (*FacetRequest) ProtoMessage()    {}
+func (*FacetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{10}
 }
-
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
-
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
-
-// IndexServiceClient is the client API for IndexService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type IndexServiceClient interface {
-	DeleteDoc(ctx context.Context, in *DocId, opts ...grpc.CallOption) (*AffectedCount, error)
-	AddDoc(ctx context.Context, in *types.Document, opts ...grpc.CallOption) (*AffectedCount, error)
-	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error)
-	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*AffectedCount, error)
+func (m *FacetRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-
-type indexServiceClient struct {
-	cc *grpc.ClientConn
+func (m *FacetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FacetRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-
-func NewIndexServiceClient(cc *grpc.ClientConn) IndexServiceClient {
-	return &indexServiceClient{cc}
+func (m *FacetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FacetRequest.Merge(m, src)
 }
-
-func (c *indexServiceClient) DeleteDoc(ctx context.Context, in *DocId, opts ...grpc.CallOption) (*AffectedCount, error) {
-	out := new(AffectedCount)
-	err := c.cc.Invoke(ctx, "/index_service.IndexService/DeleteDoc", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *FacetRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *FacetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FacetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FacetRequest proto.InternalMessageInfo
+
+func (m *FacetRequest) GetQuery() *types.TermQuery {
+	if m != nil {
+		return m.Query
 	}
-	return out, nil
+	return nil
 }
 
-func (c *indexServiceClient) AddDoc(ctx context.Context, in *types.Document, opts ...grpc.CallOption) (*AffectedCount, error) {
-	out := new(AffectedCount)
-	err := c.cc.Invoke(ctx, "/index_service.IndexService/AddDoc", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *FacetRequest) GetOnFlag() uint64 {
+	if m != nil {
+		return m.OnFlag
 	}
-	return out, nil
+	return 0
 }
 
-func (c *indexServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error) {
-	out := new(SearchResult)
-	err := c.cc.Invoke(ctx, "/index_service.IndexService/Search", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *FacetRequest) GetOffFlag() uint64 {
+	if m != nil {
+		return m.OffFlag
 	}
-	return out, nil
+	return 0
 }
 
-func (c *indexServiceClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*AffectedCount, error) {
-	out := new(AffectedCount)
-	err := c.cc.Invoke(ctx, "/index_service.IndexService/Count", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *FacetRequest) GetOrFlags() []uint64 {
+	if m != nil {
+		return m.OrFlags
 	}
-	return out, nil
+	return nil
 }
 
-// IndexServiceServer is the server API for IndexService service.
-type IndexServiceServer interface {
-	DeleteDoc(context.Context, *DocId) (*AffectedCount, error)
-	AddDoc(context.Context, *types.Document) (*AffectedCount, error)
-	Search(context.Context, *SearchRequest) (*SearchResult, error)
-	Count(context.Context, *CountRequest) (*AffectedCount, error)
+func (m *FacetRequest) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
 }
 
-// UnimplementedIndexServiceServer can be embedded to have forward compatible implementations.
-type UnimplementedIndexServiceServer struct {
+func (m *FacetRequest) GetTopN() uint32 {
+	if m != nil {
+		return m.TopN
+	}
+	return 0
 }
 
-func (*UnimplementedIndexServiceServer) DeleteDoc(ctx context.Context, req *DocId) (*AffectedCount, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method DeleteDoc not implemented")
+type FacetValue struct {
+	Value string `protobuf:"bytes,1,opt,name=Value,proto3" json:"Value,omitempty"`
+	Count int64  `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
 }
-func (*UnimplementedIndexServiceServer) AddDoc(ctx context.Context, req *types.Document) (*AffectedCount, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method AddDoc not implemented")
+
+func (m *FacetValue) Reset()         { *m = FacetValue{} }
+func (m *FacetValue) String() string { return proto.CompactTextString(m) }
+func (*FacetValue) ProtoMessage()    {}
+func (*FacetValue) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{11}
 }
-func (*UnimplementedIndexServiceServer) Search(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+func (m *FacetValue) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-func (*UnimplementedIndexServiceServer) Count(ctx context.Context, req *CountRequest) (*AffectedCount, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+func (m *FacetValue) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FacetValue.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-
-func RegisterIndexServiceServer(s *grpc.Server, srv IndexServiceServer) {
-	s.RegisterService(&_IndexService_serviceDesc, srv)
+func (m *FacetValue) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FacetValue.Merge(m, src)
+}
+func (m *FacetValue) XXX_Size() int {
+	return m.Size()
+}
+func (m *FacetValue) XXX_DiscardUnknown() {
+	xxx_messageInfo_FacetValue.DiscardUnknown(m)
 }
 
-func _IndexService_DeleteDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(DocId)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IndexServiceServer).DeleteDoc(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/index_service.IndexService/DeleteDoc",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IndexServiceServer).DeleteDoc(ctx, req.(*DocId))
+var xxx_messageInfo_FacetValue proto.InternalMessageInfo
+
+func (m *FacetValue) GetValue() string {
+	if m != nil {
+		return m.Value
 	}
-	return interceptor(ctx, in, info, handler)
+	return ""
 }
 
-func _IndexService_AddDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(types.Document)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IndexServiceServer).AddDoc(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/index_service.IndexService/AddDoc",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IndexServiceServer).AddDoc(ctx, req.(*types.Document))
+func (m *FacetValue) GetCount() int64 {
+	if m != nil {
+		return m.Count
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-func _IndexService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(SearchRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IndexServiceServer).Search(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/index_service.IndexService/Search",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IndexServiceServer).Search(ctx, req.(*SearchRequest))
-	}
-	return interceptor(ctx, in, info, handler)
+type FacetResult struct {
+	Total     int64         `protobuf:"varint,1,opt,name=Total,proto3" json:"Total,omitempty"`
+	BitCounts []int64       `protobuf:"varint,2,rep,packed,name=BitCounts,proto3" json:"BitCounts,omitempty"`
+	Values    []*FacetValue `protobuf:"bytes,3,rep,name=Values,proto3" json:"Values,omitempty"`
 }
 
-func _IndexService_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(CountRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(IndexServiceServer).Count(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/index_service.IndexService/Count",
+func (m *FacetResult) Reset()         { *m = FacetResult{} }
+func (m *FacetResult) String() string { return proto.CompactTextString(m) }
+func (*FacetResult) ProtoMessage()    {}
+func (*FacetResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{12}
+}
+func (m *FacetResult) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FacetResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FacetResult.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(IndexServiceServer).Count(ctx, req.(*CountRequest))
+}
+func (m *FacetResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FacetResult.Merge(m, src)
+}
+func (m *FacetResult) XXX_Size() int {
+	return m.Size()
+}
+func (m *FacetResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_FacetResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FacetResult proto.InternalMessageInfo
+
+func (m *FacetResult) GetTotal() int64 {
+	if m != nil {
+		return m.Total
 	}
-	return interceptor(ctx, in, info, handler)
+	return 0
 }
 
-var _IndexService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "index_service.IndexService",
-	HandlerType: (*IndexServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "DeleteDoc",
-			Handler:    _IndexService_DeleteDoc_Handler,
-		},
-		{
-			MethodName: "AddDoc",
-			Handler:    _IndexService_AddDoc_Handler,
-		},
-		{
-			MethodName: "Search",
-			Handler:    _IndexService_Search_Handler,
-		},
-		{
-			MethodName: "Count",
-			Handler:    _IndexService_Count_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "index.proto",
+func (m *FacetResult) GetBitCounts() []int64 {
+	if m != nil {
+		return m.BitCounts
+	}
+	return nil
 }
 
-func (m *DocId) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *FacetResult) GetValues() []*FacetValue {
+	if m != nil {
+		return m.Values
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *DocId) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ExplainRequest struct {
+	Query   *types.TermQuery `protobuf:"bytes,1,opt,name=Query,proto3" json:"Query,omitempty"`
+	OnFlag  uint64           `protobuf:"varint,2,opt,name=OnFlag,proto3" json:"OnFlag,omitempty"`
+	OffFlag uint64           `protobuf:"varint,3,opt,name=OffFlag,proto3" json:"OffFlag,omitempty"`
+	OrFlags []uint64         `protobuf:"varint,4,rep,packed,name=OrFlags,proto3" json:"OrFlags,omitempty"`
+	DocId   string           `protobuf:"bytes,5,opt,name=DocId,proto3" json:"DocId,omitempty"`
 }
 
-func (m *DocId) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.DocId) > 0 {
-		i -= len(m.DocId)
-		copy(dAtA[i:], m.DocId)
-		i = encodeVarintIndex(dAtA, i, uint64(len(m.DocId)))
-		i--
-		dAtA[i] = 0xa
+func (m *ExplainRequest) Reset()         { *m = ExplainRequest{} }
+func (m *ExplainRequest) String() string { return proto.CompactTextString(m) }
+func (*ExplainRequest) ProtoMessage()    {}
+func (*ExplainRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{13}
+}
+func (m *ExplainRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExplainRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExplainRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *ExplainRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExplainRequest.Merge(m, src)
+}
+func (m *ExplainRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExplainRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExplainRequest.DiscardUnknown(m)
 }
 
-func (m *AffectedCount) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ExplainRequest proto.InternalMessageInfo
+
+func (m *ExplainRequest) GetQuery() *types.TermQuery {
+	if m != nil {
+		return m.Query
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *AffectedCount) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *ExplainRequest) GetOnFlag() uint64 {
+	if m != nil {
+		return m.OnFlag
+	}
+	return 0
 }
 
-func (m *AffectedCount) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Count != 0 {
-		i = encodeVarintIndex(dAtA, i, uint64(m.Count))
-		i--
-		dAtA[i] = 0x8
+func (m *ExplainRequest) GetOffFlag() uint64 {
+	if m != nil {
+		return m.OffFlag
 	}
-	return len(dAtA) - i, nil
+	return 0
 }
 
-func (m *SearchRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (m *ExplainRequest) GetOrFlags() []uint64 {
+	if m != nil {
+		return m.OrFlags
+	}
+	return nil
+}
+
+func (m *ExplainRequest) GetDocId() string {
+	if m != nil {
+		return m.DocId
+	}
+	return ""
+}
+
+// 查询树上一个节点的执行情况，树的结构跟TermQuery相同
+type Explanation struct {
+	Op            string         `protobuf:"bytes,1,opt,name=Op,proto3" json:"Op,omitempty"`
+	Query         string         `protobuf:"bytes,2,opt,name=Query,proto3" json:"Query,omitempty"`
+	Negated       bool           `protobuf:"varint,3,opt,name=Negated,proto3" json:"Negated,omitempty"`
+	PostingLength int64          `protobuf:"varint,4,opt,name=PostingLength,proto3" json:"PostingLength,omitempty"`
+	FilteredRows  int64          `protobuf:"varint,5,opt,name=FilteredRows,proto3" json:"FilteredRows,omitempty"`
+	Rows          int64          `protobuf:"varint,6,opt,name=Rows,proto3" json:"Rows,omitempty"`
+	TimeNs        int64          `protobuf:"varint,7,opt,name=TimeNs,proto3" json:"TimeNs,omitempty"`
+	Matched       bool           `protobuf:"varint,8,opt,name=Matched,proto3" json:"Matched,omitempty"`
+	Score         float64        `protobuf:"fixed64,9,opt,name=Score,proto3" json:"Score,omitempty"`
+	Children      []*Explanation `protobuf:"bytes,10,rep,name=Children,proto3" json:"Children,omitempty"`
+}
+
+func (m *Explanation) Reset()         { *m = Explanation{} }
+func (m *Explanation) String() string { return proto.CompactTextString(m) }
+func (*Explanation) ProtoMessage()    {}
+func (*Explanation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f750e0f7889345b5, []int{14}
+}
+func (m *Explanation) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Explanation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Explanation.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Explanation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Explanation.Merge(m, src)
+}
+func (m *Explanation) XXX_Size() int {
+	return m.Size()
+}
+func (m *Explanation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Explanation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Explanation proto.InternalMessageInfo
+
+func (m *Explanation) GetOp() string {
+	if m != nil {
+		return m.Op
+	}
+	return ""
+}
+
+func (m *Explanation) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *Explanation) GetNegated() bool {
+	if m != nil {
+		return m.Negated
+	}
+	return false
+}
+
+func (m *Explanation) GetPostingLength() int64 {
+	if m != nil {
+		return m.PostingLength
+	}
+	return 0
+}
+
+func (m *Explanation) GetFilteredRows() int64 {
+	if m != nil {
+		return m.FilteredRows
+	}
+	return 0
+}
+
+func (m *Explanation) GetRows() int64 {
+	if m != nil {
+		return m.Rows
+	}
+	return 0
+}
+
+func (m *Explanation) GetTimeNs() int64 {
+	if m != nil {
+		return m.TimeNs
+	}
+	return 0
+}
+
+func (m *Explanation) GetMatched() bool {
+	if m != nil {
+		return m.Matched
+	}
+	return false
+}
+
+func (m *Explanation) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *Explanation) GetChildren() []*Explanation {
+	if m != nil {
+		return m.Children
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("index_service.UpdateMask", UpdateMask_name, UpdateMask_value)
+	proto.RegisterType((*DocId)(nil), "index_service.DocId")
+	proto.RegisterType((*AffectedCount)(nil), "index_service.AffectedCount")
+	proto.RegisterType((*DocIds)(nil), "index_service.DocIds")
+	proto.RegisterType((*Documents)(nil), "index_service.Documents")
+	proto.RegisterType((*BatchResult)(nil), "index_service.BatchResult")
+	proto.RegisterType((*DocUpdate)(nil), "index_service.DocUpdate")
+	proto.RegisterMapType((map[string]int64)(nil), "index_service.DocUpdate.NumericsEntry")
+	proto.RegisterType((*SortBy)(nil), "index_service.SortBy")
+	proto.RegisterType((*SearchRequest)(nil), "index_service.SearchRequest")
+	proto.RegisterType((*SearchResult)(nil), "index_service.SearchResult")
+	proto.RegisterType((*CountRequest)(nil), "index_service.CountRequest")
+	proto.RegisterType((*FacetRequest)(nil), "index_service.FacetRequest")
+	proto.RegisterType((*FacetValue)(nil), "index_service.FacetValue")
+	proto.RegisterType((*FacetResult)(nil), "index_service.FacetResult")
+	proto.RegisterType((*ExplainRequest)(nil), "index_service.ExplainRequest")
+	proto.RegisterType((*Explanation)(nil), "index_service.Explanation")
+}
+
+func init() { proto.RegisterFile("index.proto", fileDescriptor_f750e0f7889345b5) }
+
+var fileDescriptor_f750e0f7889345b5 = []byte{
+	// 1042 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0x5f, 0x6f, 0x1a, 0x47,
+	0x10, 0xf7, 0x71, 0xfc, 0x31, 0x03, 0xd8, 0x68, 0x93, 0xb4, 0x5b, 0x9a, 0x20, 0x74, 0x6d, 0x53,
+	0x54, 0xa9, 0xc8, 0x75, 0xa5, 0x34, 0x6a, 0x1e, 0x52, 0x30, 0x20, 0xd1, 0xc6, 0x38, 0x5d, 0x70,
+	0xa5, 0x3e, 0x54, 0xd6, 0xf5, 0x6e, 0xb1, 0x4f, 0x81, 0x3b, 0x72, 0xbb, 0x38, 0xe1, 0x5b, 0xf4,
+	0xb9, 0x5f, 0xa1, 0x55, 0x3f, 0x47, 0x1f, 0xa3, 0x3e, 0xf5, 0x31, 0xb2, 0xbf, 0x48, 0xb4, 0xb3,
+	0x7b, 0x18, 0xb0, 0x1d, 0x1e, 0xf3, 0x36, 0xbf, 0xf9, 0xb3, 0xf3, 0xdb, 0x99, 0xb9, 0xd9, 0x83,
+	0x42, 0x10, 0xfa, 0xfc, 0x75, 0x63, 0x1a, 0x47, 0x32, 0x22, 0x25, 0x04, 0x27, 0x82, 0xc7, 0xe7,
+	0x81, 0xc7, 0x2b, 0x79, 0x3f, 0xf2, 0xb4, 0xa5, 0x52, 0x96, 0x3c, 0x9e, 0x9c, 0xbc, 0x9c, 0xf1,
+	0x78, 0xae, 0x35, 0xce, 0x03, 0xc8, 0xb4, 0x23, 0xaf, 0xe7, 0x93, 0xbb, 0x46, 0xa0, 0x56, 0xcd,
+	0xaa, 0xe7, 0x99, 0x06, 0xce, 0x17, 0x50, 0x6a, 0x8e, 0x46, 0xdc, 0x93, 0xdc, 0x3f, 0x88, 0x66,
+	0xa1, 0x54, 0x6e, 0x28, 0xa0, 0x5b, 0x86, 0x69, 0xe0, 0xd4, 0x20, 0x8b, 0xfe, 0x82, 0x7c, 0x94,
+	0x48, 0xd4, 0xaa, 0xd9, 0xf5, 0x3c, 0x33, 0xc8, 0xd9, 0x83, 0x7c, 0x3b, 0xf2, 0x66, 0x13, 0x1e,
+	0x4a, 0x41, 0x3e, 0x83, 0x74, 0x3b, 0xf2, 0xb4, 0x4b, 0x61, 0x7f, 0xb7, 0x21, 0xe7, 0x53, 0x2e,
+	0x1a, 0x89, 0x9d, 0xa1, 0xd1, 0x79, 0x02, 0x85, 0x96, 0x2b, 0xbd, 0x33, 0xc6, 0xc5, 0x6c, 0x7c,
+	0x4b, 0x62, 0x95, 0xae, 0x13, 0xc7, 0x51, 0x2c, 0x68, 0x4a, 0xa7, 0xd3, 0xc8, 0xf9, 0x2f, 0x85,
+	0xf9, 0x8e, 0xa7, 0xbe, 0x2b, 0x39, 0xd9, 0x81, 0xd4, 0xe2, 0x62, 0xa9, 0x9e, 0x4f, 0x08, 0xa4,
+	0x0f, 0x5d, 0xf1, 0x82, 0xa6, 0x6a, 0x56, 0xbd, 0xc4, 0x50, 0x26, 0x35, 0x28, 0xb4, 0x02, 0x29,
+	0xba, 0xdc, 0x95, 0xb3, 0x98, 0x53, 0xbb, 0x66, 0xd5, 0xd3, 0x6c, 0x59, 0xa5, 0x18, 0xb4, 0xe6,
+	0x92, 0x0b, 0x9a, 0xae, 0x59, 0xf5, 0x22, 0xd3, 0x80, 0xb4, 0x60, 0xbb, 0x3f, 0x9b, 0xf0, 0x38,
+	0xf0, 0x04, 0xcd, 0xe0, 0x7d, 0x1e, 0x36, 0x56, 0xea, 0xdf, 0x58, 0xf0, 0x68, 0x24, 0x8e, 0x9d,
+	0x50, 0xc6, 0x73, 0xb6, 0x88, 0x23, 0x7b, 0x50, 0x68, 0xfa, 0xfe, 0x4f, 0x7c, 0xfe, 0x2a, 0x8a,
+	0x7d, 0x41, 0xb3, 0x78, 0xcc, 0x8e, 0x29, 0x8b, 0x51, 0xb3, 0x65, 0x17, 0xf2, 0x08, 0x76, 0x18,
+	0x9f, 0x44, 0xe7, 0x7c, 0x11, 0x94, 0xbb, 0x31, 0x68, 0xcd, 0xab, 0xf2, 0x04, 0x4a, 0x2b, 0x24,
+	0x48, 0x19, 0xec, 0x17, 0x7c, 0x6e, 0x6a, 0xa3, 0x44, 0x75, 0xcd, 0x73, 0x77, 0x3c, 0xe3, 0x58,
+	0x1d, 0x9b, 0x69, 0xf0, 0x7d, 0xea, 0xb1, 0xe5, 0xec, 0x41, 0x76, 0x10, 0xc5, 0xb2, 0x85, 0x3e,
+	0xdd, 0x80, 0x8f, 0x17, 0xc3, 0x82, 0x40, 0x9d, 0xd5, 0x14, 0x1e, 0xc6, 0x6d, 0x33, 0x25, 0x3a,
+	0x6f, 0x2d, 0x28, 0x0d, 0xb8, 0x1b, 0xab, 0x2e, 0xbe, 0x9c, 0x71, 0x21, 0xc9, 0x43, 0xc8, 0xfc,
+	0xac, 0xc6, 0x0f, 0x23, 0x0b, 0xfb, 0x65, 0xc3, 0x77, 0xc8, 0xe3, 0x09, 0xea, 0x99, 0x36, 0xab,
+	0xc6, 0x1e, 0x85, 0xdd, 0xb1, 0x7b, 0x8a, 0xc7, 0xa5, 0x99, 0x41, 0x84, 0x42, 0xee, 0x68, 0x34,
+	0x42, 0x83, 0x6e, 0x51, 0x02, 0xd1, 0x12, 0x2b, 0x49, 0x35, 0xc8, 0x46, 0x8b, 0x86, 0x78, 0xd6,
+	0x68, 0x24, 0xb8, 0xa4, 0x19, 0x6c, 0xb8, 0x41, 0xea, 0x16, 0xcf, 0x82, 0x49, 0x20, 0x69, 0x16,
+	0xd5, 0x1a, 0x90, 0xaf, 0x93, 0x5b, 0xd2, 0x1c, 0x52, 0xbc, 0xb7, 0xd6, 0x4e, 0x6d, 0x64, 0xc6,
+	0xc9, 0x39, 0x81, 0x62, 0x72, 0x43, 0x9c, 0xd3, 0x2f, 0x21, 0xab, 0xa5, 0xdb, 0xa6, 0xdb, 0x98,
+	0xaf, 0x2a, 0x91, 0x7a, 0x6f, 0x25, 0x9c, 0x1d, 0x28, 0xe2, 0xac, 0x9b, 0x0a, 0x3a, 0x7f, 0x5b,
+	0x50, 0xec, 0xba, 0x1e, 0x97, 0x1f, 0xb2, 0xa4, 0x8b, 0x01, 0xc8, 0x2c, 0x0f, 0x00, 0x81, 0xf4,
+	0x30, 0x9a, 0xf6, 0x4d, 0x3d, 0x51, 0x76, 0x1e, 0x03, 0x20, 0xdb, 0x5f, 0xd4, 0x18, 0xa9, 0x38,
+	0x14, 0x92, 0xc1, 0x59, 0x68, 0xf5, 0xb7, 0x6d, 0x46, 0x0e, 0x81, 0x23, 0xa1, 0x60, 0xee, 0x99,
+	0x2c, 0x80, 0x61, 0x24, 0xdd, 0x31, 0x86, 0xda, 0x4c, 0x03, 0x72, 0x1f, 0xf2, 0xad, 0x40, 0x62,
+	0x80, 0xde, 0x01, 0x36, 0xbb, 0x52, 0x90, 0x6f, 0x20, 0x8b, 0x19, 0x04, 0xb5, 0xb1, 0x19, 0x9f,
+	0xac, 0xf5, 0xf2, 0x8a, 0x19, 0x33, 0x8e, 0xce, 0x9f, 0x16, 0xec, 0x74, 0x5e, 0x4f, 0xc7, 0x6e,
+	0x10, 0x7e, 0xe0, 0x02, 0xeb, 0x75, 0x9c, 0x59, 0x5e, 0xc7, 0x7f, 0xa5, 0xa0, 0x80, 0xe4, 0x42,
+	0x57, 0x06, 0x51, 0xa8, 0x16, 0xdb, 0xd1, 0x34, 0x59, 0x6c, 0x47, 0x53, 0x15, 0x75, 0x35, 0x53,
+	0xf9, 0x84, 0x17, 0x85, 0x5c, 0x9f, 0x9f, 0xba, 0x92, 0xfb, 0x98, 0x7f, 0x9b, 0x25, 0x90, 0x7c,
+	0x0e, 0xa5, 0xe7, 0x91, 0x90, 0x41, 0x78, 0xfa, 0x8c, 0x87, 0xa7, 0xf2, 0x0c, 0x57, 0x9b, 0xcd,
+	0x56, 0x95, 0xc4, 0x81, 0x62, 0x37, 0x18, 0x4b, 0x1e, 0x73, 0x9f, 0x45, 0xaf, 0x04, 0x52, 0xb2,
+	0xd9, 0x8a, 0x4e, 0xb5, 0x1e, 0x6d, 0x59, 0xb4, 0xa1, 0xac, 0xea, 0x31, 0x0c, 0x26, 0xbc, 0x2f,
+	0xf0, 0x4b, 0xb2, 0x99, 0x41, 0x8a, 0xcf, 0xa1, 0xda, 0xec, 0xdc, 0xa7, 0xdb, 0x9a, 0x8f, 0x81,
+	0x8a, 0xff, 0xc0, 0x8b, 0x62, 0x4e, 0xf3, 0x35, 0xab, 0x6e, 0x31, 0x0d, 0xc8, 0x23, 0xd8, 0x3e,
+	0x38, 0x0b, 0xc6, 0x7e, 0xcc, 0x43, 0x0a, 0xd8, 0xc7, 0xca, 0x5a, 0x1f, 0x97, 0x6a, 0xc2, 0x16,
+	0xbe, 0x5f, 0xfd, 0x06, 0xa0, 0x17, 0x2f, 0x2e, 0xf8, 0x5d, 0x28, 0x1c, 0x3f, 0x6f, 0x37, 0x87,
+	0x9d, 0x93, 0xfe, 0x51, 0xbf, 0x53, 0xde, 0x22, 0x1f, 0xc3, 0x1d, 0xa3, 0x68, 0xf5, 0x86, 0x83,
+	0x93, 0x6e, 0xa7, 0x39, 0x3c, 0x66, 0x9d, 0xb2, 0x45, 0xca, 0x50, 0x4c, 0x0c, 0xbf, 0x0e, 0x3b,
+	0x83, 0x72, 0x8a, 0xdc, 0x81, 0xdd, 0x24, 0xf6, 0xf8, 0xb0, 0xc3, 0x7a, 0x07, 0x83, 0x72, 0x7a,
+	0xff, 0x9f, 0x0c, 0x14, 0x7b, 0x8a, 0xc6, 0x40, 0xb3, 0x20, 0x4f, 0x21, 0xdf, 0xe6, 0x63, 0x2e,
+	0x79, 0x3b, 0xf2, 0xc8, 0xdd, 0xeb, 0xaf, 0x40, 0xcf, 0xaf, 0xdc, 0x5f, 0xd3, 0xae, 0x3e, 0xae,
+	0xdf, 0x41, 0xb6, 0xe9, 0xfb, 0x2a, 0x7a, 0x7d, 0x6b, 0x6c, 0x08, 0x6c, 0x02, 0x2c, 0x32, 0x0b,
+	0x72, 0xef, 0xa6, 0xd4, 0x62, 0xc3, 0x11, 0x4f, 0x21, 0xa7, 0x73, 0x0b, 0x42, 0xaf, 0xc7, 0xeb,
+	0x87, 0xbb, 0xb2, 0x5e, 0xf7, 0xe5, 0x07, 0xfa, 0x00, 0xf2, 0xba, 0xda, 0x8a, 0x3f, 0xbd, 0xed,
+	0x0d, 0xdc, 0xc0, 0xe2, 0x00, 0xb2, 0x7a, 0x9b, 0x92, 0x75, 0xbf, 0x95, 0x67, 0xa4, 0xf2, 0xe9,
+	0x2d, 0x56, 0x64, 0xd2, 0x32, 0xeb, 0x84, 0xac, 0x7b, 0x2d, 0xef, 0xd1, 0x0d, 0x44, 0x7e, 0x34,
+	0x5b, 0x57, 0x4f, 0xa6, 0xd8, 0x40, 0xe7, 0xfd, 0x67, 0xfd, 0x00, 0x19, 0x5c, 0x34, 0xd7, 0xf8,
+	0x2c, 0xaf, 0xf1, 0x4a, 0xe5, 0x66, 0x23, 0xde, 0xa8, 0x0d, 0x39, 0xb3, 0x93, 0xc8, 0x83, 0x9b,
+	0x46, 0x3f, 0x08, 0x6f, 0x3b, 0x65, 0xe9, 0xcb, 0x68, 0xd1, 0x7f, 0x2f, 0xaa, 0xd6, 0x9b, 0x8b,
+	0xaa, 0xf5, 0xf6, 0xa2, 0x6a, 0xfd, 0x71, 0x59, 0xdd, 0x7a, 0x73, 0x59, 0xdd, 0xfa, 0xff, 0xb2,
+	0xba, 0xf5, 0x7b, 0x16, 0x7f, 0x06, 0xbf, 0x7d, 0x37, 0x00, 0xce, 0x64, 0xbe, 0x68, 0x47, 0x0a,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// IndexServiceClient is the client API for IndexService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type IndexServiceClient interface {
+	DeleteDoc(ctx context.Context, in *DocId, opts ...grpc.CallOption) (*AffectedCount, error)
+	AddDoc(ctx context.Context, in *types.Document, opts ...grpc.CallOption) (*AffectedCount, error)
+	DeleteDocs(ctx context.Context, in *DocIds, opts ...grpc.CallOption) (*AffectedCount, error)
+	AddDocs(ctx context.Context, in *Documents, opts ...grpc.CallOption) (*BatchResult, error)
+	UpdateDoc(ctx context.Context, in *DocUpdate, opts ...grpc.CallOption) (*AffectedCount, error)
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error)
+	Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*AffectedCount, error)
+	CountMatches(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*AffectedCount, error)
+	Facet(ctx context.Context, in *FacetRequest, opts ...grpc.CallOption) (*FacetResult, error)
+	Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*Explanation, error)
+}
+
+type indexServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewIndexServiceClient(cc *grpc.ClientConn) IndexServiceClient {
+	return &indexServiceClient{cc}
+}
+
+func (c *indexServiceClient) DeleteDoc(ctx context.Context, in *DocId, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/DeleteDoc", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *SearchRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *indexServiceClient) AddDoc(ctx context.Context, in *types.Document, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/AddDoc", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *SearchRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.OrFlags) > 0 {
-		dAtA2 := make([]byte, len(m.OrFlags)*10)
-		var j1 int
-		for _, num := range m.OrFlags {
-			for num >= 1<<7 {
-				dAtA2[j1] = uint8(uint64(num)&0x7f | 0x80)
-				num >>= 7
-				j1++
-			}
-			dAtA2[j1] = uint8(num)
-			j1++
-		}
-		i -= j1
-		copy(dAtA[i:], dAtA2[:j1])
-		i = encodeVarintIndex(dAtA, i, uint64(j1))
-		i--
-		dAtA[i] = 0x22
+func (c *indexServiceClient) DeleteDocs(ctx context.Context, in *DocIds, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/DeleteDocs", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.OffFlag != 0 {
-		i = encodeVarintIndex(dAtA, i, uint64(m.OffFlag))
-		i--
-		dAtA[i] = 0x18
+	return out, nil
+}
+
+func (c *indexServiceClient) AddDocs(ctx context.Context, in *Documents, opts ...grpc.CallOption) (*BatchResult, error) {
+	out := new(BatchResult)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/AddDocs", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.OnFlag != 0 {
-		i = encodeVarintIndex(dAtA, i, uint64(m.OnFlag))
-		i--
-		dAtA[i] = 0x10
+	return out, nil
+}
+
+func (c *indexServiceClient) UpdateDoc(ctx context.Context, in *DocUpdate, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/UpdateDoc", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Query != nil {
-		{
-			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintIndex(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0xa
+	return out, nil
+}
+
+func (c *indexServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResult, error) {
+	out := new(SearchResult)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *SearchResult) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *indexServiceClient) Count(ctx context.Context, in *CountRequest, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/Count", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *SearchResult) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *indexServiceClient) CountMatches(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*AffectedCount, error) {
+	out := new(AffectedCount)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/CountMatches", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (m *SearchResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Result) > 0 {
-		for iNdEx := len(m.Result) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Result[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
+func (c *indexServiceClient) Facet(ctx context.Context, in *FacetRequest, opts ...grpc.CallOption) (*FacetResult, error) {
+	out := new(FacetResult)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/Facet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *indexServiceClient) Explain(ctx context.Context, in *ExplainRequest, opts ...grpc.CallOption) (*Explanation, error) {
+	out := new(Explanation)
+	err := c.cc.Invoke(ctx, "/index_service.IndexService/Explain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IndexServiceServer is the server API for IndexService service.
+type IndexServiceServer interface {
+	DeleteDoc(context.Context, *DocId) (*AffectedCount, error)
+	AddDoc(context.Context, *types.Document) (*AffectedCount, error)
+	DeleteDocs(context.Context, *DocIds) (*AffectedCount, error)
+	AddDocs(context.Context, *Documents) (*BatchResult, error)
+	UpdateDoc(context.Context, *DocUpdate) (*AffectedCount, error)
+	Search(context.Context, *SearchRequest) (*SearchResult, error)
+	Count(context.Context, *CountRequest) (*AffectedCount, error)
+	CountMatches(context.Context, *SearchRequest) (*AffectedCount, error)
+	Facet(context.Context, *FacetRequest) (*FacetResult, error)
+	Explain(context.Context, *ExplainRequest) (*Explanation, error)
+}
+
+// UnimplementedIndexServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedIndexServiceServer struct {
+}
+
+func (*UnimplementedIndexServiceServer) DeleteDoc(ctx context.Context, req *DocId) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDoc not implemented")
+}
+func (*UnimplementedIndexServiceServer) AddDoc(ctx context.Context, req *types.Document) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddDoc not implemented")
+}
+func (*UnimplementedIndexServiceServer) DeleteDocs(ctx context.Context, req *DocIds) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteDocs not implemented")
+}
+func (*UnimplementedIndexServiceServer) AddDocs(ctx context.Context, req *Documents) (*BatchResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddDocs not implemented")
+}
+func (*UnimplementedIndexServiceServer) UpdateDoc(ctx context.Context, req *DocUpdate) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDoc not implemented")
+}
+func (*UnimplementedIndexServiceServer) Search(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (*UnimplementedIndexServiceServer) Count(ctx context.Context, req *CountRequest) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Count not implemented")
+}
+func (*UnimplementedIndexServiceServer) CountMatches(ctx context.Context, req *SearchRequest) (*AffectedCount, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountMatches not implemented")
+}
+func (*UnimplementedIndexServiceServer) Facet(ctx context.Context, req *FacetRequest) (*FacetResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Facet not implemented")
+}
+func (*UnimplementedIndexServiceServer) Explain(ctx context.Context, req *ExplainRequest) (*Explanation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Explain not implemented")
+}
+
+func RegisterIndexServiceServer(s *grpc.Server, srv IndexServiceServer) {
+	s.RegisterService(&_IndexService_serviceDesc, srv)
+}
+
+func _IndexService_DeleteDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocId)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).DeleteDoc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/DeleteDoc",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).DeleteDoc(ctx, req.(*DocId))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_AddDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(types.Document)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).AddDoc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/AddDoc",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).AddDoc(ctx, req.(*types.Document))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_DeleteDocs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocIds)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).DeleteDocs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/DeleteDocs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).DeleteDocs(ctx, req.(*DocIds))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_AddDocs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Documents)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).AddDocs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/AddDocs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).AddDocs(ctx, req.(*Documents))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_UpdateDoc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DocUpdate)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).UpdateDoc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/UpdateDoc",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).UpdateDoc(ctx, req.(*DocUpdate))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_Count_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).Count(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/Count",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).Count(ctx, req.(*CountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_CountMatches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).CountMatches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/CountMatches",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).CountMatches(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_Facet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FacetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).Facet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/Facet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).Facet(ctx, req.(*FacetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IndexService_Explain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExplainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IndexServiceServer).Explain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/index_service.IndexService/Explain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IndexServiceServer).Explain(ctx, req.(*ExplainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _IndexService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "index_service.IndexService",
+	HandlerType: (*IndexServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "DeleteDoc",
+			Handler:    _IndexService_DeleteDoc_Handler,
+		},
+		{
+			MethodName: "AddDoc",
+			Handler:    _IndexService_AddDoc_Handler,
+		},
+		{
+			MethodName: "DeleteDocs",
+			Handler:    _IndexService_DeleteDocs_Handler,
+		},
+		{
+			MethodName: "AddDocs",
+			Handler:    _IndexService_AddDocs_Handler,
+		},
+		{
+			MethodName: "UpdateDoc",
+			Handler:    _IndexService_UpdateDoc_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _IndexService_Search_Handler,
+		},
+		{
+			MethodName: "Count",
+			Handler:    _IndexService_Count_Handler,
+		},
+		{
+			MethodName: "CountMatches",
+			Handler:    _IndexService_CountMatches_Handler,
+		},
+		{
+			MethodName: "Facet",
+			Handler:    _IndexService_Facet_Handler,
+		},
+		{
+			MethodName: "Explain",
+			Handler:    _IndexService_Explain_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "index.proto",
+}
+
+func (m *DocId) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DocId) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DocId) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.DocId) > 0 {
+		i -= len(m.DocId)
+		copy(dAtA[i:], m.DocId)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.DocId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AffectedCount) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AffectedCount) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AffectedCount) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Count != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DocIds) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DocIds) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DocIds) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.DocIds) > 0 {
+		for iNdEx := len(m.DocIds) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.DocIds[iNdEx])
+			copy(dAtA[i:], m.DocIds[iNdEx])
+			i = encodeVarintIndex(dAtA, i, uint64(len(m.DocIds[iNdEx])))
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Documents) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Documents) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Documents) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Docs) > 0 {
+		for iNdEx := len(m.Docs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Docs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BatchResult) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BatchResult) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BatchResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Errors) > 0 {
+		for iNdEx := len(m.Errors) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Errors[iNdEx])
+			copy(dAtA[i:], m.Errors[iNdEx])
+			i = encodeVarintIndex(dAtA, i, uint64(len(m.Errors[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Count != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DocUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DocUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DocUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.RemoveKeywords) > 0 {
+		for iNdEx := len(m.RemoveKeywords) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.RemoveKeywords[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.AddKeywords) > 0 {
+		for iNdEx := len(m.AddKeywords) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.AddKeywords[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
+		}
+	}
+	if len(m.Numerics) > 0 {
+		for k := range m.Numerics {
+			v := m.Numerics[k]
+			baseI := i
+			i = encodeVarintIndex(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintIndex(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintIndex(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.Bytes) > 0 {
+		i -= len(m.Bytes)
+		copy(dAtA[i:], m.Bytes)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Bytes)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.BitsFeature != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.BitsFeature))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Mask != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Mask))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Id) > 0 {
+		i -= len(m.Id)
+		copy(dAtA[i:], m.Id)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Id)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SortBy) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SortBy) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SortBy) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Asc {
+		i--
+		if m.Asc {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Field) > 0 {
+		i -= len(m.Field)
+		copy(dAtA[i:], m.Field)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Field)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SearchRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SearchRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SearchRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.SortBy != nil {
+		{
+			size, err := m.SortBy.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.Limit != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Offset != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.OrFlags) > 0 {
+		dAtA3 := make([]byte, len(m.OrFlags)*10)
+		var j2 int
+		for _, num := range m.OrFlags {
+			for num >= 1<<7 {
+				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j2++
+			}
+			dAtA3[j2] = uint8(num)
+			j2++
+		}
+		i -= j2
+		copy(dAtA[i:], dAtA3[:j2])
+		i = encodeVarintIndex(dAtA, i, uint64(j2))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.OffFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OffFlag))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.OnFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OnFlag))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SearchResult) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SearchResult) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SearchResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Result) > 0 {
+		for iNdEx := len(m.Result) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Result[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CountRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CountRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CountRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *FacetRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FacetRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FacetRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.TopN != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.TopN))
+		i--
+		dAtA[i] = 0x30
+	}
+	if len(m.Field) > 0 {
+		i -= len(m.Field)
+		copy(dAtA[i:], m.Field)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Field)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.OrFlags) > 0 {
+		dAtA7 := make([]byte, len(m.OrFlags)*10)
+		var j6 int
+		for _, num := range m.OrFlags {
+			for num >= 1<<7 {
+				dAtA7[j6] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j6++
+			}
+			dAtA7[j6] = uint8(num)
+			j6++
+		}
+		i -= j6
+		copy(dAtA[i:], dAtA7[:j6])
+		i = encodeVarintIndex(dAtA, i, uint64(j6))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.OffFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OffFlag))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.OnFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OnFlag))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FacetValue) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FacetValue) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FacetValue) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Count != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FacetResult) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FacetResult) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FacetResult) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Values[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.BitCounts) > 0 {
+		dAtA10 := make([]byte, len(m.BitCounts)*10)
+		var j9 int
+		for _, num1 := range m.BitCounts {
+			num := uint64(num1)
+			for num >= 1<<7 {
+				dAtA10[j9] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j9++
+			}
+			dAtA10[j9] = uint8(num)
+			j9++
+		}
+		i -= j9
+		copy(dAtA[i:], dAtA10[:j9])
+		i = encodeVarintIndex(dAtA, i, uint64(j9))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Total != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Total))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExplainRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExplainRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExplainRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.DocId) > 0 {
+		i -= len(m.DocId)
+		copy(dAtA[i:], m.DocId)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.DocId)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.OrFlags) > 0 {
+		dAtA12 := make([]byte, len(m.OrFlags)*10)
+		var j11 int
+		for _, num := range m.OrFlags {
+			for num >= 1<<7 {
+				dAtA12[j11] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j11++
+			}
+			dAtA12[j11] = uint8(num)
+			j11++
+		}
+		i -= j11
+		copy(dAtA[i:], dAtA12[:j11])
+		i = encodeVarintIndex(dAtA, i, uint64(j11))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.OffFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OffFlag))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.OnFlag != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.OnFlag))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Query != nil {
+		{
+			size, err := m.Query.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIndex(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Explanation) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Explanation) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Explanation) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Children) > 0 {
+		for iNdEx := len(m.Children) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Children[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIndex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	if m.Score != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Score))))
+		i--
+		dAtA[i] = 0x49
+	}
+	if m.Matched {
+		i--
+		if m.Matched {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.TimeNs != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.TimeNs))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Rows != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.Rows))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.FilteredRows != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.FilteredRows))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.PostingLength != 0 {
+		i = encodeVarintIndex(dAtA, i, uint64(m.PostingLength))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Negated {
+		i--
+		if m.Negated {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Query) > 0 {
+		i -= len(m.Query)
+		copy(dAtA[i:], m.Query)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Query)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Op) > 0 {
+		i -= len(m.Op)
+		copy(dAtA[i:], m.Op)
+		i = encodeVarintIndex(dAtA, i, uint64(len(m.Op)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintIndex(dAtA []byte, offset int, v uint64) int {
+	offset -= sovIndex(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *DocId) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.DocId)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	return n
+}
+
+func (m *AffectedCount) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Count != 0 {
+		n += 1 + sovIndex(uint64(m.Count))
+	}
+	return n
+}
+
+func (m *DocIds) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.DocIds) > 0 {
+		for _, s := range m.DocIds {
+			l = len(s)
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Documents) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Docs) > 0 {
+		for _, e := range m.Docs {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *BatchResult) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Count != 0 {
+		n += 1 + sovIndex(uint64(m.Count))
+	}
+	if len(m.Errors) > 0 {
+		for _, s := range m.Errors {
+			l = len(s)
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *DocUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Id)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.Mask != 0 {
+		n += 1 + sovIndex(uint64(m.Mask))
+	}
+	if m.BitsFeature != 0 {
+		n += 1 + sovIndex(uint64(m.BitsFeature))
+	}
+	l = len(m.Bytes)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if len(m.Numerics) > 0 {
+		for k, v := range m.Numerics {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovIndex(uint64(len(k))) + 1 + sovIndex(uint64(v))
+			n += mapEntrySize + 1 + sovIndex(uint64(mapEntrySize))
+		}
+	}
+	if len(m.AddKeywords) > 0 {
+		for _, e := range m.AddKeywords {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	if len(m.RemoveKeywords) > 0 {
+		for _, e := range m.RemoveKeywords {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *SortBy) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Field)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.Asc {
+		n += 2
+	}
+	return n
+}
+
+func (m *SearchRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.OnFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OnFlag))
+	}
+	if m.OffFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OffFlag))
+	}
+	if len(m.OrFlags) > 0 {
+		l = 0
+		for _, e := range m.OrFlags {
+			l += sovIndex(uint64(e))
+		}
+		n += 1 + sovIndex(uint64(l)) + l
+	}
+	if m.Offset != 0 {
+		n += 1 + sovIndex(uint64(m.Offset))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovIndex(uint64(m.Limit))
+	}
+	if m.SortBy != nil {
+		l = m.SortBy.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	return n
+}
+
+func (m *SearchResult) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Result) > 0 {
+		for _, e := range m.Result {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	return n
+}
+
+func (m *CountRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *FacetRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.OnFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OnFlag))
+	}
+	if m.OffFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OffFlag))
+	}
+	if len(m.OrFlags) > 0 {
+		l = 0
+		for _, e := range m.OrFlags {
+			l += sovIndex(uint64(e))
+		}
+		n += 1 + sovIndex(uint64(l)) + l
+	}
+	l = len(m.Field)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.TopN != 0 {
+		n += 1 + sovIndex(uint64(m.TopN))
+	}
+	return n
+}
+
+func (m *FacetValue) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.Count != 0 {
+		n += 1 + sovIndex(uint64(m.Count))
+	}
+	return n
+}
+
+func (m *FacetResult) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Total != 0 {
+		n += 1 + sovIndex(uint64(m.Total))
+	}
+	if len(m.BitCounts) > 0 {
+		l = 0
+		for _, e := range m.BitCounts {
+			l += sovIndex(uint64(e))
+		}
+		n += 1 + sovIndex(uint64(l)) + l
+	}
+	if len(m.Values) > 0 {
+		for _, e := range m.Values {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ExplainRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Query != nil {
+		l = m.Query.Size()
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.OnFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OnFlag))
+	}
+	if m.OffFlag != 0 {
+		n += 1 + sovIndex(uint64(m.OffFlag))
+	}
+	if len(m.OrFlags) > 0 {
+		l = 0
+		for _, e := range m.OrFlags {
+			l += sovIndex(uint64(e))
+		}
+		n += 1 + sovIndex(uint64(l)) + l
+	}
+	l = len(m.DocId)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	return n
+}
+
+func (m *Explanation) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Op)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	l = len(m.Query)
+	if l > 0 {
+		n += 1 + l + sovIndex(uint64(l))
+	}
+	if m.Negated {
+		n += 2
+	}
+	if m.PostingLength != 0 {
+		n += 1 + sovIndex(uint64(m.PostingLength))
+	}
+	if m.FilteredRows != 0 {
+		n += 1 + sovIndex(uint64(m.FilteredRows))
+	}
+	if m.Rows != 0 {
+		n += 1 + sovIndex(uint64(m.Rows))
+	}
+	if m.TimeNs != 0 {
+		n += 1 + sovIndex(uint64(m.TimeNs))
+	}
+	if m.Matched {
+		n += 2
+	}
+	if m.Score != 0 {
+		n += 9
+	}
+	if len(m.Children) > 0 {
+		for _, e := range m.Children {
+			l = e.Size()
+			n += 1 + l + sovIndex(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovIndex(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozIndex(x uint64) (n int) {
+	return sovIndex(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *DocId) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DocId: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DocId: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DocId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DocId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AffectedCount) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AffectedCount: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AffectedCount: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DocIds) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DocIds: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DocIds: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DocIds", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DocIds = append(m.DocIds, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Documents) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Documents: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Documents: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Docs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Docs = append(m.Docs, &types.Document{})
+			if err := m.Docs[len(m.Docs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BatchResult) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BatchResult: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BatchResult: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Errors", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Errors = append(m.Errors, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *DocUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DocUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DocUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Id", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Id = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mask", wireType)
+			}
+			m.Mask = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mask |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BitsFeature", wireType)
+			}
+			m.BitsFeature = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BitsFeature |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bytes", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Bytes = append(m.Bytes[:0], dAtA[iNdEx:postIndex]...)
+			if m.Bytes == nil {
+				m.Bytes = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Numerics", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Numerics == nil {
+				m.Numerics = make(map[string]int64)
+			}
+			var mapkey string
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthIndex
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthIndex
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipIndex(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthIndex
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Numerics[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddKeywords", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AddKeywords = append(m.AddKeywords, &types.Keyword{})
+			if err := m.AddKeywords[len(m.AddKeywords)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RemoveKeywords", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RemoveKeywords = append(m.RemoveKeywords, &types.Keyword{})
+			if err := m.RemoveKeywords[len(m.RemoveKeywords)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SortBy) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SortBy: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SortBy: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Field", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Field = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Asc", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Asc = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SearchRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SearchRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SearchRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Query == nil {
+				m.Query = &types.TermQuery{}
+			}
+			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OnFlag", wireType)
+			}
+			m.OnFlag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OnFlag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OffFlag", wireType)
+			}
+			m.OffFlag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OffFlag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.OrFlags = append(m.OrFlags, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthIndex
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthIndex
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.OrFlags) == 0 {
+					m.OrFlags = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.OrFlags = append(m.OrFlags, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field OrFlags", wireType)
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SortBy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.SortBy == nil {
+				m.SortBy = &SortBy{}
+			}
+			if err := m.SortBy.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SearchResult) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SearchResult: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SearchResult: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Result = append(m.Result, &types.Document{})
+			if err := m.Result[len(m.Result)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Query == nil {
+				m.Query = &types.TermQuery{}
+			}
+			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CountRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CountRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CountRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FacetRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIndex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FacetRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FacetRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Query == nil {
+				m.Query = &types.TermQuery{}
+			}
+			if err := m.Query.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OnFlag", wireType)
+			}
+			m.OnFlag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OnFlag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OffFlag", wireType)
+			}
+			m.OffFlag = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OffFlag |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.OrFlags = append(m.OrFlags, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthIndex
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthIndex
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.OrFlags) == 0 {
+					m.OrFlags = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.OrFlags = append(m.OrFlags, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field OrFlags", wireType)
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Field", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Field = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TopN", wireType)
+			}
+			m.TopN = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TopN |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-				i -= size
-				i = encodeVarintIndex(dAtA, i, uint64(size))
 			}
-			i--
-			dAtA[i] = 0xa
-		}
-	}
-	return len(dAtA) - i, nil
-}
-
-func (m *CountRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
-}
-
-func (m *CountRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *CountRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	return len(dAtA) - i, nil
-}
-
-func encodeVarintIndex(dAtA []byte, offset int, v uint64) int {
-	offset -= sovIndex(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
-	return base
-}
-func (m *DocId) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.DocId)
-	if l > 0 {
-		n += 1 + l + sovIndex(uint64(l))
-	}
-	return n
-}
-
-func (m *AffectedCount) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Count != 0 {
-		n += 1 + sovIndex(uint64(m.Count))
-	}
-	return n
-}
-
-func (m *SearchRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Query != nil {
-		l = m.Query.Size()
-		n += 1 + l + sovIndex(uint64(l))
-	}
-	if m.OnFlag != 0 {
-		n += 1 + sovIndex(uint64(m.OnFlag))
-	}
-	if m.OffFlag != 0 {
-		n += 1 + sovIndex(uint64(m.OffFlag))
-	}
-	if len(m.OrFlags) > 0 {
-		l = 0
-		for _, e := range m.OrFlags {
-			l += sovIndex(uint64(e))
-		}
-		n += 1 + sovIndex(uint64(l)) + l
-	}
-	return n
-}
-
-func (m *SearchResult) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if len(m.Result) > 0 {
-		for _, e := range m.Result {
-			l = e.Size()
-			n += 1 + l + sovIndex(uint64(l))
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIndex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
 
-func (m *CountRequest) Size() (n int) {
-	if m == nil {
-		return 0
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	var l int
-	_ = l
-	return n
-}
-
-func sovIndex(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozIndex(x uint64) (n int) {
-	return sovIndex(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *DocId) Unmarshal(dAtA []byte) error {
+func (m *FacetValue) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -784,15 +4225,15 @@ func (m *DocId) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: DocId: wiretype end group for non-group")
+			return fmt.Errorf("proto: FacetValue: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: DocId: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FacetValue: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DocId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -820,8 +4261,27 @@ func (m *DocId) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.DocId = string(dAtA[iNdEx:postIndex])
+			m.Value = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIndex(dAtA[iNdEx:])
@@ -843,7 +4303,7 @@ func (m *DocId) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AffectedCount) Unmarshal(dAtA []byte) error {
+func (m *FacetResult) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -866,17 +4326,17 @@ func (m *AffectedCount) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AffectedCount: wiretype end group for non-group")
+			return fmt.Errorf("proto: FacetResult: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AffectedCount: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FacetResult: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
 			}
-			m.Count = 0
+			m.Total = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIndex
@@ -886,11 +4346,121 @@ func (m *AffectedCount) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Count |= int32(b&0x7F) << shift
+				m.Total |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType == 0 {
+				var v int64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= int64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.BitCounts = append(m.BitCounts, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowIndex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthIndex
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthIndex
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.BitCounts) == 0 {
+					m.BitCounts = make([]int64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v int64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowIndex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.BitCounts = append(m.BitCounts, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field BitCounts", wireType)
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, &FacetValue{})
+			if err := m.Values[len(m.Values)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIndex(dAtA[iNdEx:])
@@ -912,7 +4482,7 @@ func (m *AffectedCount) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SearchRequest) Unmarshal(dAtA []byte) error {
+func (m *ExplainRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -935,10 +4505,10 @@ func (m *SearchRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SearchRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ExplainRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SearchRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ExplainRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1091,6 +4661,38 @@ func (m *SearchRequest) Unmarshal(dAtA []byte) error {
 			} else {
 				return fmt.Errorf("proto: wrong wireType = %d for field OrFlags", wireType)
 			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DocId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DocId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIndex(dAtA[iNdEx:])
@@ -1112,7 +4714,7 @@ func (m *SearchRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SearchResult) Unmarshal(dAtA []byte) error {
+func (m *Explanation) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1135,17 +4737,17 @@ func (m *SearchResult) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SearchResult: wiretype end group for non-group")
+			return fmt.Errorf("proto: Explanation: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SearchResult: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: Explanation: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Result", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIndex
@@ -1155,76 +4757,217 @@ func (m *SearchResult) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIndex
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIndex
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Result = append(m.Result, &types.Document{})
-			if err := m.Result[len(m.Result)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Op = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIndex(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Query", wireType)
 			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIndex
 			}
-			if (iNdEx + skippy) > l {
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *CountRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIndex
+			m.Query = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Negated", wireType)
 			}
-			if iNdEx >= l {
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Negated = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PostingLength", wireType)
+			}
+			m.PostingLength = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PostingLength |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FilteredRows", wireType)
+			}
+			m.FilteredRows = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FilteredRows |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rows", wireType)
+			}
+			m.Rows = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Rows |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimeNs", wireType)
+			}
+			m.TimeNs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimeNs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matched", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Matched = bool(v != 0)
+		case 9:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Score", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Score = float64(math.Float64frombits(v))
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Children", wireType)
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: CountRequest: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: CountRequest: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIndex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIndex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIndex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Children = append(m.Children, &Explanation{})
+			if err := m.Children[len(m.Children)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIndex(dAtA[iNdEx:])
@@ -19,9 +19,9 @@ type IndexServiceWorker struct {
 }
 
 // 初始化索引
-func (service *IndexServiceWorker) Init(DocNumEstimate int, dbType int, DataDir string) error {
+func (service *IndexServiceWorker) Init(DocNumEstimate int, dbType int, reverseIndexType int, DataDir string) error {
 	service.Indexer = new(Indexer)
-	return service.Indexer.Init(DocNumEstimate, dbType, DataDir)
+	return service.Indexer.Init(DocNumEstimate, dbType, reverseIndexType, DataDir)
 }
 
 // 向注册中心注册自己
@@ -73,13 +73,48 @@ func (service *IndexServiceWorker) AddDoc(ctx context.Context, doc *types.Docume
 	return &AffectedCount{int32(n)}, err
 }
 
-// 检索，返回文档列表
+// 批量删除文档
+func (service *IndexServiceWorker) DeleteDocs(ctx context.Context, docIds *DocIds) (*AffectedCount, error) {
+	return &AffectedCount{int32(service.Indexer.DeleteDocs(docIds.DocIds))}, nil
+}
+
+// 批量添加文档，每个文档的错误放在BatchResult.Errors里
+func (service *IndexServiceWorker) AddDocs(ctx context.Context, docs *Documents) (*BatchResult, error) {
+	list := make([]types.Document, 0, len(docs.Docs))
+	for _, doc := range docs.Docs {
+		list = append(list, *doc)
+	}
+	n, errs := service.Indexer.AddDocs(list)
+	return &BatchResult{Count: int32(n), Errors: errorStrings(errs)}, nil
+}
+
+// 修改文档的部分字段
+func (service *IndexServiceWorker) UpdateDoc(ctx context.Context, update *DocUpdate) (*AffectedCount, error) {
+	n, err := service.Indexer.UpdateDoc(update)
+	return &AffectedCount{int32(n)}, err
+}
+
+// 检索，返回按request.SortBy排好序的一页文档
 func (service *IndexServiceWorker) Search(ctx context.Context, request *SearchRequest) (*SearchResult, error) {
-	result := service.Indexer.Search(request.Query, request.OnFlag, request.OffFlag, request.OrFlags)
-	return &SearchResult{Result: result}, nil
+	return service.Indexer.SearchPage(request), nil
 }
 
 // 索引里有几个文档
 func (service *IndexServiceWorker) Count(ctx context.Context, request *CountRequest) (*AffectedCount, error) {
 	return &AffectedCount{int32(service.Indexer.Count())}, nil
 }
+
+// 命中query的文档数
+func (service *IndexServiceWorker) CountMatches(ctx context.Context, request *SearchRequest) (*AffectedCount, error) {
+	return &AffectedCount{int32(service.Indexer.CountMatches(request.Query, request.OnFlag, request.OffFlag, request.OrFlags))}, nil
+}
+
+// 分面统计
+func (service *IndexServiceWorker) Facet(ctx context.Context, request *FacetRequest) (*FacetResult, error) {
+	return service.Indexer.Facet(request), nil
+}
+
+// 查询执行情况
+func (service *IndexServiceWorker) Explain(ctx context.Context, request *ExplainRequest) (*Explanation, error) {
+	return service.Indexer.Explain(request), nil
+}
@@ -1,81 +1,195 @@
 package index_service
 
 import (
-	"bytes"
-	"encoding/gob"
 	"errors"
+	"github.com/Muoshu/myRadic/analysis"
 	"github.com/Muoshu/myRadic/internal/kvdb"
 	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/internal/wal"
 	"github.com/Muoshu/myRadic/types"
 	"github.com/Muoshu/myRadic/util"
+	"sort"
 	"strings"
+	"sync"
 	"sync/atomic"
 )
 
 // 外观Facade模式。把正排和倒排2个子系统封装到了一起
 type Indexer struct {
-	forwardIndex kvdb.IKeyValueDB
-	reverseIndex reverseindex.IReverseIndexer
-	maxIntId     uint64
+	forwardIndex  kvdb.IKeyValueDB
+	reverseIndex  reverseindex.IReverseIndexer
+	maxIntId      uint64     //最近分配的IntId
+	reservedIntId uint64     //已经持久化到正排索引的IntId上限，maxIntId超过它时再预留一块
+	intIdLock     sync.Mutex //预留IntId时加锁
+	docCount      int64      //正排索引里的文档数，增删文档时实时维护
+	codec         DocumentCodec
+	synonyms      *analysis.Synonyms
+	wal           *wal.WAL     //正排和倒排索引的每次修改都先写进预写日志
+	walLock       sync.RWMutex //修改索引时加读锁，checkpoint时加写锁
+	docLocks      docLocks     //同一篇文档的写操作串行执行
 }
 
-func (indexer *Indexer) Init(DocNumEstimate int, dbType int, dataDir string) error {
+// WithSynonyms builder模式，检索前把查询里的Keyword展开成同义词
+func (indexer *Indexer) WithSynonyms(synonyms *analysis.Synonyms) *Indexer {
+	indexer.synonyms = synonyms
+	return indexer
+}
+
+// WithCodec builder模式，指定文档在正排索引里的格式，需要在Init之前调用。默认用ProtoCodec
+func (indexer *Indexer) WithCodec(codec DocumentCodec) *Indexer {
+	indexer.codec = codec
+	return indexer
+}
+
+// Init reverseIndexType决定倒排索引的实现方式，取值见reverse_index包里的SKIPLIST、ROARING、SEGMENT。持久化的倒排索引存放在dataDir+"_reverse"目录下，
+// 预写日志存放在dataDir+"_wal"目录下，上次没有正常关闭时会先重放日志
+func (indexer *Indexer) Init(DocNumEstimate int, dbType int, reverseIndexType int, dataDir string) error {
 	db, err := kvdb.GetKvDb(dbType, dataDir)
 	if err != nil {
 		return err
 	}
+	reverseIndex, err := reverseindex.GetReverseIndexer(reverseIndexType, DocNumEstimate, dataDir+"_reverse", true)
+	if err != nil {
+		db.Close()
+		return err
+	}
 	indexer.forwardIndex = db
-	indexer.reverseIndex = reverseindex.NewSkipListReverseIndex(DocNumEstimate)
+	indexer.reverseIndex = reverseIndex
+	if indexer.codec == nil {
+		indexer.codec = ProtoCodec{}
+	}
+	if err := indexer.migrateIfNeeded(); err != nil {
+		indexer.Close()
+		return err
+	}
+	if err := indexer.openWal(dataDir + "_wal"); err != nil {
+		indexer.Close()
+		return err
+	}
+	//只在启动时遍历一次key，之后随增删实时更新
+	var docCount int64
+	db.IterKey(func(k []byte) error {
+		if !isMetaKey(k) {
+			docCount++
+		}
+		return nil
+	})
+	indexer.docCount = docCount
+	if err := indexer.recoverIntId(); err != nil {
+		indexer.Close()
+		return err
+	}
 	return nil
 }
 
 // LoadFromIndexFile 系统重启时，直接从索引文件里加载数据,其中v是document序列化后的字节流
 func (indexer *Indexer) LoadFromIndexFile() int {
-	reader := bytes.NewReader([]byte{})
-	n := indexer.forwardIndex.IterDB(func(k, v []byte) error {
-		reader.Reset(v)
-		decoder := gob.NewDecoder(reader)
-		var doc types.Document
-		err := decoder.Decode(&doc)
+	//倒排索引能从磁盘加载时，不需要再逐篇解码正排索引来重建
+	persistent, ok := indexer.reverseIndex.(reverseindex.IPersistentReverseIndexer)
+	if ok && persistent.Loaded() {
+		indexer.observeIntId(persistent.MaxIntId())
+		n := persistent.DocCount()
+		util.Log.Printf("load %d data from reverse index segments", n)
+		return n
+	}
+	var n int64
+	indexer.forwardIndex.IterDB(func(k, v []byte) error {
+		if isMetaKey(k) {
+			return nil
+		}
+		doc, err := indexer.codec.Decode(v)
 		if err != nil {
-			util.Log.Printf("gob decode document failed：%s", err)
+			util.Log.Printf("decode document failed：%s", err)
 			return err
 		}
-		indexer.reverseIndex.Add(doc)
+		indexer.reverseIndex.Add(*doc)
+		indexer.observeIntId(doc.IntId) //新文档的IntId不能跟已有的重复
+		n++
 		return nil
 	})
 	util.Log.Printf("load %d data from forward index %s", n, indexer.forwardIndex.GetDbPath())
+	if ok { //重建完立即落盘，下次启动直接从磁盘加载
+		if err := persistent.Flush(); err != nil {
+			util.Log.Printf("flush reverse index failed: %s", err)
+		}
+	}
 	return int(n)
 }
 
-// 关闭索引
+// 关闭索引。倒排索引落盘成功后才清空预写日志
 func (indexer *Indexer) Close() error {
+	closed := true
+	if persistent, ok := indexer.reverseIndex.(reverseindex.IPersistentReverseIndexer); ok {
+		if err := persistent.Close(); err != nil {
+			util.Log.Printf("close reverse index failed: %s", err)
+			closed = false
+		}
+	}
+	if indexer.wal != nil {
+		if closed {
+			if err := indexer.checkpoint(); err != nil {
+				util.Log.Printf("checkpoint failed: %s", err)
+			}
+		}
+		indexer.wal.Close()
+	}
 	return indexer.forwardIndex.Close()
 }
 
+// 从正排索引上读取文档。文档存在但解码失败时，返回的文档为nil
+func (indexer *Indexer) getDoc(docId string) (*types.Document, bool) {
+	if isMetaKey([]byte(docId)) {
+		return nil, false
+	}
+	docBytes, err := indexer.forwardIndex.Get([]byte(docId))
+	if err != nil || len(docBytes) == 0 {
+		return nil, false
+	}
+	doc, err := indexer.codec.Decode(docBytes)
+	if err != nil {
+		return nil, true
+	}
+	return doc, true
+}
+
+// 读出正排索引里的原始记录，不存在时返回nil
+func (indexer *Indexer) getRaw(key []byte) []byte {
+	value, err := indexer.forwardIndex.Get(key)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
 func (indexer *Indexer) DeleteDoc(docId string) int {
-	n := 0
+	if isMetaKey([]byte(docId)) {
+		return 0
+	}
+	defer indexer.docLocks.lock(docId)()
 	forwardKey := []byte(docId)
 	//先读正排索引，得到IntId和Keywords
-	docBytes, err := indexer.forwardIndex.Get(forwardKey)
-	if err == nil {
-		reader := bytes.NewReader([]byte{})
-		if len(docBytes) > 0 {
-			n = 1
-			reader.Reset(docBytes)
-			decoder := gob.NewDecoder(reader)
-			var doc types.Document
-			err := decoder.Decode(&doc)
-			if err == nil {
-				// 遍历每一个keyword，从倒排索引上删除
-				for _, keyword := range doc.Keywords {
-					indexer.reverseIndex.Delete(doc.IntId, keyword)
-				}
-			}
-		}
+	oldValue := indexer.getRaw(forwardKey)
+	if len(oldValue) == 0 {
+		return 0
+	}
+	record := deleteRecord(docId, oldValue)
+	done, err := indexer.logWrite(record)
+	if err != nil {
+		util.Log.Printf("write wal failed: %s", err)
+		return 0
+	}
+	defer done()
+	//日志已经落盘，删除失败时两边都保持原样，撤销掉日志里的记录
+	if err := indexer.forwardIndex.Delete(forwardKey); err != nil {
+		util.Log.Printf("delete %s from forward index failed: %s", docId, err)
+		indexer.abortWrite(record)
+		return 0
 	}
-	indexer.forwardIndex.Delete(forwardKey)
-	return n
+	atomic.AddInt64(&indexer.docCount, -1)
+	if doc, err := indexer.codec.Decode(oldValue); err == nil {
+		indexer.reverseIndex.DeleteBatch([]*types.Document{doc})
+	}
+	return 1
 }
 
 // 向索引中添加(亦是更新)文档(如果已存在，会先删除)
@@ -84,33 +198,142 @@ func (indexer *Indexer) AddDoc(doc types.Document) (int, error) {
 	if len(docId) == 0 {
 		return 0, errors.New("doc id is empty")
 	}
-	//先从正排和倒排索引上将docId删除
-	indexer.DeleteDoc(docId)
-	//写入索引时自动为文档生成IntId
-	doc.IntId = atomic.AddUint64(&indexer.maxIntId, 1)
+	if isMetaKey([]byte(docId)) {
+		return 0, errors.New("doc id must not start with \\x00")
+	}
+	defer indexer.docLocks.lock(docId)()
+	forwardKey := []byte(docId)
+	oldValue := indexer.getRaw(forwardKey)
+	//写入索引时自动为文档生成IntId，重启后也不会跟已有的重复
+	intId, err := indexer.nextIntId()
+	if err != nil {
+		return 0, err
+	}
+	doc.IntId = intId
+	value, err := indexer.codec.Encode(&doc)
+	if err != nil {
+		return 0, err
+	}
 
-	//写入正排索引
-	var value bytes.Buffer
-	encoder := gob.NewEncoder(&value)
-	if err := encoder.Encode(doc); err != nil {
+	//删除旧文档和写入新文档作为一个整体写进日志
+	records := make([][]byte, 0, 2)
+	if len(oldValue) > 0 {
+		records = append(records, deleteRecord(docId, oldValue))
+	}
+	records = append(records, addRecord(value))
+	done, err := indexer.logWrite(records...)
+	if err != nil {
 		return 0, err
 	}
-	indexer.forwardIndex.Set([]byte(docId), value.Bytes())
+	defer done()
 
-	//写入倒排索引
+	//写入正排索引。失败时两边都保持原样，撤销掉日志里的记录
+	if err := indexer.forwardIndex.Set(forwardKey, value); err != nil {
+		indexer.abortWrite(records...)
+		return 0, err
+	}
+	if len(oldValue) == 0 {
+		atomic.AddInt64(&indexer.docCount, 1)
+	}
+
+	//从倒排索引上删除旧文档，再写入新文档
+	if len(oldValue) > 0 {
+		if old, err := indexer.codec.Decode(oldValue); err == nil {
+			indexer.reverseIndex.DeleteBatch([]*types.Document{old})
+		}
+	}
 	indexer.reverseIndex.Add(doc)
 	return 1, nil
 }
 
-// 检索，返回文档列表
+// 检索，返回文档列表。文档按BM25得分从高到低排序，得分写在Document.Score里
 func (indexer *Indexer) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document {
-	docIds := indexer.reverseIndex.Search(query, onFlag, offFlag, orFlags)
-	if len(docIds) == 0 {
+	hits := indexer.reverseIndex.Search(indexer.synonyms.Rewrite(query), onFlag, offFlag, orFlags)
+	return indexer.fetchDocs(hits)
+}
+
+// SearchTopK 检索，只返回得分最高的k个文档
+func (indexer *Indexer) SearchTopK(query *types.TermQuery, k int, onFlag uint64, offFlag uint64, orFlags []uint64) []*types.Document {
+	hits := indexer.reverseIndex.SearchTopK(indexer.synonyms.Rewrite(query), k, onFlag, offFlag, orFlags)
+	return indexer.fetchDocs(hits)
+}
+
+// SearchPage 按request.SortBy排序后分页，只从正排索引读取这一页的文档。同时返回同义词改写并展开之后的查询，供高亮使用
+func (indexer *Indexer) SearchPage(request *SearchRequest) *SearchResult {
+	sortBy, query := request.SortBy, indexer.synonyms.Rewrite(request.Query)
+	offset, limit := int(request.Offset), int(request.Limit)
+	var hits []reverseindex.SearchHit
+	if len(sortBy.GetField()) == 0 && !sortBy.GetAsc() {
+		if limit > 0 { //按得分从高到低，只需要前offset+limit个
+			hits = indexer.reverseIndex.SearchTopK(query, offset+limit, request.OnFlag, request.OffFlag, request.OrFlags)
+		} else {
+			hits = indexer.reverseIndex.Search(query, request.OnFlag, request.OffFlag, request.OrFlags)
+		}
+	} else {
+		hits = indexer.reverseIndex.Search(query, request.OnFlag, request.OffFlag, request.OrFlags)
+		indexer.sortHits(hits, sortBy)
+	}
+	return &SearchResult{Result: indexer.fetchDocs(page(hits, offset, limit)), Query: indexer.reverseIndex.Expand(query)}
+}
+
+// 按sortBy排序，数值字段从倒排索引上取，不用读正排索引
+func (indexer *Indexer) sortHits(hits []reverseindex.SearchHit, sortBy *SortBy) {
+	type keyedHit struct {
+		hit reverseindex.SearchHit
+		key sortKey
+	}
+	field := sortBy.GetField()
+	keyed := make([]keyedHit, 0, len(hits))
+	for _, hit := range hits {
+		key := sortKey{score: hit.Score, id: hit.Id}
+		if len(field) > 0 {
+			value, ok := indexer.reverseIndex.Numeric(hit.IntId, field)
+			key.value, key.missing = value, !ok
+		}
+		keyed = append(keyed, keyedHit{hit: hit, key: key})
+	}
+	sort.Slice(keyed, func(i, j int) bool { return lessKey(keyed[i].key, keyed[j].key, sortBy) })
+	for i := range keyed {
+		hits[i] = keyed[i].hit
+	}
+}
+
+// CountMatches 命中的文档数，只用到倒排索引
+func (indexer *Indexer) CountMatches(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int {
+	return indexer.reverseIndex.Count(indexer.synonyms.Rewrite(query), onFlag, offFlag, orFlags)
+}
+
+// Facet 统计检索结果在BitsFeature每个bit上、以及request.Field每个word上的文档数，只用到倒排索引
+func (indexer *Indexer) Facet(request *FacetRequest) *FacetResult {
+	facets := indexer.reverseIndex.Facets(indexer.synonyms.Rewrite(request.Query), request.Field, request.OnFlag, request.OffFlag, request.OrFlags)
+	return newFacetResult(facets, int(request.TopN))
+}
+
+// Explain 查询树上每个节点的文档数和耗时。request.DocId不为空时，给出该文档在每个节点上的得分
+func (indexer *Indexer) Explain(request *ExplainRequest) *Explanation {
+	var intId uint64
+	if len(request.DocId) > 0 {
+		if doc, _ := indexer.getDoc(request.DocId); doc != nil {
+			intId = doc.IntId
+		}
+	}
+	explanation := indexer.reverseIndex.Explain(indexer.synonyms.Rewrite(request.Query), request.OnFlag, request.OffFlag, request.OrFlags, intId)
+	return newExplanation(explanation)
+}
+
+// Terms field里以prefix开头的word，按字典序排列，最多limit个。用于自动补全
+func (indexer *Indexer) Terms(field string, prefix string, limit int) []string {
+	return indexer.reverseIndex.Terms(field, prefix, limit)
+}
+
+// 根据倒排索引的检索结果去正排索引上读取文档，保持hits的顺序
+func (indexer *Indexer) fetchDocs(hits []reverseindex.SearchHit) []*types.Document {
+	if len(hits) == 0 {
 		return nil
 	}
-	keys := make([][]byte, 0, len(docIds))
-	for _, docId := range docIds {
-		keys = append(keys, []byte(docId))
+	keys := make([][]byte, 0, len(hits))
+	for _, hit := range hits {
+		keys = append(keys, []byte(hit.Id))
 	}
 	docs, err := indexer.forwardIndex.BatchGet(keys)
 	if err != nil {
@@ -118,26 +341,18 @@ func (indexer *Indexer) Search(query *types.TermQuery, onFlag uint64, offFlag ui
 		return nil
 	}
 	result := make([]*types.Document, 0, len(docs))
-	reader := bytes.NewReader([]byte{})
-	for _, docBytes := range docs {
-		if len(docs) > 0 {
-			reader.Reset(docBytes)
-			var doc types.Document
-			decoder := gob.NewDecoder(reader)
-			err := decoder.Decode(&doc)
-			if err == nil {
-				result = append(result, &doc)
+	for i, docBytes := range docs {
+		if len(docBytes) > 0 {
+			if doc, err := indexer.codec.Decode(docBytes); err == nil {
+				doc.Score = hits[i].Score
+				result = append(result, doc)
 			}
 		}
 	}
 	return result
 }
 
+// Count 索引里的文档数
 func (indexer *Indexer) Count() int {
-	n := 0
-	indexer.forwardIndex.IterKey(func(k []byte) error {
-		n++
-		return nil
-	})
-	return n
+	return int(atomic.LoadInt64(&indexer.docCount))
 }
@@ -0,0 +1,80 @@
+package index_service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync/atomic"
+)
+
+const (
+	metaKeyPrefix  = "\x00"                       //正排索引里以\x00开头的key留给索引自己用，不能作为docId
+	maxIntIdKey    = metaKeyPrefix + "max_int_id" //已经预留出去的最大IntId
+	intIdBlockSize = 1024                         //每次预留这么多个IntId，写一次正排索引
+)
+
+// 是否为索引自己用的key，遍历正排索引时要跳过
+func isMetaKey(k []byte) bool {
+	return bytes.HasPrefix(k, []byte(metaKeyPrefix))
+}
+
+// 启动时恢复IntId的分配位置。预留出去的IntId可能在进程退出前已经分配给了文档，所以从预留的上限之后继续分配。
+// 老的正排索引里没有记录预留的上限，就解码全部文档取最大的IntId
+func (indexer *Indexer) recoverIntId() error {
+	value, err := indexer.forwardIndex.Get([]byte(maxIntIdKey))
+	if err == nil && len(value) == 8 {
+		indexer.reservedIntId = binary.BigEndian.Uint64(value)
+		indexer.maxIntId = indexer.reservedIntId
+		return nil
+	}
+	var maxIntId uint64
+	indexer.forwardIndex.IterDB(func(k, v []byte) error {
+		if isMetaKey(k) {
+			return nil
+		}
+		if doc, err := indexer.codec.Decode(v); err == nil {
+			maxIntId = max(maxIntId, doc.IntId)
+		}
+		return nil
+	})
+	indexer.maxIntId = maxIntId
+	return indexer.reserveIntId(maxIntId)
+}
+
+// 分配一个新的IntId。超出已预留的范围时，先把新的上限写入正排索引再返回
+func (indexer *Indexer) nextIntId() (uint64, error) {
+	intId := atomic.AddUint64(&indexer.maxIntId, 1)
+	if intId <= atomic.LoadUint64(&indexer.reservedIntId) {
+		return intId, nil
+	}
+	indexer.intIdLock.Lock()
+	defer indexer.intIdLock.Unlock()
+	if intId <= indexer.reservedIntId {
+		return intId, nil
+	}
+	if err := indexer.reserveIntId(intId); err != nil {
+		return 0, err
+	}
+	return intId, nil
+}
+
+// 把预留的上限推进到至少intId之后一个块，调用方需要持有intIdLock(启动时除外)
+func (indexer *Indexer) reserveIntId(intId uint64) error {
+	reserved := intId + intIdBlockSize
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, reserved)
+	if err := indexer.forwardIndex.Set([]byte(maxIntIdKey), value); err != nil {
+		return err
+	}
+	atomic.StoreUint64(&indexer.reservedIntId, reserved)
+	return nil
+}
+
+// 加载已有文档时，后面分配的IntId不能跟它们重复
+func (indexer *Indexer) observeIntId(intId uint64) {
+	for {
+		current := atomic.LoadUint64(&indexer.maxIntId)
+		if intId <= current || atomic.CompareAndSwapUint64(&indexer.maxIntId, current, intId) {
+			return
+		}
+	}
+}
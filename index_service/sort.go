@@ -0,0 +1,113 @@
+package index_service
+
+import (
+	"container/heap"
+	"github.com/Muoshu/myRadic/types"
+)
+
+// sortKey 文档排序时比较的内容
+type sortKey struct {
+	score   float64 //按得分排序时用
+	value   int64   //按数值字段排序时用
+	missing bool    //文档没有要排序的数值字段
+	id      string  //前面都相同时按业务Id排，保证各分片合并后的顺序是确定的
+}
+
+// 按sortBy判断a是否应该排在b前面。sortBy为nil时按得分从高到低
+func lessKey(a, b sortKey, sortBy *SortBy) bool {
+	if a.missing != b.missing {
+		return b.missing
+	}
+	if len(sortBy.GetField()) == 0 {
+		if a.score != b.score {
+			return (a.score < b.score) == sortBy.GetAsc()
+		}
+	} else if a.value != b.value {
+		return (a.value < b.value) == sortBy.GetAsc()
+	}
+	return a.id < b.id
+}
+
+func docKey(doc *types.Document, sortBy *SortBy) sortKey {
+	key := sortKey{score: doc.Score, id: doc.Id}
+	if field := sortBy.GetField(); len(field) > 0 {
+		value, ok := doc.Numerics[field]
+		key.value, key.missing = value, !ok
+	}
+	return key
+}
+
+// docCursor 一个分片的结果列表上的游标
+type docCursor struct {
+	docs []*types.Document
+	pos  int
+}
+
+// cursorHeap 小顶堆，堆顶是各分片当前文档里应该排在最前面的那个
+type cursorHeap struct {
+	cursors []*docCursor
+	sortBy  *SortBy
+}
+
+func (h cursorHeap) Len() int { return len(h.cursors) }
+func (h cursorHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	return lessKey(docKey(a.docs[a.pos], h.sortBy), docKey(b.docs[b.pos], h.sortBy), h.sortBy)
+}
+func (h cursorHeap) Swap(i, j int) { h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i] }
+func (h *cursorHeap) Push(x any)   { h.cursors = append(h.cursors, x.(*docCursor)) }
+func (h *cursorHeap) Pop() any {
+	old := h.cursors
+	n := len(old)
+	x := old[n-1]
+	h.cursors = old[0 : n-1]
+	return x
+}
+
+// MergeDocs 多路归并。lists里的每个列表都已经按sortBy排好序，返回全局排序后从第offset个开始的limit个文档，limit为0时不限
+func MergeDocs(lists [][]*types.Document, sortBy *SortBy, offset int, limit int) []*types.Document {
+	h := &cursorHeap{cursors: make([]*docCursor, 0, len(lists)), sortBy: sortBy}
+	total := 0
+	for _, docs := range lists {
+		if len(docs) > 0 {
+			h.cursors = append(h.cursors, &docCursor{docs: docs})
+			total += len(docs)
+		}
+	}
+	heap.Init(h)
+	n := total - offset
+	if limit > 0 {
+		n = min(n, limit)
+	}
+	if n <= 0 {
+		return nil
+	}
+	result := make([]*types.Document, 0, n)
+	for skipped := 0; h.Len() > 0 && len(result) < n; {
+		cursor := h.cursors[0]
+		if skipped < offset {
+			skipped++
+		} else {
+			result = append(result, cursor.docs[cursor.pos])
+		}
+		cursor.pos++
+		if cursor.pos < len(cursor.docs) {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return result
+}
+
+// 分页。offset超出范围时返回空
+func page[T any](list []T, offset int, limit int) []T {
+	if offset >= len(list) {
+		return nil
+	}
+	list = list[offset:]
+	if limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	return list
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestAddDocs(t *testing.T) {
+	for _, reverseIndexType := range []int{reverseindex.SKIPLIST, reverseindex.ROARING, reverseindex.SEGMENT} {
+		batched, single := newIndexer(t, reverseIndexType), newIndexer(t, reverseIndexType)
+		batched.AddDoc(newDoc("a", 100, "go", "c"))
+		single.AddDoc(newDoc("a", 100, "go", "c"))
+		docs := []types.Document{
+			newDoc("a", 300, "java"), //更新已有的文档，原来的posting要删掉
+			newDoc("b", 200, "go", "go", "java"),
+			newDoc(" ", 0, "go"), //docId为空
+			newDoc("c", 100, "rust"),
+			newDoc("c", 400, "go"), //同一批里重复，以最后一个为准
+		}
+		n, errs := batched.AddDocs(docs)
+		if n != 3 {
+			t.Fatalf("倒排索引类型%d 应写入3个文档，实际%d", reverseIndexType, n)
+		}
+		for i, err := range errs {
+			if (err != nil) != (i == 2) {
+				t.Fatalf("倒排索引类型%d 第%d个文档的错误不对：%v", reverseIndexType, i, err)
+			}
+		}
+		for _, i := range []int{0, 1, 4} {
+			single.AddDoc(docs[i])
+		}
+		if batched.Count() != 3 || single.Count() != 3 {
+			t.Fatalf("倒排索引类型%d 应有3个文档，实际批量%d逐个%d", reverseIndexType, batched.Count(), single.Count())
+		}
+		for _, word := range []string{"go", "java", "c", "rust"} {
+			query := types.NewTermQuery("content", word)
+			expect, actual := docIds(single.Search(query, 0, 0, nil)), docIds(batched.Search(query, 0, 0, nil))
+			sort.Strings(expect)
+			sort.Strings(actual)
+			if !reflect.DeepEqual(expect, actual) {
+				t.Fatalf("倒排索引类型%d %s应命中%v，实际%v", reverseIndexType, word, expect, actual)
+			}
+		}
+	}
+}
+
+func TestDeleteDocs(t *testing.T) {
+	for _, reverseIndexType := range []int{reverseindex.SKIPLIST, reverseindex.ROARING, reverseindex.SEGMENT} {
+		indexer := newIndexer(t, reverseIndexType)
+		indexer.AddDocs([]types.Document{newDoc("a", -1, "go"), newDoc("b", -1, "go", "java"), newDoc("c", -1, "java")})
+		if n := indexer.DeleteDocs([]string{"a", "b", "a", "x"}); n != 2 {
+			t.Fatalf("倒排索引类型%d 应删除2个文档，实际%d", reverseIndexType, n)
+		}
+		if n := indexer.Count(); n != 1 {
+			t.Fatalf("倒排索引类型%d 删除后应剩1个文档，实际%d", reverseIndexType, n)
+		}
+		if ids := docIds(indexer.Search(types.NewTermQuery("content", "go").Or(types.NewTermQuery("content", "java")), 0, 0, nil)); !reflect.DeepEqual(ids, []string{"c"}) {
+			t.Fatalf("倒排索引类型%d 删除后应只命中c，实际%v", reverseIndexType, ids)
+		}
+		if terms := indexer.Terms("content", "", 10); !reflect.DeepEqual(terms, []string{"java"}) {
+			t.Fatalf("倒排索引类型%d 删除后词典里应只剩java，实际%v", reverseIndexType, terms)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"reflect"
+	"testing"
+)
+
+func TestProtoCodec(t *testing.T) {
+	doc := newDoc("a", 100, "go", "java")
+	doc.IntId, doc.BitsFeature, doc.Bytes = 7, 0b101, []byte("视频")
+	codec := index_service.ProtoCodec{}
+	data, err := codec.Encode(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[0] != index_service.FORMAT_PROTO {
+		t.Fatalf("第1个字节应是格式版本%d，实际%d", index_service.FORMAT_PROTO, data[0])
+	}
+	//新格式和老的gob格式都能读
+	gobData, err := index_service.GobCodec{}.Encode(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{data, gobData} {
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Id != doc.Id || decoded.IntId != doc.IntId || decoded.BitsFeature != doc.BitsFeature || string(decoded.Bytes) != string(doc.Bytes) ||
+			len(decoded.Keywords) != 2 || decoded.Keywords[1].Word != "java" || !reflect.DeepEqual(decoded.Numerics, doc.Numerics) {
+			t.Fatalf("解码结果跟原文档不一致：%v", decoded)
+		}
+	}
+}
+
+// 老的gob记录在打开索引时被重写成新格式
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir() + "/db"
+	indexer := new(index_service.Indexer).WithCodec(index_service.GobCodec{})
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	indexer.AddDoc(newDoc("a", 100, "go"))
+	indexer.AddDoc(newDoc("b", 200, "go", "java"))
+	indexer.Close()
+
+	indexer = new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	indexer.LoadFromIndexFile()
+	if ids := docIds(indexer.SearchPage(&index_service.SearchRequest{Query: types.NewTermQuery("content", "go"), SortBy: &index_service.SortBy{Field: "view"}}).Result); !reflect.DeepEqual(ids, []string{"b", "a"}) {
+		t.Fatalf("迁移后应命中b、a，实际%v", ids)
+	}
+	if n, err := indexer.Migrate(); err != nil || n != 0 {
+		t.Fatalf("已经迁移过，不应再重写，实际重写%d个，错误%v", n, err)
+	}
+	indexer.Close()
+
+	db, err := kvdb.GetKvDb(kvdb.BOLT, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, id := range []string{"a", "b"} {
+		if data, _ := db.Get([]byte(id)); len(data) == 0 || data[0] != index_service.FORMAT_PROTO {
+			t.Fatalf("文档%s没有被重写成新格式", id)
+		}
+	}
+}
+
+// 检索时从正排索引批量读出文档并解码
+func BenchmarkSearchFetch(b *testing.B) {
+	for _, c := range []struct {
+		name  string
+		codec index_service.DocumentCodec
+	}{
+		{"gob", index_service.GobCodec{}},
+		{"proto", index_service.ProtoCodec{}},
+	} {
+		b.Run(c.name, func(b *testing.B) {
+			indexer := new(index_service.Indexer).WithCodec(c.codec)
+			if err := indexer.Init(1000, kvdb.BOLT, reverseindex.SKIPLIST, b.TempDir()+"/db"); err != nil {
+				b.Fatal(err)
+			}
+			defer indexer.Close()
+			payload := make([]byte, 512)
+			for i := 0; i < 1000; i++ {
+				doc := newDoc(fmt.Sprintf("doc%d", i), int64(i), "go", fmt.Sprintf("w%d", i%10), fmt.Sprintf("v%d", i%100))
+				doc.Bytes = payload
+				indexer.AddDoc(doc)
+			}
+			query := types.NewTermQuery("content", "go")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if docs := indexer.Search(query, 0, 0, nil); len(docs) != 1000 {
+					b.Fatalf("应命中1000个，实际%d", len(docs))
+				}
+			}
+		})
+	}
+}
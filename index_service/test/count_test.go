@@ -0,0 +1,65 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	dir := t.TempDir() + "/db"
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	indexer.AddDoc(newDoc("a", -1, "go"))
+	indexer.AddDoc(newDoc("b", -1, "go", "java"))
+	indexer.AddDoc(newDoc("c", -1, "java"))
+	indexer.AddDoc(newDoc("a", -1, "java")) //更新已有的文档，文档数不变
+	if n := indexer.Count(); n != 3 {
+		t.Fatalf("应有3个文档，实际%d", n)
+	}
+	indexer.DeleteDoc("b")
+	indexer.DeleteDoc("b") //删除不存在的文档，文档数不变
+	if n := indexer.Count(); n != 2 {
+		t.Fatalf("删除后应有2个文档，实际%d", n)
+	}
+	indexer.Close()
+
+	//重启后从正排索引恢复文档数
+	indexer = new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Close()
+	if n := indexer.Count(); n != 2 {
+		t.Fatalf("重启后应有2个文档，实际%d", n)
+	}
+}
+
+func TestCountMatches(t *testing.T) {
+	for _, reverseIndexType := range []int{reverseindex.SKIPLIST, reverseindex.ROARING, reverseindex.SEGMENT} {
+		indexer := newIndexer(t, reverseIndexType)
+		for i, words := range [][]string{{"go"}, {"go", "java"}, {"java"}, {"go", "python"}} {
+			doc := newDoc(string(rune('a'+i)), -1, words...)
+			doc.BitsFeature = uint64(1 << (i % 2))
+			indexer.AddDoc(doc)
+		}
+		goQuery := types.NewTermQuery("content", "go")
+		if n := indexer.CountMatches(goQuery, 0, 0, nil); n != 3 {
+			t.Fatalf("倒排索引类型%d go应命中3个，实际%d", reverseIndexType, n)
+		}
+		if n := indexer.CountMatches(goQuery, 0b10, 0, nil); n != 2 {
+			t.Fatalf("倒排索引类型%d go按bits过滤后应命中2个，实际%d", reverseIndexType, n)
+		}
+		if n := indexer.CountMatches(goQuery.Or(types.NewTermQuery("content", "java")), 0, 0b10, nil); n != 2 {
+			t.Fatalf("倒排索引类型%d go或java按bits过滤后应命中2个，实际%d", reverseIndexType, n)
+		}
+		indexer.DeleteDoc("a")
+		if n := indexer.CountMatches(goQuery, 0, 0, nil); n != 2 {
+			t.Fatalf("倒排索引类型%d 删除后go应命中2个，实际%d", reverseIndexType, n)
+		}
+	}
+}
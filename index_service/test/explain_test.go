@@ -0,0 +1,34 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/index_service"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	indexer := newIndexer(t, reverseindex.SKIPLIST)
+	indexer.AddDoc(newDoc("a", -1, "go", "java"))
+	indexer.AddDoc(newDoc("b", -1, "go"))
+	indexer.AddDoc(newDoc("c", -1, "java"))
+	query := types.NewTermQuery("content", "go").And(types.NewTermQuery("content", "java"))
+	explanation := indexer.Explain(&index_service.ExplainRequest{Query: query, DocId: "a"})
+	if explanation.Op != "MUST" || explanation.PostingLength != 4 || explanation.Rows != 1 || len(explanation.Children) != 2 {
+		t.Fatalf("根节点错误 %v", explanation)
+	}
+	hits := indexer.Search(query, 0, 0, nil)
+	if !explanation.Matched || explanation.Score != hits[0].Score {
+		t.Fatalf("文档a的得分%g跟Search的%g不同", explanation.Score, hits[0].Score)
+	}
+	for _, child := range explanation.Children {
+		if child.Op != "KEYWORD" || child.Rows != 2 || !child.Matched || child.Score <= 0 {
+			t.Fatalf("子节点错误 %v", child)
+		}
+	}
+	//文档不存在时不给得分
+	explanation = indexer.Explain(&index_service.ExplainRequest{Query: query, DocId: "x"})
+	if explanation.Matched || explanation.Score != 0 {
+		t.Fatalf("不存在的文档不应有得分 %v", explanation)
+	}
+}
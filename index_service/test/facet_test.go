@@ -0,0 +1,62 @@
+package test
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/index_service"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func facetValues(values []*index_service.FacetValue) string {
+	s := ""
+	for _, value := range values {
+		s += fmt.Sprintf("%s:%d ", value.Value, value.Count)
+	}
+	return s
+}
+
+func TestFacet(t *testing.T) {
+	indexer := newIndexer(t, reverseindex.SKIPLIST)
+	for i, author := range []string{"张三", "李四", "张三", "王五", "张三", "李四"} {
+		doc := newDoc(strconv.Itoa(i), -1, "go")
+		doc.BitsFeature = uint64(1 << (i % 3))
+		doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: author})
+		indexer.AddDoc(doc)
+	}
+	result := indexer.Facet(&index_service.FacetRequest{Query: types.NewTermQuery("content", "go"), Field: "author", TopN: 2})
+	if result.Total != 6 || len(result.BitCounts) != 64 || result.BitCounts[0] != 2 || result.BitCounts[1] != 2 || result.BitCounts[2] != 2 {
+		t.Fatalf("bit统计错误 %d %v", result.Total, result.BitCounts[:3])
+	}
+	if s := facetValues(result.Values); s != "张三:3 李四:2 " {
+		t.Fatalf("只应返回命中最多的2个作者 %s", s)
+	}
+}
+
+// 每个分片返回全部的word，加起来之后再取前N个，跟所有数据放在一起统计的结果相同
+func TestMergeFacets(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	whole := newIndexer(t, reverseindex.SKIPLIST)
+	shards := []*index_service.Indexer{newIndexer(t, reverseindex.SKIPLIST), newIndexer(t, reverseindex.ROARING), newIndexer(t, reverseindex.SEGMENT)}
+	for i := 0; i < 300; i++ {
+		doc := newDoc("doc"+strconv.Itoa(i), -1, "w"+strconv.Itoa(rnd.Intn(3)))
+		doc.BitsFeature = uint64(rnd.Intn(8))
+		doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: "u" + strconv.Itoa(rnd.Intn(20))})
+		whole.AddDoc(doc)
+		shards[rnd.Intn(len(shards))].AddDoc(doc)
+	}
+	for w := 0; w < 3; w++ {
+		request := &index_service.FacetRequest{Query: types.NewTermQuery("content", "w"+strconv.Itoa(w)), Field: "author"}
+		results := make([]*index_service.FacetResult, 0, len(shards))
+		for _, shard := range shards {
+			results = append(results, shard.Facet(request))
+		}
+		expect := whole.Facet(&index_service.FacetRequest{Query: request.Query, Field: "author", TopN: 5})
+		got := index_service.MergeFacets(results, 5)
+		if got.Total != expect.Total || fmt.Sprint(got.BitCounts) != fmt.Sprint(expect.BitCounts) || facetValues(got.Values) != facetValues(expect.Values) {
+			t.Fatalf("合并结果错误\n期望%d %v %s\n实际%d %v %s", expect.Total, expect.BitCounts[:3], facetValues(expect.Values), got.Total, got.BitCounts[:3], facetValues(got.Values))
+		}
+	}
+}
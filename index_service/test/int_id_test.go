@@ -0,0 +1,91 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"sort"
+	"testing"
+)
+
+// 重启之后新文档的IntId不能跟已有文档重复，否则会覆盖倒排索引上已有的文档
+func TestIntIdAfterRestart(t *testing.T) {
+	for _, reverseIndexType := range []int{reverseindex.SKIPLIST, reverseindex.ROARING, reverseindex.SEGMENT} {
+		dir := t.TempDir() + "/db"
+		open := func() *index_service.Indexer {
+			indexer := new(index_service.Indexer)
+			if err := indexer.Init(100, kvdb.BOLT, reverseIndexType, dir); err != nil {
+				t.Fatal(err)
+			}
+			return indexer
+		}
+		indexer := open()
+		for _, id := range []string{"a", "b", "c"} {
+			indexer.AddDoc(newDoc(id, -1, "go"))
+		}
+		indexer.Close()
+
+		//重启两次，每次都加载已有文档后再写入新文档
+		for round, ids := range [][]string{{"d", "e"}, {"f"}} {
+			indexer = open()
+			indexer.LoadFromIndexFile()
+			for _, id := range ids {
+				if _, err := indexer.AddDoc(newDoc(id, -1, "go")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if round == 0 {
+				indexer.Close()
+			}
+		}
+		docs := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil)
+		ids := docIds(docs)
+		sort.Strings(ids)
+		if len(ids) != 6 || ids[0] != "a" || ids[5] != "f" {
+			t.Fatalf("倒排索引类型%d 重启后应命中a到f共6个文档，实际%v", reverseIndexType, ids)
+		}
+		intIds := make(map[uint64]string, len(docs))
+		for _, doc := range docs {
+			if other, exists := intIds[doc.IntId]; exists {
+				t.Fatalf("倒排索引类型%d 文档%s和%s的IntId都是%d", reverseIndexType, doc.Id, other, doc.IntId)
+			}
+			intIds[doc.IntId] = doc.Id
+		}
+		if n := indexer.Count(); n != 6 {
+			t.Fatalf("倒排索引类型%d 应有6个文档，实际%d", reverseIndexType, n)
+		}
+		indexer.Close()
+	}
+}
+
+// 不加载已有文档就直接写入，IntId也不能重复；以\x00开头的docId留给索引自己用
+func TestIntIdWithoutLoad(t *testing.T) {
+	dir := t.TempDir() + "/db"
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	indexer.AddDoc(newDoc("a", -1, "go"))
+	if _, err := indexer.AddDoc(newDoc("\x00max_int_id", -1, "go")); err == nil {
+		t.Fatal("以\\x00开头的docId应该报错")
+	}
+	indexer.Close()
+
+	indexer = new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseindex.SKIPLIST, dir); err != nil {
+		t.Fatal(err)
+	}
+	defer indexer.Close()
+	if n := indexer.Count(); n != 1 {
+		t.Fatalf("应有1个文档，实际%d", n)
+	}
+	indexer.AddDoc(newDoc("b", -1, "java"))
+	if n := indexer.LoadFromIndexFile(); n != 2 {
+		t.Fatalf("应加载2个文档，实际%d", n)
+	}
+	docs := indexer.Search(types.NewTermQuery("content", "go").Or(types.NewTermQuery("content", "java")), 0, 0, nil)
+	if len(docs) != 2 || docs[0].IntId == docs[1].IntId {
+		t.Fatalf("a和b的IntId不能相同，实际%v", docIds(docs))
+	}
+}
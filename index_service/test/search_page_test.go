@@ -0,0 +1,138 @@
+package test
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func newIndexer(t *testing.T, reverseIndexType int) *index_service.Indexer {
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseIndexType, t.TempDir()+"/db"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { indexer.Close() })
+	return indexer
+}
+
+func newDoc(id string, view int64, words ...string) types.Document {
+	doc := types.Document{Id: id}
+	for _, word := range words {
+		doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "content", Word: word})
+	}
+	if view >= 0 {
+		doc.Numerics = map[string]int64{"view": view}
+	}
+	return doc
+}
+
+func docIds(docs []*types.Document) []string {
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.Id)
+	}
+	return ids
+}
+
+func TestSearchPage(t *testing.T) {
+	indexer := newIndexer(t, reverseindex.SKIPLIST)
+	indexer.AddDoc(newDoc("a", 300, "go", "go", "go"))
+	indexer.AddDoc(newDoc("b", 100, "go", "go", "java"))
+	indexer.AddDoc(newDoc("c", 200, "go", "java", "rust", "c"))
+	indexer.AddDoc(newDoc("d", -1, "go")) //没有播放量
+	indexer.AddDoc(newDoc("e", 500, "java"))
+
+	query := types.NewTermQuery("content", "go")
+	all := docIds(indexer.Search(query, 0, 0, nil))
+	cases := []struct {
+		offset, limit uint32
+		sortBy        *index_service.SortBy
+		expect        string
+	}{
+		{0, 0, nil, fmt.Sprint(all)},
+		{1, 2, nil, fmt.Sprint(all[1:3])},
+		{3, 5, nil, fmt.Sprint(all[3:])},
+		{4, 5, nil, "[]"},
+		{0, 0, &index_service.SortBy{Field: "view"}, "[a c b d]"},
+		{0, 0, &index_service.SortBy{Field: "view", Asc: true}, "[b c a d]"}, //没有该字段的总是排在最后
+		{1, 2, &index_service.SortBy{Field: "view"}, "[c b]"},
+		{0, 2, &index_service.SortBy{Asc: true}, fmt.Sprint([]string{all[3], all[2]})},
+	}
+	for _, c := range cases {
+		docs := indexer.SearchPage(&index_service.SearchRequest{Query: query, Offset: c.offset, Limit: c.limit, SortBy: c.sortBy}).Result
+		if ids := fmt.Sprint(docIds(docs)); ids != c.expect {
+			t.Fatalf("offset=%d limit=%d sortBy=%v 应返回%s，实际返回%s", c.offset, c.limit, c.sortBy, c.expect, ids)
+		}
+	}
+}
+
+// 数据分散在多个分片上，每个分片取前offset+limit个再归并，跟所有数据放在一起时的那一页完全相同
+func TestMergeDocs(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	whole := newIndexer(t, reverseindex.SKIPLIST)
+	shards := []*index_service.Indexer{newIndexer(t, reverseindex.SKIPLIST), newIndexer(t, reverseindex.ROARING), newIndexer(t, reverseindex.SEGMENT)}
+	for i := 0; i < 300; i++ {
+		doc := newDoc("doc"+strconv.Itoa(i), int64(rnd.Intn(50))-1, "w"+strconv.Itoa(rnd.Intn(3)))
+		whole.AddDoc(doc)
+		shards[rnd.Intn(len(shards))].AddDoc(doc)
+	}
+	for round := 0; round < 50; round++ {
+		query := types.NewTermQuery("content", "w"+strconv.Itoa(rnd.Intn(3)))
+		sortBy := &index_service.SortBy{Field: "view", Asc: rnd.Intn(2) == 0}
+		offset, limit := uint32(rnd.Intn(120)), uint32(rnd.Intn(20))
+		expect := whole.SearchPage(&index_service.SearchRequest{Query: query, Offset: offset, Limit: limit, SortBy: sortBy}).Result
+		lists := make([][]*types.Document, 0, len(shards))
+		for _, shard := range shards {
+			shardLimit := uint32(0)
+			if limit > 0 {
+				shardLimit = offset + limit
+			}
+			lists = append(lists, shard.SearchPage(&index_service.SearchRequest{Query: query, Limit: shardLimit, SortBy: sortBy}).Result)
+		}
+		got := index_service.MergeDocs(lists, sortBy, int(offset), int(limit))
+		if fmt.Sprint(docIds(got)) != fmt.Sprint(docIds(expect)) {
+			t.Fatalf("offset=%d limit=%d sortBy=%v 归并结果错误\n期望%v\n实际%v", offset, limit, sortBy, docIds(expect), docIds(got))
+		}
+	}
+}
+
+// 按得分排序时大量文档得分相同。每个分片只取前offset+limit个再归并，跟所有分片的全部结果按得分降序、业务Id升序排好后的那一页相同
+func TestMergeDocsScoreTies(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	shards := []*index_service.Indexer{newIndexer(t, reverseindex.SKIPLIST), newIndexer(t, reverseindex.ROARING), newIndexer(t, reverseindex.SEGMENT)}
+	for _, i := range rnd.Perm(300) { //入库顺序跟业务Id的顺序无关，IntId的顺序也就跟业务Id无关
+		words := []string{"w" + strconv.Itoa(rnd.Intn(2))}
+		if rnd.Intn(4) == 0 {
+			words = append(words, words[0])
+		}
+		shards[rnd.Intn(len(shards))].AddDoc(newDoc("doc"+strconv.Itoa(i), -1, words...))
+	}
+	for round := 0; round < 50; round++ {
+		query := types.NewTermQuery("content", "w"+strconv.Itoa(rnd.Intn(2)))
+		offset, limit := uint32(rnd.Intn(60)), uint32(1+rnd.Intn(20))
+		all := make([]*types.Document, 0, 300)
+		lists := make([][]*types.Document, 0, len(shards))
+		for _, shard := range shards {
+			all = append(all, shard.Search(query, 0, 0, nil)...)
+			lists = append(lists, shard.SearchPage(&index_service.SearchRequest{Query: query, Limit: offset + limit}).Result)
+		}
+		sort.Slice(all, func(i, j int) bool {
+			if all[i].Score != all[j].Score {
+				return all[i].Score > all[j].Score
+			}
+			return all[i].Id < all[j].Id
+		})
+		expect := all[min(int(offset), len(all)):]
+		expect = expect[:min(int(limit), len(expect))]
+		got := index_service.MergeDocs(lists, nil, int(offset), int(limit))
+		if fmt.Sprint(docIds(got)) != fmt.Sprint(docIds(expect)) {
+			t.Fatalf("offset=%d limit=%d 归并结果错误\n期望%v\n实际%v", offset, limit, docIds(expect), docIds(got))
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/analysis"
+	"github.com/Muoshu/myRadic/index_service"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestIndexerSynonyms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "synonyms.txt")
+	if err := os.WriteFile(path, []byte("golang,go语言,go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	synonyms, err := analysis.LoadSynonyms(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer synonyms.Close()
+	indexer := newIndexer(t, reverseindex.SKIPLIST).WithSynonyms(synonyms)
+	indexer.AddDoc(newDoc("a", -1, "golang"))
+	indexer.AddDoc(newDoc("b", -1, "go语言", "java"))
+	indexer.AddDoc(newDoc("c", -1, "go"))
+	indexer.AddDoc(newDoc("d", -1, "java"))
+
+	query := types.NewTermQuery("content", "go")
+	ids := docIds(indexer.Search(query, 0, 0, nil))
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Fatalf("go应通过同义词命中a、b、c，实际%v", ids)
+	}
+	if n := indexer.CountMatches(query, 0, 0, nil); n != 3 {
+		t.Fatalf("go应命中3个，实际%d", n)
+	}
+	ids = docIds(indexer.SearchPage(&index_service.SearchRequest{Query: types.NewTermQuery("content", "java").Not(types.NewTermQuery("content", "golang"))}).Result)
+	if !reflect.DeepEqual(ids, []string{"d"}) {
+		t.Fatalf("java且不含golang及其同义词应只命中d，实际%v", ids)
+	}
+
+	//返回实际执行的查询：同义词改写之后，前缀和模糊查询也已经展开成字典里的词
+	indexer.AddDoc(newDoc("e", -1, "gopher"))
+	result := indexer.SearchPage(&index_service.SearchRequest{Query: query.Or(types.NewPrefixQuery("content", "goph"), types.NewFuzzyQuery("content", "gopker", 1))})
+	terms := analysis.QueryTerms(result.Query, "content")
+	for _, word := range []string{"golang", "go语言", "go", "gopher"} {
+		if !terms.Match(word) {
+			t.Fatalf("实际执行的查询%s里应包含%s", result.Query.ToString(), word)
+		}
+	}
+	if terms.Match("java") || terms.Match("gop") {
+		t.Fatalf("实际执行的查询%s里不应包含java和gop", result.Query.ToString())
+	}
+}
@@ -0,0 +1,128 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/index_service"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"reflect"
+	"testing"
+)
+
+func keywords(words ...string) []*types.Keyword {
+	list := make([]*types.Keyword, 0, len(words))
+	for _, word := range words {
+		list = append(list, &types.Keyword{Field: "content", Word: word})
+	}
+	return list
+}
+
+// 读出文档的IntId，文档不存在时返回0
+func intIdOf(indexer *index_service.Indexer, docId, word string) uint64 {
+	for _, doc := range indexer.Search(types.NewTermQuery("content", word), 0, 0, nil) {
+		if doc.Id == docId {
+			return doc.IntId
+		}
+	}
+	return 0
+}
+
+func TestUpdateDoc(t *testing.T) {
+	for name, reverseIndexType := range map[string]int{"skiplist": reverseindex.SKIPLIST, "roaring": reverseindex.ROARING, "segment": reverseindex.SEGMENT} {
+		t.Run(name, func(t *testing.T) {
+			indexer := newIndexer(t, reverseIndexType)
+			a := newDoc("a", 10, "go", "java", "java")
+			a.BitsFeature, a.Bytes = 1, []byte("video a")
+			indexer.AddDoc(a)
+			indexer.AddDoc(newDoc("b", 50, "go"))
+			intId := intIdOf(indexer, "a", "go")
+
+			n, err := indexer.UpdateDoc(&index_service.DocUpdate{
+				Id:             "a",
+				Mask:           uint32(index_service.UpdateMask_UPDATE_BITS_FEATURE | index_service.UpdateMask_UPDATE_NUMERICS),
+				BitsFeature:    2,
+				Numerics:       map[string]int64{"view": 100},
+				RemoveKeywords: keywords("java"),
+				AddKeywords:    keywords("rust"),
+			})
+			if n != 1 || err != nil {
+				t.Fatalf("update a got %d, %v", n, err)
+			}
+			if got := intIdOf(indexer, "a", "rust"); got != intId {
+				t.Errorf("IntId changed from %d to %d", intId, got)
+			}
+			for word, expect := range map[string][]string{"go": {"a", "b"}, "java": {}, "rust": {"a"}} {
+				if got := searchIds(indexer, word); !reflect.DeepEqual(got, expect) {
+					t.Errorf("search %s got %v, expect %v", word, got, expect)
+				}
+			}
+			if got := docIds(indexer.Search(types.NewTermQuery("content", "go"), 2, 0, nil)); !reflect.DeepEqual(got, []string{"a"}) {
+				t.Errorf("search with new bits got %v", got)
+			}
+			page := indexer.SearchPage(&index_service.SearchRequest{Query: types.NewTermQuery("content", "go"), SortBy: &index_service.SortBy{Field: "view"}}).Result
+			if got := docIds(page); !reflect.DeepEqual(got, []string{"a", "b"}) {
+				t.Errorf("sort by new view got %v", got)
+			}
+			if string(page[0].Bytes) != "video a" || len(page[0].Keywords) != 2 {
+				t.Errorf("fields out of mask changed: bytes %q, keywords %v", page[0].Bytes, page[0].Keywords)
+			}
+
+			//只改Bytes，其他字段不变
+			indexer.UpdateDoc(&index_service.DocUpdate{Id: "a", Mask: uint32(index_service.UpdateMask_UPDATE_BYTES), Bytes: []byte("new")})
+			docs := indexer.Search(types.NewTermQuery("content", "rust"), 0, 0, nil)
+			if len(docs) != 1 || string(docs[0].Bytes) != "new" || docs[0].BitsFeature != 2 || docs[0].Numerics["view"] != 100 {
+				t.Errorf("update bytes got %v", docs)
+			}
+			if n, err := indexer.UpdateDoc(&index_service.DocUpdate{Id: "x", AddKeywords: keywords("go")}); n != 0 || err != nil {
+				t.Errorf("update missing doc got %d, %v", n, err)
+			}
+			if indexer.Count() != 2 {
+				t.Errorf("count %d after update", indexer.Count())
+			}
+			if report := indexer.CheckConsistency(); !report.Consistent() {
+				t.Errorf("inconsistent after update: %v", report.Samples)
+			}
+		})
+	}
+}
+
+// 倒排索引停留在修改之前，重放日志里的原地修改后要跟正排索引一致
+func TestUpdateDocWalReplay(t *testing.T) {
+	for name, reverseIndexType := range map[string]int{"skiplist": reverseindex.SKIPLIST, "roaring": reverseindex.ROARING, "segment": reverseindex.SEGMENT} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			indexer := openIndexer(t, reverseIndexType, dir+"/db")
+			indexer.AddDoc(newDoc("a", -1, "go", "java"))
+			indexer.AddDoc(newDoc("b", -1, "go"))
+			indexer.Close()
+			indexer = openIndexer(t, reverseIndexType, dir+"/db")
+			defer indexer.Close()
+			intId := intIdOf(indexer, "a", "go")
+
+			crash := t.TempDir()
+			snapshot(t, dir, crash, "db", "db_reverse")
+			indexer.UpdateDoc(&index_service.DocUpdate{Id: "a", RemoveKeywords: keywords("java"), AddKeywords: keywords("rust")})
+			indexer.UpdateDoc(&index_service.DocUpdate{Id: "a", AddKeywords: keywords("c")})
+			indexer.UpdateDoc(&index_service.DocUpdate{Id: "b", RemoveKeywords: keywords("go")}) //不剩keyword了
+			indexer.AddDoc(newDoc("c", -1, "java"))
+			indexer.UpdateDoc(&index_service.DocUpdate{Id: "c", AddKeywords: keywords("go")})
+			snapshot(t, dir, crash, "db_wal")
+
+			recovered := openIndexer(t, reverseIndexType, crash+"/db")
+			defer recovered.Close()
+			if recovered.Count() != 3 {
+				t.Errorf("recovered %d docs, expect 3", recovered.Count())
+			}
+			for word, expect := range map[string][]string{"go": {"a", "c"}, "java": {"c"}, "rust": {"a"}, "c": {"a"}} {
+				if got := searchIds(recovered, word); !reflect.DeepEqual(got, expect) {
+					t.Errorf("search %s got %v, expect %v", word, got, expect)
+				}
+			}
+			if got := intIdOf(recovered, "a", "go"); got != intId {
+				t.Errorf("IntId changed from %d to %d after replay", intId, got)
+			}
+			if report := recovered.CheckConsistency(); !report.Consistent() {
+				t.Errorf("inconsistent after replay: %v", report.Samples)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package test
+
+import (
+	"bytes"
+	"github.com/Muoshu/myRadic/index_service"
+	"github.com/Muoshu/myRadic/internal/kvdb"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// 把src下的文件或目录拷贝到dst下，不存在的跳过。用来给正在运行的索引拍快照，模拟进程崩溃时磁盘上的状态
+func snapshot(t *testing.T, src, dst string, names ...string) {
+	for _, name := range names {
+		filepath.Walk(filepath.Join(src, name), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, _ := filepath.Rel(src, path)
+			target := filepath.Join(dst, rel)
+			if info.IsDir() {
+				return os.MkdirAll(target, os.ModePerm)
+			}
+			in, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			out, err := os.Create(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer out.Close()
+			if _, err := io.Copy(out, in); err != nil {
+				t.Fatal(err)
+			}
+			return nil
+		})
+	}
+}
+
+func openIndexer(t *testing.T, reverseIndexType int, dataDir string) *index_service.Indexer {
+	indexer := new(index_service.Indexer)
+	if err := indexer.Init(100, kvdb.BOLT, reverseIndexType, dataDir); err != nil {
+		t.Fatal(err)
+	}
+	indexer.LoadFromIndexFile()
+	return indexer
+}
+
+func searchIds(indexer *index_service.Indexer, word string) []string {
+	ids := docIds(indexer.Search(types.NewTermQuery("content", word), 0, 0, nil))
+	sort.Strings(ids)
+	return ids
+}
+
+// 正排和倒排索引都还停留在修改之前，只有预写日志落了盘，重启后两边都要重放到修改之后
+func TestWalReplay(t *testing.T) {
+	for name, reverseIndexType := range map[string]int{"skiplist": reverseindex.SKIPLIST, "roaring": reverseindex.ROARING, "segment": reverseindex.SEGMENT} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			indexer := openIndexer(t, reverseIndexType, dir+"/db")
+			indexer.AddDoc(newDoc("a", -1, "go", "java"))
+			indexer.AddDoc(newDoc("b", -1, "go"))
+			indexer.Close()
+			indexer = openIndexer(t, reverseIndexType, dir+"/db") //倒排索引正常关闭过，这次从磁盘加载
+			defer indexer.Close()
+
+			before := t.TempDir() //修改之前的正排和倒排索引
+			snapshot(t, dir, before, "db", "db_reverse")
+			indexer.AddDoc(newDoc("a", -1, "rust"))
+			indexer.AddDoc(newDoc("c", -1, "go", "rust"))
+			indexer.DeleteDoc("b")
+			indexer.AddDocs([]types.Document{newDoc("d", -1, "java")})
+			indexer.DeleteDocs([]string{"d"})
+			snapshot(t, dir, before, "db_wal")
+			after := t.TempDir() //修改之后的真实崩溃现场：正排索引已经写入，倒排索引留着dirty文件
+			snapshot(t, dir, after, "db", "db_reverse", "db_wal")
+			segments := make(map[string][]byte) //崩溃现场里已经落盘的段，重启后应该原样保留，再在上面重放日志
+			if reverseIndexType == reverseindex.SEGMENT {
+				if _, err := os.Stat(filepath.Join(after, "db_reverse", "dirty")); err != nil {
+					t.Fatalf("snapshot after writes should have the dirty marker: %s", err)
+				}
+				files, _ := filepath.Glob(filepath.Join(after, "db_reverse", "*.seg"))
+				for _, file := range files {
+					segments[file], _ = os.ReadFile(file)
+				}
+				if len(segments) == 0 {
+					t.Fatal("snapshot after writes has no segment")
+				}
+			}
+
+			for _, crash := range []string{before, after} {
+				recovered := openIndexer(t, reverseIndexType, crash+"/db")
+				if crash == after {
+					for file, data := range segments {
+						if got, err := os.ReadFile(file); err != nil || !bytes.Equal(got, data) {
+							t.Errorf("segment %s was discarded instead of replaying the wal on top of it", filepath.Base(file))
+						}
+					}
+				}
+				if recovered.Count() != 2 {
+					t.Errorf("recovered %d docs, expect 2", recovered.Count())
+				}
+				for word, expect := range map[string][]string{"go": {"c"}, "java": {}, "rust": {"a", "c"}} {
+					if got := searchIds(recovered, word); !reflect.DeepEqual(got, expect) {
+						t.Errorf("search %s got %v, expect %v", word, got, expect)
+					}
+				}
+				if report := recovered.CheckConsistency(); !report.Consistent() {
+					t.Errorf("inconsistent after replay: %v", report.Samples)
+				}
+				//新分配的IntId不能跟重放出来的文档重复
+				recovered.AddDoc(newDoc("e", -1, "rust"))
+				if got := searchIds(recovered, "rust"); !reflect.DeepEqual(got, []string{"a", "c", "e"}) {
+					t.Errorf("search rust got %v", got)
+				}
+				recovered.Close()
+			}
+		})
+	}
+}
+
+// 绕过Indexer直接改正排索引，倒排索引从磁盘加载后跟它对不上
+func TestCheckConsistency(t *testing.T) {
+	dir := t.TempDir()
+	indexer := openIndexer(t, reverseindex.SEGMENT, dir+"/db")
+	indexer.AddDoc(newDoc("a", -1, "go", "java"))
+	indexer.AddDoc(newDoc("b", -1, "go"))
+	indexer.AddDoc(newDoc("c", -1, "rust"))
+	if report := indexer.CheckConsistency(); !report.Consistent() || report.ForwardDocs != 3 || report.ReverseDocs != 3 {
+		t.Fatalf("consistent index reported %+v", report)
+	}
+	docs := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil)
+	indexer.Close()
+
+	db, err := kvdb.GetKvDb(kvdb.BOLT, dir+"/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Delete([]byte("c"))
+	for _, doc := range docs {
+		if doc.Id == "b" {
+			doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "content", Word: "java"})
+			value, _ := index_service.ProtoCodec{}.Encode(doc)
+			db.Set([]byte("b"), value)
+		}
+	}
+	db.Set([]byte("x"), []byte{index_service.FORMAT_PROTO, 0xff})
+	db.Close()
+
+	indexer = openIndexer(t, reverseindex.SEGMENT, dir+"/db")
+	defer indexer.Close()
+	report := indexer.CheckConsistency()
+	if report.Consistent() {
+		t.Fatal("inconsistency not detected")
+	}
+	if report.ForwardDocs != 3 || report.ReverseDocs != 3 {
+		t.Errorf("forward docs %d, reverse docs %d", report.ForwardDocs, report.ReverseDocs)
+	}
+	if report.MissingPostings != 1 || report.ExtraPostings != 1 || !reflect.DeepEqual(report.CorruptDocs, []string{"x"}) {
+		t.Errorf("missing %d, extra %d, corrupt %v, samples %v", report.MissingPostings, report.ExtraPostings, report.CorruptDocs, report.Samples)
+	}
+}
+
+// 日志写进去之后正排索引写入失败(key太长，bolt拒绝)，调用方收到了失败，重放时不能把这次修改再做一遍
+func TestWalAbort(t *testing.T) {
+	for name, reverseIndexType := range map[string]int{"skiplist": reverseindex.SKIPLIST, "segment": reverseindex.SEGMENT} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			indexer := openIndexer(t, reverseIndexType, dir+"/db")
+			indexer.AddDoc(newDoc("a", -1, "go"))
+			indexer.AddDoc(newDoc("b", -1, "java"))
+			indexer.Close()
+			indexer = openIndexer(t, reverseIndexType, dir+"/db")
+			defer indexer.Close()
+
+			huge := strings.Repeat("x", 40000)
+			if _, err := indexer.AddDoc(newDoc(huge, -1, "rust")); err == nil {
+				t.Fatal("add doc with a huge id should fail")
+			}
+			//整批写入失败，b的删除和添加也要一起撤销
+			if n, errs := indexer.AddDocs([]types.Document{newDoc("b", -1, "rust"), newDoc(huge, -1, "rust")}); n != 0 || errs[0] == nil {
+				t.Fatalf("add docs should fail, got %d %v", n, errs)
+			}
+			indexer.AddDoc(newDoc("c", -1, "go"))
+
+			crash := t.TempDir()
+			snapshot(t, dir, crash, "db", "db_reverse", "db_wal")
+			recovered := openIndexer(t, reverseIndexType, crash+"/db")
+			defer recovered.Close()
+			if recovered.Count() != 3 {
+				t.Errorf("recovered %d docs, expect 3", recovered.Count())
+			}
+			for word, expect := range map[string][]string{"go": {"a", "c"}, "java": {"b"}, "rust": {}} {
+				if got := searchIds(recovered, word); !reflect.DeepEqual(got, expect) {
+					t.Errorf("search %s got %v, expect %v", word, got, expect)
+				}
+			}
+			if report := recovered.CheckConsistency(); !report.Consistent() {
+				t.Errorf("inconsistent after replay: %v", report.Samples)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package index_service
+
+import (
+	"errors"
+	"github.com/Muoshu/myRadic/types"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const docLockCount = 256
+
+// docLocks 按docId分段的锁，同一篇文档的写操作串行执行。UpdateDoc要先读出旧文档再写回，不能跟同一篇文档的其他写操作交错
+type docLocks [docLockCount]sync.Mutex
+
+// lock 锁住docIds所在的段，按段号从小到大加锁以免死锁，返回解锁的函数
+func (locks *docLocks) lock(docIds ...string) func() {
+	slots := make([]int, 0, len(docIds))
+	seen := make(map[int]struct{}, len(docIds))
+	for _, docId := range docIds {
+		h := fnv.New32a()
+		h.Write([]byte(docId))
+		slot := int(h.Sum32() % docLockCount)
+		if _, exists := seen[slot]; !exists {
+			seen[slot] = struct{}{}
+			slots = append(slots, slot)
+		}
+	}
+	sort.Ints(slots)
+	for _, slot := range slots {
+		locks[slot].Lock()
+	}
+	return func() {
+		for _, slot := range slots {
+			locks[slot].Unlock()
+		}
+	}
+}
+
+// UpdateDoc 按update.Mask修改已有文档的部分字段，IntId保持不变，倒排索引上只改动受影响的posting。文档不存在时返回0
+func (indexer *Indexer) UpdateDoc(update *DocUpdate) (int, error) {
+	docId := strings.TrimSpace(update.Id)
+	if len(docId) == 0 {
+		return 0, errors.New("doc id is empty")
+	}
+	if isMetaKey([]byte(docId)) {
+		return 0, nil
+	}
+	defer indexer.docLocks.lock(docId)()
+	forwardKey := []byte(docId)
+	oldValue := indexer.getRaw(forwardKey)
+	if len(oldValue) == 0 {
+		return 0, nil
+	}
+	old, err := indexer.codec.Decode(oldValue)
+	if err != nil {
+		return 0, err
+	}
+	doc := applyUpdate(old, update)
+	value, err := indexer.codec.Encode(&doc)
+	if err != nil {
+		return 0, err
+	}
+
+	//日志里记成IntId相同的一次删除加一次添加，重放时据此把倒排索引对齐到新文档
+	records := [][]byte{deleteRecord(docId, oldValue), addRecord(value)}
+	done, err := indexer.logWrite(records...)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	if err := indexer.forwardIndex.Set(forwardKey, value); err != nil {
+		indexer.abortWrite(records...)
+		return 0, err
+	}
+	indexer.reverseIndex.Update(old, doc)
+	return 1, nil
+}
+
+// 在旧文档的基础上应用update，返回新文档。先删掉Keywords里跟RemoveKeywords相同的keyword(出现多次的全部删掉)，再把AddKeywords追加到末尾
+func applyUpdate(old *types.Document, update *DocUpdate) types.Document {
+	doc := types.Document{Id: old.Id, IntId: old.IntId, BitsFeature: old.BitsFeature, Bytes: old.Bytes, Numerics: old.Numerics}
+	mask := update.Mask
+	if mask&uint32(UpdateMask_UPDATE_BITS_FEATURE) != 0 {
+		doc.BitsFeature = update.BitsFeature
+	}
+	if mask&uint32(UpdateMask_UPDATE_BYTES) != 0 {
+		doc.Bytes = update.Bytes
+	}
+	if mask&uint32(UpdateMask_UPDATE_NUMERICS) != 0 {
+		doc.Numerics = update.Numerics
+	}
+	removed := make(map[string]struct{}, len(update.RemoveKeywords))
+	for _, keyword := range update.RemoveKeywords {
+		removed[keyword.ToString()] = struct{}{}
+	}
+	doc.Keywords = make([]*types.Keyword, 0, len(old.Keywords)+len(update.AddKeywords))
+	for _, keyword := range old.Keywords {
+		if _, ok := removed[keyword.ToString()]; !ok {
+			doc.Keywords = append(doc.Keywords, keyword)
+		}
+	}
+	doc.Keywords = append(doc.Keywords, update.AddKeywords...)
+	return doc
+}
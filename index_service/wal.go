@@ -0,0 +1,270 @@
+package index_service
+
+import (
+	"encoding/binary"
+	"errors"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/internal/wal"
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+	"slices"
+)
+
+// 预写日志里的记录类型，写在每条记录的第1个字节
+const (
+	walAdd    byte = 1 //后面是用codec编码的新文档
+	walDelete byte = 2 //后面是docId的长度(uvarint)、docId、用codec编码的旧文档(不知道旧文档时为空)
+	walAbort  byte = 3 //后面是若干个(长度(uvarint)、记录)，这些记录已经写进日志但没能修改正排索引，重放时跳过
+)
+
+const checkpointSize = 64 << 20 //预写日志超过这么大时做一次checkpoint
+
+// 添加文档的日志记录。value是codec编码后的文档
+func addRecord(value []byte) []byte {
+	record := make([]byte, 0, 1+len(value))
+	record = append(record, walAdd)
+	return append(record, value...)
+}
+
+// 删除文档的日志记录。old是codec编码后的旧文档，重放时要用它的Keywords清理倒排索引
+func deleteRecord(docId string, old []byte) []byte {
+	record := make([]byte, 0, 1+binary.MaxVarintLen64+len(docId)+len(old))
+	record = append(record, walDelete)
+	record = binary.AppendUvarint(record, uint64(len(docId)))
+	record = append(record, docId...)
+	return append(record, old...)
+}
+
+// 撤销记录。写完日志之后修改正排索引失败时追加，records是之前写进日志的原始记录
+func abortRecord(records ...[]byte) []byte {
+	size := 1
+	for _, record := range records {
+		size += binary.MaxVarintLen64 + len(record)
+	}
+	abort := make([]byte, 0, size)
+	abort = append(abort, walAbort)
+	for _, record := range records {
+		abort = binary.AppendUvarint(abort, uint64(len(record)))
+		abort = append(abort, record...)
+	}
+	return abort
+}
+
+// 解析撤销记录，返回被撤销的原始记录
+func parseAbort(abort []byte) ([][]byte, error) {
+	records := make([][]byte, 0, 2)
+	for rest := abort[1:]; len(rest) > 0; {
+		length, n := binary.Uvarint(rest)
+		if n <= 0 || uint64(len(rest)-n) < length {
+			return nil, errors.New("invalid wal abort record")
+		}
+		records = append(records, rest[n:n+int(length)])
+		rest = rest[n+int(length):]
+	}
+	return records, nil
+}
+
+// 解析日志记录，返回记录类型、docId和记录里的文档(删除记录不知道旧文档时为nil)
+func (indexer *Indexer) parseRecord(record []byte) (byte, string, *types.Document, error) {
+	if len(record) == 0 {
+		return 0, "", nil, errors.New("empty wal record")
+	}
+	switch record[0] {
+	case walAdd:
+		doc, err := indexer.codec.Decode(record[1:])
+		if err != nil {
+			return 0, "", nil, err
+		}
+		return walAdd, doc.Id, doc, nil
+	case walDelete:
+		length, n := binary.Uvarint(record[1:])
+		if n <= 0 || uint64(len(record)-1-n) < length {
+			return 0, "", nil, errors.New("invalid wal delete record")
+		}
+		docId := string(record[1+n : 1+n+int(length)])
+		old := record[1+n+int(length):]
+		if len(old) == 0 {
+			return walDelete, docId, nil, nil
+		}
+		doc, err := indexer.codec.Decode(old)
+		if err != nil {
+			return 0, "", nil, err
+		}
+		return walDelete, docId, doc, nil
+	default:
+		return 0, "", nil, errors.New("unknown wal record type")
+	}
+}
+
+// 先把records写进预写日志再修改索引。返回的函数在修改完索引后调用，在此之前不会做checkpoint
+func (indexer *Indexer) logWrite(records ...[]byte) (func(), error) {
+	if indexer.wal == nil {
+		return func() {}, nil
+	}
+	indexer.walLock.RLock()
+	if err := indexer.wal.Append(records...); err != nil {
+		indexer.walLock.RUnlock()
+		return nil, err
+	}
+	return func() {
+		indexer.walLock.RUnlock()
+		if indexer.wal.Size() > checkpointSize {
+			if err := indexer.checkpoint(); err != nil {
+				util.Log.Printf("checkpoint failed: %s", err)
+			}
+		}
+	}, nil
+}
+
+// abortWrite logWrite之后修改正排索引失败时调用，此时两边索引都还是原样。追加一条撤销记录，重放时跳过records，
+// 免得把调用方已经收到失败的修改又重做一遍。必须在logWrite返回的函数之前调用，保证撤销记录跟records在同一段日志里
+func (indexer *Indexer) abortWrite(records ...[]byte) {
+	if indexer.wal == nil {
+		return
+	}
+	if err := indexer.wal.Append(abortRecord(records...)); err != nil { //日志已经写不进去了，重放时仍会重做这次修改
+		util.Log.Printf("write wal abort record failed: %s", err)
+	}
+}
+
+// checkpoint 日志里的修改都已经持久化之后清空日志。正排索引写入即持久化，持久化的倒排索引需要先落盘；
+// 纯内存的倒排索引每次启动都从正排索引重建，不依赖日志
+func (indexer *Indexer) checkpoint() error {
+	if indexer.wal == nil {
+		return nil
+	}
+	indexer.walLock.Lock()
+	defer indexer.walLock.Unlock()
+	if indexer.wal.Size() == 0 {
+		return nil
+	}
+	if persistent, ok := indexer.reverseIndex.(reverseindex.IPersistentReverseIndexer); ok {
+		if err := persistent.Flush(); err != nil {
+			return err
+		}
+	}
+	return indexer.wal.Reset()
+}
+
+// 启动时重放预写日志。先找出被撤销的记录，其余的记录里正排索引按日志的顺序重做一遍；从磁盘加载的倒排索引则对齐到重放后的正排索引：
+// 日志里出现过的旧IntId全部删掉，正排索引里的当前文档如果还不在倒排索引上就补上。
+// UpdateDoc修改过的文档IntId不变，倒排索引上可能是日志里的任何一个版本，所以先删掉所有版本的posting再写入当前文档。
+// 没有从磁盘加载到数据的倒排索引之后会从正排索引整个重建，不需要重放
+func (indexer *Indexer) replayWal() error {
+	aborted := make(map[string]int) //被撤销的记录，内容相同的记录可能被撤销多次
+	err := indexer.wal.Replay(func(record []byte) error {
+		if len(record) == 0 || record[0] != walAbort {
+			return nil
+		}
+		records, err := parseAbort(record)
+		if err != nil {
+			util.Log.Printf("parse wal record failed: %s", err)
+			return nil
+		}
+		for _, record := range records {
+			aborted[string(record)]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	seen := make(map[string][]*types.Document) //每个docId在日志里出现过的文档
+	order := make([]string, 0)
+	n := 0
+	err = indexer.wal.Replay(func(record []byte) error {
+		if len(record) > 0 && record[0] == walAbort {
+			return nil
+		}
+		if aborted[string(record)] > 0 { //内容相同的记录效果也相同，跳过哪一条都一样
+			aborted[string(record)]--
+			return nil
+		}
+		kind, docId, doc, err := indexer.parseRecord(record)
+		if err != nil { //校验和没问题但解析失败，说明是程序的bug，跳过这条记录
+			util.Log.Printf("parse wal record failed: %s", err)
+			return nil
+		}
+		n++
+		switch kind {
+		case walAdd:
+			value, err := indexer.codec.Encode(doc)
+			if err != nil {
+				return err
+			}
+			if err := indexer.forwardIndex.Set([]byte(docId), value); err != nil {
+				return err
+			}
+		case walDelete:
+			if err := indexer.forwardIndex.Delete([]byte(docId)); err != nil {
+				return err
+			}
+		}
+		if _, exists := seen[docId]; !exists {
+			order = append(order, docId)
+		}
+		seen[docId] = append(seen[docId], doc)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	util.Log.Printf("replay %d wal records", n)
+
+	persistent, ok := indexer.reverseIndex.(reverseindex.IPersistentReverseIndexer)
+	if !ok || !persistent.Loaded() {
+		return nil
+	}
+	stale := make([]*types.Document, 0, len(order))
+	fresh := make([]types.Document, 0, len(order))
+	updated := make([]*types.Document, 0)
+	for _, docId := range order {
+		current, _ := indexer.getDoc(docId)
+		versions := make(map[uint64]*types.Document, len(seen[docId])) //IntId -> 所有版本的keyword之和
+		counts := make(map[uint64]int, len(seen[docId]))
+		for _, doc := range seen[docId] {
+			if doc == nil {
+				continue
+			}
+			counts[doc.IntId]++
+			if union, ok := versions[doc.IntId]; ok {
+				union.Keywords = append(union.Keywords, doc.Keywords...)
+			} else {
+				versions[doc.IntId] = &types.Document{Id: doc.Id, IntId: doc.IntId, Keywords: slices.Clone(doc.Keywords)}
+			}
+		}
+		for intId, union := range versions {
+			if current == nil || intId != current.IntId {
+				stale = append(stale, union)
+			} else if counts[intId] > 1 { //原地修改过
+				stale = append(stale, union)
+				updated = append(updated, current)
+			}
+		}
+		if current != nil && counts[current.IntId] <= 1 && !persistent.HasDoc(current.IntId) {
+			fresh = append(fresh, *current)
+		}
+	}
+	indexer.reverseIndex.DeleteBatch(stale)
+	for _, doc := range updated {
+		indexer.reverseIndex.Update(&types.Document{Id: doc.Id, IntId: doc.IntId}, *doc)
+	}
+	indexer.reverseIndex.AddBatch(fresh)
+	return nil
+}
+
+// 打开dir下的预写日志并重放，重放完做一次checkpoint
+func (indexer *Indexer) openWal(dir string) error {
+	log, err := wal.Open(dir)
+	if err != nil {
+		return err
+	}
+	indexer.wal = log
+	if err := indexer.replayWal(); err != nil {
+		return err
+	}
+	return indexer.checkpoint()
+}
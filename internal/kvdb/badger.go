@@ -69,14 +69,19 @@ func (b *Badger) BatchSet(keys, values [][]byte) error {
 		value := values[i]
 		//duration := time.Hour * 87600
 		//util.util.Log.Debugf("duration",duration)
-		if err = txn.Set(key, value); err != nil {
-			_ = txn.Commit() //发生异常时就提交老事务，然后开一个新事务，重试set
+		if err = txn.Set(key, value); err == badger.ErrTxnTooBig {
+			if err = txn.Commit(); err != nil { //事务太大时就提交老事务，然后开一个新事务，重试set
+				return err
+			}
 			txn = b.db.NewTransaction(true)
-			_ = txn.Set(key, value)
+			err = txn.Set(key, value)
+		}
+		if err != nil {
+			txn.Discard()
+			return err
 		}
 	}
-	txn.Commit()
-	return err
+	return txn.Commit()
 }
 
 func (b *Badger) Get(k []byte) ([]byte, error) {
@@ -106,10 +111,7 @@ func (b *Badger) BatchGet(keys [][]byte) ([][]byte, error) {
 			//buffer := make([]byte, badgerOptions.ValueLogMaxEntries)
 			var ival []byte
 			//ival, err = item.ValueCopy(buffer)
-			err = item.Value(func(val []byte) error {
-				ival = val
-				return nil
-			})
+			ival, err = item.ValueCopy(nil) //value只在事务内有效，需要拷贝出来
 			if err == nil {
 				values[i] = ival
 			} else { //拷贝失败
@@ -117,7 +119,9 @@ func (b *Badger) BatchGet(keys [][]byte) ([][]byte, error) {
 			}
 		} else { //读取失败
 			values[i] = []byte{}              //读取失败就把value设为空数组
-			if err != badger.ErrKeyNotFound { //如果真的发生异常，则开一个新事务继续读后面的key
+			if err == badger.ErrKeyNotFound { //key不存在不算异常
+				err = nil
+			} else { //如果真的发生异常，则开一个新事务继续读后面的key
 				txn.Discard()
 				txn = b.db.NewTransaction(false)
 			}
@@ -139,14 +143,19 @@ func (b *Badger) BatchDelete(keys [][]byte) error {
 	var err error
 	txn := b.db.NewTransaction(true)
 	for _, key := range keys {
-		if err = txn.Delete(key); err != nil {
-			_ = txn.Commit() //发生异常时就提交老事务，然后开一个新事务，重试delete
+		if err = txn.Delete(key); err == badger.ErrTxnTooBig {
+			if err = txn.Commit(); err != nil { //事务太大时就提交老事务，然后开一个新事务，重试delete
+				return err
+			}
 			txn = b.db.NewTransaction(true)
-			_ = txn.Delete(key)
+			err = txn.Delete(key)
+		}
+		if err != nil {
+			txn.Discard()
+			return err
 		}
 	}
-	txn.Commit()
-	return err
+	return txn.Commit()
 }
 
 func (b *Badger) Has(k []byte) bool {
@@ -65,15 +65,14 @@ func (s *Bolt) BatchSet(keys, values [][]byte) error {
 	if len(keys) != len(values) {
 		return errors.New("key value not the same length")
 	}
-	var err error
-	s.db.Batch(func(tx *bolt.Tx) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
 		for i, key := range keys {
-			value := values[i]
-			tx.Bucket(s.bucket).Put(key, value)
+			if err := tx.Bucket(s.bucket).Put(key, values[i]); err != nil {
+				return err //整个事务回滚
+			}
 		}
 		return nil
 	})
-	return err
 }
 
 func (s *Bolt) Get(k []byte) ([]byte, error) {
@@ -89,12 +88,12 @@ func (s *Bolt) Get(k []byte) ([]byte, error) {
 }
 
 func (s *Bolt) BatchGet(keys [][]byte) ([][]byte, error) {
-	var err error
 	values := make([][]byte, len(keys))
-	s.db.Batch(func(tx *bolt.Tx) error {
+	err := s.db.View(func(tx *bolt.Tx) error {
 		for i, key := range keys {
-			ival := tx.Bucket(s.bucket).Get(key)
-			values[i] = ival
+			if ival := tx.Bucket(s.bucket).Get(key); ival != nil {
+				values[i] = append([]byte{}, ival...) //bolt返回的value只在事务内有效，需要拷贝出来
+			}
 		}
 		return nil
 	})
@@ -108,14 +107,14 @@ func (s *Bolt) Delete(k []byte) error {
 }
 
 func (s *Bolt) BatchDelete(keys [][]byte) error {
-	var err error
-	s.db.Batch(func(tx *bolt.Tx) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
 		for _, key := range keys {
-			tx.Bucket(s.bucket).Delete(key)
+			if err := tx.Bucket(s.bucket).Delete(key); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
-	return err
 }
 
 func (s *Bolt) Has(k []byte) bool {
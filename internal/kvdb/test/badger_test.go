@@ -1,15 +1,14 @@
 package test
 
 import (
-	"myRadic/internal/kvdb"
-	"myRadic/util"
+	"github.com/Muoshu/myRadic/internal/kvdb"
 	"testing"
 )
 
 func TestBadger(t *testing.T) {
 	setup = func() {
 		var err error
-		db, err = kvdb.GetKvDb(kvdb.BADGER, util.RootPath+"data/badger_db")
+		db, err = kvdb.GetKvDb(kvdb.BADGER, t.TempDir()+"/badger_db")
 		if err != nil {
 			panic(err)
 		}
@@ -1,15 +1,14 @@
 package test
 
 import (
-	"myRadic/internal/kvdb"
-	"myRadic/util"
+	"github.com/Muoshu/myRadic/internal/kvdb"
 	"testing"
 )
 
 func TestBolt(t *testing.T) {
 	setup = func() {
 		var err error
-		db, err = kvdb.GetKvDb(kvdb.BOLT, util.RootPath+"data/bolt_db") //使用工厂模式
+		db, err = kvdb.GetKvDb(kvdb.BOLT, t.TempDir()+"/bolt_db") //使用工厂模式
 		if err != nil {
 			panic(err)
 		}
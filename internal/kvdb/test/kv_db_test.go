@@ -3,7 +3,7 @@ package test
 import (
 	"errors"
 	"fmt"
-	"myRadic/internal/kvdb"
+	"github.com/Muoshu/myRadic/internal/kvdb"
 	"testing"
 )
 
@@ -77,12 +77,17 @@ func testBatchGetSetDelete(db kvdb.IKeyValueDB) error {
 	k2 := []byte("k2")
 	v2 := []byte("v2")
 	//批量写入<k, v>
-	db.BatchSet([][]byte{k1, k2}, [][]byte{v1, v2})
-	//批量读取
-	values, err := db.BatchGet([][]byte{k1, k2})
+	if err := db.BatchSet([][]byte{k1, k2}, [][]byte{v1, v2}); err != nil {
+		return err
+	}
+	//批量读取，不存在的key读出空值，不算错误
+	values, err := db.BatchGet([][]byte{k1, k2, []byte("k3")})
 	if err != nil {
 		return err
 	}
+	if string(values[0]) != "v1" || string(values[1]) != "v2" || len(values[2]) != 0 {
+		return errors.New("批量读取的结果不对")
+	}
 	fmt.Printf("values ")
 	for _, v := range values {
 		fmt.Printf("%s ", string(v))
@@ -90,7 +95,9 @@ func testBatchGetSetDelete(db kvdb.IKeyValueDB) error {
 	fmt.Println()
 
 	//批量删除
-	db.BatchDelete([][]byte{k1, k2})
+	if err := db.BatchDelete([][]byte{k1, k2}); err != nil {
+		return err
+	}
 	//批量读取
 	// 读取<k, v>
 	_, err = db.Get(k1)
@@ -0,0 +1,49 @@
+package reverse_index
+
+import "github.com/Muoshu/myRadic/types"
+
+// batchPosting 一批文档里某个文档在某个key上的posting
+type batchPosting struct {
+	doc       *types.Document
+	positions []int32
+}
+
+// groupPostings 把一批文档的posting按key分组，这样每个key只需要加一次锁。
+// 每个有keyword的文档先回调一次onDoc，用于更新文档级别的统计量；没有keyword的文档跳过，跟Add一致
+func groupPostings(docs []types.Document, onDoc func(doc *types.Document, termPositions map[string][]int32)) map[string][]batchPosting {
+	groups := make(map[string][]batchPosting, len(docs)*4)
+	for i := range docs {
+		doc := &docs[i]
+		termPositions := keywordPositions(*doc)
+		if len(termPositions) == 0 {
+			continue
+		}
+		onDoc(doc, termPositions)
+		for key, positions := range termPositions {
+			groups[key] = append(groups[key], batchPosting{doc: doc, positions: positions})
+		}
+	}
+	return groups
+}
+
+// groupDeletes 把要删除的posting按key分组，同一个文档的重复keyword只删一次
+func groupDeletes(docs []*types.Document) map[string][]uint64 {
+	groups := make(map[string][]uint64, len(docs)*4)
+	seen := make(map[string]map[uint64]struct{}, len(docs)*4)
+	for _, doc := range docs {
+		for _, keyword := range doc.Keywords {
+			key := keyword.ToString()
+			ids, ok := seen[key]
+			if !ok {
+				ids = make(map[uint64]struct{}, 1)
+				seen[key] = ids
+			}
+			if _, exists := ids[doc.IntId]; exists {
+				continue
+			}
+			ids[doc.IntId] = struct{}{}
+			groups[key] = append(groups[key], doc.IntId)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,122 @@
+package reverse_index
+
+import (
+	"math"
+	"sync"
+)
+
+// BM25的两个超参数
+const (
+	BM25_K1 = 1.2  //词频饱和度，越大则词频对得分的影响越大
+	BM25_B  = 0.75 //文档长度归一化的程度，0表示不考虑文档长度
+)
+
+type docStat struct {
+	length   int    //文档长度，即文档Keywords的个数(重复的keyword也计数)
+	postings int    //该文档在倒排索引上还剩几条posting，减到0时文档就从统计量中移除
+	bits     uint64 //BitsFeature，分面统计用
+}
+
+// DocStats 维护BM25需要的全局统计量：文档总数、文档总长度以及每篇文档的长度。顺便记下每篇文档的BitsFeature
+type DocStats struct {
+	lock     sync.RWMutex
+	docs     map[uint64]*docStat
+	totalLen int64
+}
+
+func NewDocStats(docNum int) *DocStats {
+	return &DocStats{
+		docs: make(map[uint64]*docStat, docNum),
+	}
+}
+
+// Add 登记一篇文档。length是文档长度，postings是文档在倒排索引上的posting条数(即不重复的keyword个数)
+func (stats *DocStats) Add(intId uint64, length int, postings int, bits uint64) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	if old, ok := stats.docs[intId]; ok { //重复添加时先扣掉旧的长度
+		stats.totalLen -= int64(old.length)
+	}
+	stats.docs[intId] = &docStat{length: length, postings: postings, bits: bits}
+	stats.totalLen += int64(length)
+}
+
+// RemovePosting 文档的一条posting被删除了。所有posting都被删除后，文档从统计量中移除，此时返回true
+func (stats *DocStats) RemovePosting(intId uint64) bool {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	stat, ok := stats.docs[intId]
+	if !ok {
+		return false
+	}
+	stat.postings--
+	if stat.postings <= 0 {
+		stats.totalLen -= int64(stat.length)
+		delete(stats.docs, intId)
+		return true
+	}
+	return false
+}
+
+// Has 文档是否还在索引上
+func (stats *DocStats) Has(intId uint64) bool {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+	_, ok := stats.docs[intId]
+	return ok
+}
+
+// DocLen 文档长度
+func (stats *DocStats) DocLen(intId uint64) int {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+	if stat, ok := stats.docs[intId]; ok {
+		return stat.length
+	}
+	return 0
+}
+
+// Bits 文档的BitsFeature
+func (stats *DocStats) Bits(intId uint64) uint64 {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+	if stat, ok := stats.docs[intId]; ok {
+		return stat.bits
+	}
+	return 0
+}
+
+// DocCount 索引上的文档总数
+func (stats *DocStats) DocCount() int {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+	return len(stats.docs)
+}
+
+// AvgDocLen 平均文档长度
+func (stats *DocStats) AvgDocLen() float64 {
+	stats.lock.RLock()
+	defer stats.lock.RUnlock()
+	if len(stats.docs) == 0 {
+		return 0
+	}
+	return float64(stats.totalLen) / float64(len(stats.docs))
+}
+
+// BM25Idf 逆文档频率。docCount是文档总数，docFreq是包含该term的文档数
+func BM25Idf(docCount, docFreq int) float64 {
+	return math.Log(1 + (float64(docCount)-float64(docFreq)+0.5)/(float64(docFreq)+0.5))
+}
+
+// BM25TermScore 单个term对一篇文档贡献的得分
+func BM25TermScore(idf float64, termFreq int, docLen int, avgDocLen float64) float64 {
+	if termFreq <= 0 {
+		return 0
+	}
+	tf := float64(termFreq)
+	norm := 1.0
+	if avgDocLen > 0 {
+		norm = 1 - BM25_B + BM25_B*float64(docLen)/avgDocLen
+	}
+	return idf * tf * (BM25_K1 + 1) / (tf + BM25_K1*norm)
+}
@@ -0,0 +1,98 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math"
+)
+
+const (
+	MAX_EXPANSIONS = 128 //一个前缀、通配符或模糊查询最多展开成多少个keyword。前缀和通配符按字典序取前面的，模糊查询取编辑距离近的
+	FUZZY_DISCOUNT = 0.5 //模糊匹配每多一个编辑，得分乘一次这个折扣，保证完全匹配的排在前面
+)
+
+// noMatchKeyword 一个不可能存在的keyword。前缀或通配符一个term都没匹配上时用它占位，保证该节点一个文档都不命中，而不是被当成空节点忽略掉
+var noMatchKeyword = &types.Keyword{Field: "\000", Word: "\000"}
+
+// ExpandQuery 把查询树上的前缀、通配符和模糊查询节点展开成由字典里的keyword组成的Should。
+// 不修改原来的查询，没有需要展开的节点时原样返回
+func ExpandQuery(q *types.TermQuery, dict *TermDict, maxExpansions int) *types.TermQuery {
+	if q == nil {
+		return nil
+	}
+	mustNot, mustNotChanged := expandQuerys(q.MustNot, dict, maxExpansions)
+	if q.Keyword != nil {
+		//Keyword节点本身不用展开，但它的MustNot里可能有
+		if !mustNotChanged {
+			return q
+		}
+		return &types.TermQuery{Keyword: q.Keyword, Boost: q.Boost, MustNot: mustNot}
+	}
+	if keywords, _, _ := proximityKeywords(q); len(keywords) == 0 {
+		//跟BuildIterator的优先级一致：Keyword、Phrase、Near之后是Prefix、Wildcard、Fuzzy，然后才是Must、Should
+		var leaves []*types.TermQuery
+		if q.Prefix != nil {
+			leaves = wordLeaves(q.Prefix.Field, dict.Prefix(q.Prefix.Field, q.Prefix.Word, maxExpansions))
+		} else if q.Wildcard != nil {
+			leaves = wordLeaves(q.Wildcard.Field, dict.Wildcard(q.Wildcard.Field, q.Wildcard.Word, maxExpansions))
+		} else if q.Fuzzy != nil && q.Fuzzy.Keyword != nil {
+			leaves = fuzzyLeaves(q.Fuzzy, dict, maxExpansions)
+		}
+		if leaves != nil {
+			res := &types.TermQuery{MustNot: mustNot}
+			switch len(leaves) {
+			case 0:
+				res.Keyword = noMatchKeyword
+			case 1:
+				res.Keyword, res.Boost = leaves[0].Keyword, leaves[0].Boost
+			default:
+				res.Should = leaves
+			}
+			return res
+		}
+	}
+	must, mustChanged := expandQuerys(q.Must, dict, maxExpansions)
+	should, shouldChanged := expandQuerys(q.Should, dict, maxExpansions)
+	if !mustChanged && !shouldChanged && !mustNotChanged {
+		return q
+	}
+	return &types.TermQuery{Phrase: q.Phrase, Near: q.Near, Range: q.Range, Must: must, Should: should, MustNot: mustNot}
+}
+
+func wordLeaves(field string, words []string) []*types.TermQuery {
+	leaves := make([]*types.TermQuery, 0, len(words))
+	for _, word := range words {
+		leaves = append(leaves, types.NewTermQuery(field, word))
+	}
+	return leaves
+}
+
+// 模糊查询展开出的keyword按编辑距离打折，完全匹配的不打折
+func fuzzyLeaves(fuzzy *types.Fuzzy, dict *TermDict, maxExpansions int) []*types.TermQuery {
+	field := fuzzy.Keyword.Field
+	terms := dict.Fuzzy(field, fuzzy.Keyword.Word, int(fuzzy.MaxEdits), maxExpansions)
+	leaves := make([]*types.TermQuery, 0, len(terms))
+	for _, term := range terms {
+		leaf := types.NewTermQuery(field, term.Word)
+		if term.Distance > 0 {
+			leaf.Boost = float32(math.Pow(FUZZY_DISCOUNT, float64(term.Distance)))
+		}
+		leaves = append(leaves, leaf)
+	}
+	return leaves
+}
+
+func expandQuerys(querys []*types.TermQuery, dict *TermDict, maxExpansions int) ([]*types.TermQuery, bool) {
+	changed := false
+	res := querys
+	for i, q := range querys {
+		if expanded := ExpandQuery(q, dict, maxExpansions); expanded != q {
+			if !changed {
+				res = make([]*types.TermQuery, len(querys))
+				copy(res, querys)
+				changed = true
+			}
+			res[i] = expanded
+		}
+	}
+	return res, changed
+}
@@ -0,0 +1,87 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"time"
+)
+
+// Explanation 查询树上一个节点的执行情况，树的结构跟TermQuery相同
+type Explanation struct {
+	Op            string        //KEYWORD、PHRASE、NEAR、RANGE、MUST、SHOULD
+	Query         string        //该节点对应的子查询
+	Negated       bool          //是否为MustNot里的节点。这种节点不按bits过滤，也不贡献得分
+	PostingLength int           //叶子节点是倒排链的长度，组合节点是各子节点Rows之和
+	FilteredRows  int           //叶子节点是按bits过滤后剩下的文档数，组合节点是各子节点FilteredRows之和
+	Rows          int           //求完交集、并集、差集后，该节点产出的文档数
+	Time          time.Duration //打开并遍历该节点的迭代器所花的时间，包含子节点
+	Matched       bool          //指定的文档是否被该节点产出
+	Score         float64       //指定的文档在该节点上的得分
+	Children      []*Explanation
+}
+
+// ExplainQuery 逐个节点单独构建迭代器并遍历，统计每个节点的文档数和耗时。intId大于0时记录该文档在每个节点上的得分。
+// 每个节点都要重新遍历一遍子树，只用于排查问题，不要用在线上检索中
+func ExplainQuery(q *types.TermQuery, open LeafOpener, intId uint64) *Explanation {
+	if q == nil || q.Empty() {
+		return nil
+	}
+	return explainNode(q, open, true, intId)
+}
+
+func explainNode(q *types.TermQuery, open LeafOpener, filtered bool, intId uint64) *Explanation {
+	node := &Explanation{Query: q.ToString(), Negated: !filtered}
+	keywords, ordered, _ := proximityKeywords(q)
+	switch {
+	case q.Keyword != nil:
+		node.Op = "KEYWORD"
+		node.PostingLength = CountAll(open.OpenKeyword(q.Keyword, false))
+		node.FilteredRows = CountAll(open.OpenKeyword(q.Keyword, filtered))
+	case len(keywords) > 0:
+		node.Op = "NEAR"
+		if ordered {
+			node.Op = "PHRASE"
+		}
+		for _, keyword := range keywords {
+			node.Children = append(node.Children, explainNode(&types.TermQuery{Keyword: keyword}, open, filtered, intId))
+		}
+	case q.Range != nil:
+		node.Op = "RANGE"
+		node.PostingLength = CountAll(open.OpenRange(q.Range, false))
+		node.FilteredRows = CountAll(open.OpenRange(q.Range, filtered))
+	case len(q.Must) > 0:
+		node.Op = "MUST"
+		node.Children = explainChildren(q.Must, open, filtered, intId)
+	case len(q.Should) > 0:
+		node.Op = "SHOULD"
+		node.Children = explainChildren(q.Should, open, filtered, intId)
+	}
+	for _, child := range node.Children {
+		node.PostingLength += child.Rows
+		node.FilteredRows += child.FilteredRows
+	}
+	node.Children = append(node.Children, explainChildren(q.MustNot, open, false, intId)...)
+
+	begin := time.Now()
+	it := buildIterator(q, open, filtered)
+	if it != nil {
+		for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+			node.Rows++
+			if doc == intId {
+				node.Matched = true
+				node.Score = it.Score()
+			}
+		}
+	}
+	node.Time = time.Since(begin)
+	return node
+}
+
+func explainChildren(querys []*types.TermQuery, open LeafOpener, filtered bool, intId uint64) []*Explanation {
+	children := make([]*Explanation, 0, len(querys))
+	for _, q := range querys {
+		if q != nil && !q.Empty() {
+			children = append(children, explainNode(q, open, filtered, intId))
+		}
+	}
+	return children
+}
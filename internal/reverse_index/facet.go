@@ -0,0 +1,86 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math/bits"
+	"slices"
+)
+
+// Facets 检索结果的分面统计
+type Facets struct {
+	Total  int            //命中的文档数
+	Bits   [64]int        //Bits[i]是BitsFeature第i个bit为1的命中文档数
+	Values map[string]int //field里每个word命中的文档数，一篇都没命中的word不列出
+}
+
+// field下的所有word。field为空时不统计
+func facetWords(dict *TermDict, field string) []string {
+	if len(field) == 0 {
+		return nil
+	}
+	return dict.Prefix(field, "", 0)
+}
+
+func facetKeys(field string, words []string) []string {
+	keys := make([]string, 0, len(words))
+	for _, word := range words {
+		keys = append(keys, (&types.Keyword{Field: field, Word: word}).ToString())
+	}
+	return keys
+}
+
+// 遍历迭代器，统计命中的文档数和bits，不打分。同时按IntId从小到大记下命中的文档，给countFacetValues求交集用
+func collectMatches(it PostingIterator, stats *DocStats) (*Facets, []uint64) {
+	facets := &Facets{Values: make(map[string]int)}
+	intIds := make([]uint64, 0, 64)
+	if it != nil {
+		for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+			intIds = append(intIds, doc)
+			facets.addBits(stats.Bits(doc))
+		}
+	}
+	facets.Total = len(intIds)
+	return facets, intIds
+}
+
+func (facets *Facets) addBits(b uint64) {
+	for ; b != 0; b &= b - 1 { //逐个取出为1的bit
+		facets.Bits[bits.TrailingZeros64(b)]++
+	}
+}
+
+// countFacetValues field的每个word，拿它的倒排链跟命中的文档(按IntId从小到大排好序)求交集，谁短就遍历谁。
+// 只在查询了分面时才读field的倒排链，不需要额外按文档存一份key表。
+// open打开不按bits过滤的叶子迭代器，调用方需要事先对words对应的keyword加读锁
+func countFacetValues(facets *Facets, intIds []uint64, field string, words []string, open LeafOpener) {
+	if len(intIds) == 0 {
+		return
+	}
+	for _, word := range words {
+		it := open.OpenKeyword(&types.Keyword{Field: field, Word: word}, false)
+		count := 0
+		if it.Cost() < int64(len(intIds)) {
+			for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+				if _, found := slices.BinarySearch(intIds, doc); found {
+					count++
+				}
+			}
+		} else {
+			for _, intId := range intIds {
+				doc := it.DocId()
+				if doc < intId {
+					doc = it.Advance(intId)
+				}
+				if doc == NO_MORE_DOCS {
+					break
+				}
+				if doc == intId {
+					count++
+				}
+			}
+		}
+		if count > 0 {
+			facets.Values[word] = count
+		}
+	}
+}
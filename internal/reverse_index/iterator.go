@@ -0,0 +1,368 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math"
+	"sort"
+)
+
+// NO_MORE_DOCS 迭代器走到尽头时DocId()的返回值
+const NO_MORE_DOCS uint64 = math.MaxUint64
+
+// PostingIterator 倒排链上的迭代器，按IntId从小到大产出文档。迭代器模式
+//
+// 查询树上的每个节点都是一个迭代器：叶子节点直接遍历某个keyword的倒排链，Must、Should、MustNot节点由子节点的迭代器组合而成。
+// 结果是边遍历边算出来的，中间不会生成任何集合
+type PostingIterator interface {
+	DocId() uint64                //当前文档的IntId。还没开始遍历时为0，走到尽头时为NO_MORE_DOCS
+	Next() uint64                 //前进到下一个文档，返回其IntId
+	Advance(target uint64) uint64 //前进到第一个IntId>=target的文档，返回其IntId。target不能小于当前的DocId()
+	Cost() int64                  //最多还能产出多少个文档，用来决定谁驱动谁
+	Id() string                   //当前文档的业务Id
+	Score() float64               //当前文档的得分
+	MaxScore() float64            //任意文档得分的上界
+}
+
+// emptyIterator 一个文档都没有的迭代器，keyword不存在时使用
+type emptyIterator struct {
+	doc uint64
+}
+
+func (it *emptyIterator) DocId() uint64                { return it.doc }
+func (it *emptyIterator) Next() uint64                 { it.doc = NO_MORE_DOCS; return it.doc }
+func (it *emptyIterator) Advance(target uint64) uint64 { it.doc = NO_MORE_DOCS; return it.doc }
+func (it *emptyIterator) Cost() int64                  { return 0 }
+func (it *emptyIterator) Id() string                   { return "" }
+func (it *emptyIterator) Score() float64               { return 0 }
+func (it *emptyIterator) MaxScore() float64            { return 0 }
+
+// conjunctionIterator 求交集。由Cost最小的子迭代器驱动，其他子迭代器跳跃查找
+type conjunctionIterator struct {
+	children []PostingIterator //保持查询里的原始顺序，累加得分时用
+	lead     PostingIterator
+	others   []PostingIterator //按Cost从小到大排列
+	doc      uint64
+}
+
+func newConjunctionIterator(children []PostingIterator) *conjunctionIterator {
+	sorted := make([]PostingIterator, len(children))
+	copy(sorted, children)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Cost() < sorted[j].Cost() })
+	return &conjunctionIterator{children: children, lead: sorted[0], others: sorted[1:]}
+}
+
+func (it *conjunctionIterator) DocId() uint64 { return it.doc }
+
+func (it *conjunctionIterator) Next() uint64 {
+	return it.doNext(it.lead.Next())
+}
+
+func (it *conjunctionIterator) Advance(target uint64) uint64 {
+	return it.doNext(it.lead.Advance(target))
+}
+
+// target是驱动迭代器的当前文档，让其他迭代器都对齐到target。对不齐时驱动迭代器跳到更大的位置重来
+func (it *conjunctionIterator) doNext(target uint64) uint64 {
+	for target != NO_MORE_DOCS {
+		matched := true
+		for _, other := range it.others {
+			doc := other.DocId()
+			if doc < target {
+				doc = other.Advance(target)
+			}
+			if doc != target {
+				target = it.lead.Advance(doc)
+				matched = false
+				break
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	it.doc = target
+	return it.doc
+}
+
+func (it *conjunctionIterator) Cost() int64 { return it.lead.Cost() }
+func (it *conjunctionIterator) Id() string  { return it.lead.Id() }
+
+func (it *conjunctionIterator) Score() float64 {
+	var score float64
+	for _, child := range it.children {
+		score += child.Score()
+	}
+	return score
+}
+
+func (it *conjunctionIterator) MaxScore() float64 {
+	var score float64
+	for _, child := range it.children {
+		score += child.MaxScore()
+	}
+	return score
+}
+
+// disjunctionIterator 求并集。当前文档是各子迭代器当前文档中最小的那个
+type disjunctionIterator struct {
+	children []PostingIterator
+	doc      uint64
+}
+
+func newDisjunctionIterator(children []PostingIterator) *disjunctionIterator {
+	return &disjunctionIterator{children: children}
+}
+
+func (it *disjunctionIterator) DocId() uint64 { return it.doc }
+
+func (it *disjunctionIterator) Next() uint64 {
+	if it.doc == NO_MORE_DOCS {
+		return it.doc
+	}
+	for _, child := range it.children {
+		if child.DocId() == it.doc { //还没开始遍历时，所有孩子的DocId()都是0
+			child.Next()
+		}
+	}
+	return it.update()
+}
+
+func (it *disjunctionIterator) Advance(target uint64) uint64 {
+	for _, child := range it.children {
+		if child.DocId() < target {
+			child.Advance(target)
+		}
+	}
+	return it.update()
+}
+
+func (it *disjunctionIterator) update() uint64 {
+	it.doc = NO_MORE_DOCS
+	for _, child := range it.children {
+		if doc := child.DocId(); doc < it.doc {
+			it.doc = doc
+		}
+	}
+	return it.doc
+}
+
+func (it *disjunctionIterator) Cost() int64 {
+	var cost int64
+	for _, child := range it.children {
+		cost += child.Cost()
+	}
+	return cost
+}
+
+func (it *disjunctionIterator) Id() string {
+	for _, child := range it.children {
+		if child.DocId() == it.doc {
+			return child.Id()
+		}
+	}
+	return ""
+}
+
+// Score 当前文档所在的各子迭代器的得分之和
+func (it *disjunctionIterator) Score() float64 {
+	var score float64
+	for _, child := range it.children {
+		if child.DocId() == it.doc {
+			score += child.Score()
+		}
+	}
+	return score
+}
+
+func (it *disjunctionIterator) MaxScore() float64 {
+	var score float64
+	for _, child := range it.children {
+		score += child.MaxScore()
+	}
+	return score
+}
+
+// exclusionIterator 求差集。产出在required里但不在excluded里的文档，得分只来自required
+type exclusionIterator struct {
+	required PostingIterator
+	excluded PostingIterator
+	doc      uint64
+}
+
+func (it *exclusionIterator) DocId() uint64 { return it.doc }
+
+func (it *exclusionIterator) Next() uint64 {
+	return it.skipExcluded(it.required.Next())
+}
+
+func (it *exclusionIterator) Advance(target uint64) uint64 {
+	return it.skipExcluded(it.required.Advance(target))
+}
+
+func (it *exclusionIterator) skipExcluded(doc uint64) uint64 {
+	for doc != NO_MORE_DOCS {
+		excluded := it.excluded.DocId()
+		if excluded < doc {
+			excluded = it.excluded.Advance(doc)
+		}
+		if excluded != doc {
+			break
+		}
+		doc = it.required.Next()
+	}
+	it.doc = doc
+	return it.doc
+}
+
+func (it *exclusionIterator) Cost() int64       { return it.required.Cost() }
+func (it *exclusionIterator) Id() string        { return it.required.Id() }
+func (it *exclusionIterator) Score() float64    { return it.required.Score() }
+func (it *exclusionIterator) MaxScore() float64 { return it.required.MaxScore() }
+
+// boostIterator 得分乘以一个权重，用于Keyword节点上的Boost
+type boostIterator struct {
+	PostingIterator
+	boost float64
+}
+
+func (it *boostIterator) Score() float64    { return it.PostingIterator.Score() * it.boost }
+func (it *boostIterator) MaxScore() float64 { return it.PostingIterator.MaxScore() * it.boost }
+
+// leafBoost Keyword节点得分的权重，Boost为0时不加权
+func leafBoost(q *types.TermQuery) float64 {
+	if q.Boost > 0 {
+		return float64(q.Boost)
+	}
+	return 1
+}
+
+// 为Keyword节点创建叶子迭代器，带上Boost
+func openLeaf(q *types.TermQuery, open LeafOpener, filtered bool) PostingIterator {
+	it := open.OpenKeyword(q.Keyword, filtered)
+	if boost := leafBoost(q); boost != 1 {
+		return &boostIterator{PostingIterator: it, boost: boost}
+	}
+	return it
+}
+
+// LeafOpener 为查询树的叶子节点创建迭代器。filtered为false时不按bits过滤
+type LeafOpener interface {
+	OpenKeyword(keyword *types.Keyword, filtered bool) PostingIterator
+	OpenRange(r *types.Range, filtered bool) PostingIterator
+}
+
+// leafOpener keyword的倒排链由各索引自己打开，Range统一从NumericStore里取
+type leafOpener struct {
+	openKeyword func(keyword *types.Keyword, filtered bool) PostingIterator
+	numerics    *NumericStore
+	filter      func(bits uint64) bool
+}
+
+func (o leafOpener) OpenKeyword(keyword *types.Keyword, filtered bool) PostingIterator {
+	return o.openKeyword(keyword, filtered)
+}
+
+func (o leafOpener) OpenRange(r *types.Range, filtered bool) PostingIterator {
+	if filtered {
+		return o.numerics.OpenRange(r, o.filter)
+	}
+	return o.numerics.OpenRange(r, nil)
+}
+
+// BuildIterator 把查询树翻译成迭代器树。查询为空时返回nil
+func BuildIterator(q *types.TermQuery, open LeafOpener) PostingIterator {
+	return buildIterator(q, open, true)
+}
+
+func buildIterator(q *types.TermQuery, open LeafOpener, filtered bool) PostingIterator {
+	if q == nil {
+		return nil
+	}
+	var it PostingIterator
+	if q.Keyword != nil {
+		it = openLeaf(q, open, filtered)
+	} else if keywords, ordered, distance := proximityKeywords(q); len(keywords) > 0 {
+		it = buildProximityIterator(keywords, ordered, distance, open, filtered)
+	} else if q.Range != nil {
+		it = open.OpenRange(q.Range, filtered)
+	} else if len(q.Must) > 0 {
+		it = combineIterators(q.Must, open, filtered, func(children []PostingIterator) PostingIterator {
+			return newConjunctionIterator(children)
+		})
+	} else if len(q.Should) > 0 {
+		it = combineIterators(q.Should, open, filtered, func(children []PostingIterator) PostingIterator {
+			return newDisjunctionIterator(children)
+		})
+	}
+	//MustNot只能从已有的结果里做减法。排除时不需要按bits过滤，因为被排除的文档如果能被required产出，它的bits必然已经通过了过滤
+	if it != nil && len(q.MustNot) > 0 {
+		excluded := combineIterators(q.MustNot, open, false, func(children []PostingIterator) PostingIterator {
+			return newDisjunctionIterator(children)
+		})
+		if excluded != nil {
+			it = &exclusionIterator{required: it, excluded: excluded}
+		}
+	}
+	return it
+}
+
+func combineIterators(querys []*types.TermQuery, open LeafOpener, filtered bool, combine func([]PostingIterator) PostingIterator) PostingIterator {
+	children := make([]PostingIterator, 0, len(querys))
+	for _, q := range querys {
+		if child := buildIterator(q, open, filtered); child != nil {
+			children = append(children, child)
+		}
+	}
+	switch len(children) {
+	case 0:
+		return nil
+	case 1:
+		return children[0]
+	default:
+		return combine(children)
+	}
+}
+
+// QueryKeywords 查询树上的所有keyword，包括MustNot里的
+func QueryKeywords(q *types.TermQuery) []*types.Keyword {
+	if q == nil {
+		return nil
+	}
+	proximity, _, _ := proximityKeywords(q)
+	keywords := make([]*types.Keyword, 0, 1+len(proximity)+len(q.Must)+len(q.Should)+len(q.MustNot))
+	if q.Keyword != nil { //Keyword节点上也可以带MustNot，不能就此返回
+		keywords = append(keywords, q.Keyword)
+	}
+	keywords = append(keywords, proximity...)
+	for _, children := range [][]*types.TermQuery{q.Must, q.Should, q.MustNot} {
+		for _, child := range children {
+			keywords = append(keywords, QueryKeywords(child)...)
+		}
+	}
+	return keywords
+}
+
+// CountAll 遍历迭代器，只计数，不取业务Id也不打分
+func CountAll(it PostingIterator) int {
+	if it == nil {
+		return 0
+	}
+	n := 0
+	for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+		n++
+	}
+	return n
+}
+
+// CollectAll 遍历迭代器，返回按得分排好序的全部结果
+func CollectAll(it PostingIterator) []SearchHit {
+	if it == nil {
+		return nil
+	}
+	hits := make([]SearchHit, 0, min(it.Cost(), 1024))
+	for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+		hits = append(hits, SearchHit{Id: it.Id(), IntId: doc, Score: it.Score()})
+	}
+	SortHits(hits)
+	return hits
+}
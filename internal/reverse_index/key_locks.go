@@ -0,0 +1,52 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	farmhash "github.com/leemcloughlin/gofarmhash"
+	"sort"
+	"sync"
+)
+
+// keyLocks 分段锁。修改倒排索引时，相同的key需要去竞争同一把锁
+type keyLocks []sync.RWMutex
+
+func (locks keyLocks) getLockIndex(key string) int {
+	n := int(farmhash.Hash32WithSeed([]byte(key), 0))
+	return n % len(locks)
+}
+
+func (locks keyLocks) getLock(key string) *sync.RWMutex {
+	return &locks[locks.getLockIndex(key)]
+}
+
+// 对查询里用到的所有keyword，连同extra里的key一起加读锁，返回解锁函数
+func (locks keyLocks) rLockQuery(query *types.TermQuery, extra ...string) func() {
+	keywords := QueryKeywords(query)
+	keys := make([]string, 0, len(keywords)+len(extra))
+	keys = append(keys, extra...)
+	for _, keyword := range keywords {
+		keys = append(keys, keyword.ToString())
+	}
+	return locks.rLockKeys(keys)
+}
+
+// 对多个key加读锁。按锁的下标从小到大加锁，且同一把锁只加一次，避免多个查询之间死锁
+func (locks keyLocks) rLockKeys(keys []string) func() {
+	idx := make(map[int]struct{}, len(keys))
+	for _, key := range keys {
+		idx[locks.getLockIndex(key)] = struct{}{}
+	}
+	sorted := make([]int, 0, len(idx))
+	for i := range idx {
+		sorted = append(sorted, i)
+	}
+	sort.Ints(sorted)
+	for _, i := range sorted {
+		locks[i].RLock()
+	}
+	return func() {
+		for _, i := range sorted {
+			locks[i].RUnlock()
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"sort"
+	"sync"
+)
+
+// numericColumn 一个数值字段的列，docs里是有该字段的文档
+type numericColumn struct {
+	docs   *Bitmap
+	values map[uint64]int64
+}
+
+// numericDoc 有数值字段的文档。Range查询单独使用时需要业务Id和bits
+type numericDoc struct {
+	id     string
+	bits   uint64
+	fields []string
+}
+
+// NumericStore 按列存放文档的数值字段(播放量、点赞数、发布时间...)，Range查询在这里求出满足条件的文档，
+// 不用等正排索引BatchGet之后再过滤
+type NumericStore struct {
+	lock    sync.RWMutex
+	columns map[string]*numericColumn
+	docs    map[uint64]numericDoc
+}
+
+func NewNumericStore(docNum int) *NumericStore {
+	return &NumericStore{
+		columns: make(map[string]*numericColumn, 8),
+		docs:    make(map[uint64]numericDoc, docNum),
+	}
+}
+
+// Add 登记文档的数值字段，没有数值字段时什么也不做
+func (store *NumericStore) Add(intId uint64, id string, bits uint64, numerics map[string]int64) {
+	if len(numerics) == 0 {
+		return
+	}
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.remove(intId) //重复添加时先删掉旧值
+	fields := make([]string, 0, len(numerics))
+	for field, value := range numerics {
+		column, ok := store.columns[field]
+		if !ok {
+			column = &numericColumn{docs: NewBitmap(), values: make(map[uint64]int64)}
+			store.columns[field] = column
+		}
+		column.docs.Add(intId)
+		column.values[intId] = value
+		fields = append(fields, field)
+	}
+	store.docs[intId] = numericDoc{id: id, bits: bits, fields: fields}
+}
+
+// Update 文档更新后替换它的数值字段，新文档没有数值字段时删掉旧值
+func (store *NumericStore) Update(intId uint64, id string, bits uint64, numerics map[string]int64) {
+	if len(numerics) == 0 {
+		store.Remove(intId)
+		return
+	}
+	store.Add(intId, id, bits, numerics)
+}
+
+// Remove 文档从倒排索引上彻底删除后，把它的数值字段也删掉
+func (store *NumericStore) Remove(intId uint64) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.remove(intId)
+}
+
+func (store *NumericStore) remove(intId uint64) {
+	doc, ok := store.docs[intId]
+	if !ok {
+		return
+	}
+	for _, field := range doc.fields {
+		if column := store.columns[field]; column != nil {
+			column.docs.Remove(intId)
+			delete(column.values, intId)
+		}
+	}
+	delete(store.docs, intId)
+}
+
+// Get 文档field的数值，文档没有该字段时返回false
+func (store *NumericStore) Get(intId uint64, field string) (int64, bool) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	column := store.columns[field]
+	if column == nil {
+		return 0, false
+	}
+	value, ok := column.values[intId]
+	return value, ok
+}
+
+// Match 文档field的数值是否在r的范围内
+func (store *NumericStore) Match(r *types.Range, intId uint64) bool {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	column := store.columns[r.Field]
+	if column == nil {
+		return false
+	}
+	value, ok := column.values[intId]
+	return ok && value >= r.Min && value <= r.Max
+}
+
+// Range 满足r的文档集合，返回的位图是新建的，调用方可以随意使用
+func (store *NumericStore) Range(r *types.Range) *Bitmap {
+	res := NewBitmap()
+	store.scan(r, func(intId uint64, doc numericDoc) { res.Add(intId) })
+	return res
+}
+
+// 按IntId从小到大遍历满足r的文档
+func (store *NumericStore) scan(r *types.Range, fun func(intId uint64, doc numericDoc)) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	column := store.columns[r.Field]
+	if column == nil || r.Min > r.Max {
+		return
+	}
+	it := column.docs.Iterator()
+	for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+		if value := column.values[intId]; intId > 0 && value >= r.Min && value <= r.Max { //确保有效元素都大于0
+			fun(intId, store.docs[intId])
+		}
+	}
+}
+
+// rangeIterator Range节点的叶子迭代器。打开时就把满足条件的文档取出来，遍历期间不用一直持有NumericStore的锁。
+// Range只过滤不打分，得分为0
+type rangeIterator struct {
+	docs []uint64
+	ids  []string
+	pos  int
+	doc  uint64
+}
+
+// OpenRange 为Range节点创建叶子迭代器。filter为nil时不按bits过滤
+func (store *NumericStore) OpenRange(r *types.Range, filter func(bits uint64) bool) PostingIterator {
+	it := &rangeIterator{pos: -1}
+	store.scan(r, func(intId uint64, doc numericDoc) {
+		if filter == nil || filter(doc.bits) {
+			it.docs = append(it.docs, intId)
+			it.ids = append(it.ids, doc.id)
+		}
+	})
+	return it
+}
+
+func (it *rangeIterator) DocId() uint64 { return it.doc }
+
+func (it *rangeIterator) Next() uint64 {
+	if it.doc == NO_MORE_DOCS {
+		return it.doc
+	}
+	it.pos++
+	return it.current()
+}
+
+func (it *rangeIterator) Advance(target uint64) uint64 {
+	if it.doc == NO_MORE_DOCS || target <= it.doc {
+		return it.doc
+	}
+	start := max(it.pos, 0)
+	it.pos = start + sort.Search(len(it.docs)-start, func(i int) bool { return it.docs[start+i] >= target })
+	return it.current()
+}
+
+func (it *rangeIterator) current() uint64 {
+	if it.pos < len(it.docs) {
+		it.doc = it.docs[it.pos]
+	} else {
+		it.doc = NO_MORE_DOCS
+	}
+	return it.doc
+}
+
+func (it *rangeIterator) Cost() int64       { return int64(len(it.docs)) }
+func (it *rangeIterator) Id() string        { return it.ids[it.pos] }
+func (it *rangeIterator) Score() float64    { return 0 }
+func (it *rangeIterator) MaxScore() float64 { return 0 }
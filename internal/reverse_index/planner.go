@@ -0,0 +1,202 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math"
+	"sort"
+)
+
+// noMatchQuery 一个文档都不命中的查询节点。跟空节点不同，它在Must里会让整个Must不命中
+func noMatchQuery() *types.TermQuery {
+	return &types.TermQuery{Keyword: noMatchKeyword}
+}
+
+func isNoMatch(q *types.TermQuery) bool {
+	return q != nil && q.Keyword == noMatchKeyword
+}
+
+// PlanQuery 在不改变命中集合的前提下简化查询树，需在ExpandQuery之后调用：
+//   - 嵌套的Must里的Must、Should里的Should拍平成一层，去掉只有一个子节点的Must、Should
+//   - 去掉重复的子节点。重复的子节点只算一次得分
+//   - Must的子节点按倒排链长度从短到长排列，任何一个子节点一定不命中时整个Must直接不命中
+//
+// 倒排链长度通过open打开的叶子迭代器的Cost()取得，调用方需事先对查询里的keyword加读锁。不修改原来的查询，查询为空时返回nil
+func PlanQuery(q *types.TermQuery, open LeafOpener) *types.TermQuery {
+	planner := queryPlanner{open: open, sizes: make(map[string]int64)}
+	return planner.plan(q)
+}
+
+type queryPlanner struct {
+	open  LeafOpener
+	sizes map[string]int64 //keyword的倒排链长度，同一个keyword只打开一次
+}
+
+func (planner queryPlanner) postingSize(keyword *types.Keyword) int64 {
+	key := keyword.ToString()
+	size, exists := planner.sizes[key]
+	if !exists {
+		size = planner.open.OpenKeyword(keyword, false).Cost()
+		planner.sizes[key] = size
+	}
+	return size
+}
+
+// 估计查询节点最多命中多少个文档。Range的命中数不好估计，当成最大值排在Must的最后
+func (planner queryPlanner) estimate(q *types.TermQuery) int64 {
+	if q.Keyword != nil {
+		return planner.postingSize(q.Keyword)
+	}
+	if keywords, _, _ := proximityKeywords(q); len(keywords) > 0 {
+		size := int64(math.MaxInt64)
+		for _, keyword := range keywords {
+			size = min(size, planner.postingSize(keyword))
+		}
+		return size
+	}
+	if q.Range != nil {
+		return math.MaxInt64
+	}
+	if len(q.Must) > 0 {
+		size := int64(math.MaxInt64)
+		for _, child := range q.Must {
+			size = min(size, planner.estimate(child))
+		}
+		return size
+	}
+	var size int64
+	for _, child := range q.Should {
+		if size += planner.estimate(child); size < 0 { //溢出
+			return math.MaxInt64
+		}
+	}
+	return size
+}
+
+// 返回nil表示空节点，返回noMatchQuery()表示一定不命中
+func (planner queryPlanner) plan(q *types.TermQuery) *types.TermQuery {
+	if q == nil || q.Empty() {
+		return nil
+	}
+	mustNot, _ := planner.planChildren(q.MustNot, false)
+	if q.Keyword != nil {
+		if q.Keyword == noMatchKeyword || planner.postingSize(q.Keyword) == 0 {
+			return noMatchQuery()
+		}
+		return &types.TermQuery{Keyword: q.Keyword, Boost: q.Boost, MustNot: mustNot}
+	}
+	if keywords, _, _ := proximityKeywords(q); len(keywords) > 0 {
+		for _, keyword := range keywords {
+			if planner.postingSize(keyword) == 0 {
+				return noMatchQuery()
+			}
+		}
+		return &types.TermQuery{Phrase: q.Phrase, Near: q.Near, MustNot: mustNot}
+	}
+	if q.Range != nil {
+		return &types.TermQuery{Range: q.Range, MustNot: mustNot}
+	}
+	var res *types.TermQuery
+	if len(q.Must) > 0 {
+		must, noMatch := planner.planChildren(q.Must, true)
+		if noMatch {
+			return noMatchQuery()
+		}
+		if len(must) == 0 {
+			return nil
+		}
+		res = &types.TermQuery{}
+		for _, child := range must {
+			if isPureMust(child) { //x且(a且b且非c) 等价于 x且a且b且非c
+				res.Must = append(res.Must, child.Must...)
+				mustNot = append(mustNot, child.MustNot...)
+			} else {
+				res.Must = append(res.Must, child)
+			}
+		}
+		res.Must = dedupQuerys(res.Must)
+		sizes := make(map[*types.TermQuery]int64, len(res.Must))
+		for _, child := range res.Must {
+			sizes[child] = planner.estimate(child)
+		}
+		sort.SliceStable(res.Must, func(i, j int) bool { return sizes[res.Must[i]] < sizes[res.Must[j]] })
+	} else if len(q.Should) > 0 {
+		should, noMatch := planner.planChildren(q.Should, false)
+		if len(should) == 0 {
+			if noMatch {
+				return noMatchQuery()
+			}
+			return nil
+		}
+		res = &types.TermQuery{}
+		for _, child := range should {
+			if len(child.Should) > 0 && len(child.MustNot) == 0 && isPureCombination(child) {
+				res.Should = append(res.Should, child.Should...)
+			} else {
+				res.Should = append(res.Should, child)
+			}
+		}
+		res.Should = dedupQuerys(res.Should)
+	}
+	if res == nil { //只有MustNot，BuildIterator会忽略这种节点
+		return nil
+	}
+	res.MustNot = dedupQuerys(mustNot)
+	if len(res.MustNot) == 0 {
+		if len(res.Must) == 1 {
+			return res.Must[0]
+		}
+		if len(res.Should) == 1 {
+			return res.Should[0]
+		}
+	}
+	return res
+}
+
+// 逐个规划子节点，去掉空节点。inMust为false时一定不命中的子节点也去掉；inMust为true时遇到一定不命中的子节点就停下
+func (planner queryPlanner) planChildren(querys []*types.TermQuery, inMust bool) (children []*types.TermQuery, noMatch bool) {
+	children = make([]*types.TermQuery, 0, len(querys))
+	for _, q := range querys {
+		child := planner.plan(q)
+		if child == nil {
+			continue
+		}
+		if isNoMatch(child) {
+			noMatch = true
+			if inMust {
+				return nil, true
+			}
+			continue
+		}
+		children = append(children, child)
+	}
+	return
+}
+
+// 节点只由Must或Should组合而成，不是叶子节点
+func isPureCombination(q *types.TermQuery) bool {
+	if keywords, _, _ := proximityKeywords(q); len(keywords) > 0 {
+		return false
+	}
+	return q.Keyword == nil && q.Range == nil
+}
+
+func isPureMust(q *types.TermQuery) bool {
+	return len(q.Must) > 0 && isPureCombination(q)
+}
+
+// 去掉重复的查询，保留第一次出现的位置
+func dedupQuerys(querys []*types.TermQuery) []*types.TermQuery {
+	if len(querys) < 2 {
+		return querys
+	}
+	seen := make(map[string]bool, len(querys))
+	res := make([]*types.TermQuery, 0, len(querys))
+	for _, q := range querys {
+		key := q.ToString()
+		if !seen[key] {
+			seen[key] = true
+			res = append(res, q)
+		}
+	}
+	return res
+}
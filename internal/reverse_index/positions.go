@@ -0,0 +1,175 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"slices"
+	"sort"
+)
+
+// PositionalIterator 能给出keyword在当前文档中出现位置的迭代器。叶子迭代器都实现了这个接口
+type PositionalIterator interface {
+	PostingIterator
+	Positions() []int32 //keyword在当前文档中出现的位置，从小到大排列
+}
+
+func (it *emptyIterator) Positions() []int32 { return nil }
+
+// keywordPositions 统计文档中每个keyword出现的位置，key是Keyword.ToString()。
+// 位置是keyword在同一个field的所有keyword里的序号，所以doc.Keywords需要按分词结果的原始顺序排列。出现的次数就是词频
+func keywordPositions(doc types.Document) map[string][]int32 {
+	positions := make(map[string][]int32, len(doc.Keywords))
+	fieldLens := make(map[string]int32, 2)
+	for _, keyword := range doc.Keywords {
+		key := keyword.ToString()
+		positions[key] = append(positions[key], fieldLens[keyword.Field])
+		fieldLens[keyword.Field]++
+	}
+	return positions
+}
+
+// matchProximity 检查各keyword的位置是否满足短语(ordered=true，第i个keyword紧跟在第i-1个后面)或邻近(所有keyword落在distance以内)的要求
+func matchProximity(positions [][]int32, ordered bool, distance int32) bool {
+	if ordered {
+		return matchPhrase(positions)
+	}
+	return matchNear(positions, distance)
+}
+
+func matchPhrase(positions [][]int32) bool {
+	for _, start := range positions[0] {
+		matched := true
+		for i := 1; i < len(positions); i++ {
+			target := start + int32(i)
+			j := sort.Search(len(positions[i]), func(j int) bool { return positions[i][j] >= target })
+			if j == len(positions[i]) || positions[i][j] != target {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// 每个keyword取一个位置组成窗口，看窗口宽度能否不超过distance。
+// 同一个keyword在查询里出现多次时，每次出现都要在窗口里占一个不同的位置，所以先按keyword分组，记下每组需要的位置个数
+func matchNear(positions [][]int32, distance int32) bool {
+	groups := make([][]int32, 0, len(positions))
+	need := make([]int, 0, len(positions))
+	for _, pos := range positions {
+		g := slices.IndexFunc(groups, func(other []int32) bool { return slices.Equal(other, pos) })
+		if g < 0 {
+			groups = append(groups, pos)
+			need = append(need, 0)
+			g = len(groups) - 1
+		}
+		need[g]++
+	}
+
+	//同一个field里不同keyword的位置互不相同，归并成一个有序序列后滑动窗口
+	type occurrence struct {
+		pos   int32
+		group int
+	}
+	occurrences := make([]occurrence, 0, 16)
+	for g, pos := range groups {
+		for _, p := range pos {
+			occurrences = append(occurrences, occurrence{pos: p, group: g})
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].pos < occurrences[j].pos })
+
+	counts := make([]int, len(groups))
+	satisfied := 0 //窗口里位置个数已够的组数
+	lo := 0
+	for _, occ := range occurrences {
+		counts[occ.group]++
+		if counts[occ.group] == need[occ.group] {
+			satisfied++
+		}
+		for occ.pos-occurrences[lo].pos > distance {
+			g := occurrences[lo].group
+			if counts[g] == need[g] {
+				satisfied--
+			}
+			counts[g]--
+			lo++
+		}
+		if satisfied == len(groups) {
+			return true
+		}
+	}
+	return false
+}
+
+// proximityIterator 短语和邻近查询。先对各keyword求交集，再检查它们在文档中的位置，得分跟交集相同
+type proximityIterator struct {
+	*conjunctionIterator
+	terms     []PositionalIterator //保持查询里的原始顺序
+	positions [][]int32
+	ordered   bool
+	distance  int32
+}
+
+func newProximityIterator(terms []PositionalIterator, ordered bool, distance int32) *proximityIterator {
+	children := make([]PostingIterator, 0, len(terms))
+	for _, term := range terms {
+		children = append(children, term)
+	}
+	return &proximityIterator{
+		conjunctionIterator: newConjunctionIterator(children),
+		terms:               terms,
+		positions:           make([][]int32, len(terms)),
+		ordered:             ordered,
+		distance:            distance,
+	}
+}
+
+func (it *proximityIterator) Next() uint64 {
+	return it.checkPositions(it.conjunctionIterator.Next())
+}
+
+func (it *proximityIterator) Advance(target uint64) uint64 {
+	return it.checkPositions(it.conjunctionIterator.Advance(target))
+}
+
+func (it *proximityIterator) checkPositions(doc uint64) uint64 {
+	for ; doc != NO_MORE_DOCS; doc = it.conjunctionIterator.Next() {
+		for i, term := range it.terms {
+			it.positions[i] = term.Positions()
+		}
+		if matchProximity(it.positions, it.ordered, it.distance) {
+			break
+		}
+	}
+	return doc
+}
+
+// 把短语或邻近查询翻译成迭代器。叶子迭代器给不出位置时，当作一个文档都没有
+func buildProximityIterator(keywords []*types.Keyword, ordered bool, distance int32, open LeafOpener, filtered bool) PostingIterator {
+	terms := make([]PositionalIterator, 0, len(keywords))
+	for _, keyword := range keywords {
+		term, ok := open.OpenKeyword(keyword, filtered).(PositionalIterator)
+		if !ok {
+			return &emptyIterator{}
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return newProximityIterator(terms, ordered, distance)
+}
+
+// proximityKeywords 查询节点如果是短语或邻近查询，返回其中的keyword
+func proximityKeywords(q *types.TermQuery) (keywords []*types.Keyword, ordered bool, distance int32) {
+	if len(q.Phrase) > 0 {
+		return q.Phrase, true, 0
+	}
+	if q.Near != nil && len(q.Near.Keywords) > 0 {
+		return q.Near.Keywords, false, int32(q.Near.Distance)
+	}
+	return nil, false, 0
+}
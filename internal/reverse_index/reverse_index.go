@@ -2,8 +2,63 @@ package reverse_index
 
 import "github.com/Muoshu/myRadic/types"
 
+// SearchHit 倒排索引上的一条检索结果
+type SearchHit struct {
+	Id    string  //业务Id
+	IntId uint64  //倒排索引上使用的文档id
+	Score float64 //BM25相关性得分
+}
+
 type IReverseIndexer interface {
 	Add(doc types.Document)
 	Delete(IntId uint64, keywords *types.Keyword)
-	Search(q *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []string
+	AddBatch(docs []types.Document)                                                                         //批量添加，同一个key只加一次锁
+	DeleteBatch(docs []*types.Document)                                                                     //删除这些文档在各自Keywords上的posting，同一个key只加一次锁
+	Update(old *types.Document, doc types.Document)                                                         //old和doc的IntId相同，只改动有变化的posting
+	Search(q *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit                 //按得分从高到低排序
+	SearchTopK(q *types.TermQuery, k int, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit      //只返回得分最高的k个
+	Count(q *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int                          //命中的文档数，跟Search结果的长度相同
+	Explain(q *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64, intId uint64) *Explanation //逐个节点统计文档数和耗时，intId大于0时给出该文档在每个节点上的得分
+	Terms(field string, prefix string, limit int) []string                                                  //field里以prefix开头的word，按字典序排列，用于自动补全
+	Expand(q *types.TermQuery) *types.TermQuery                                                             //把前缀、通配符和模糊查询展开成字典里的keyword，跟检索时的展开结果相同
+	Numeric(intId uint64, field string) (int64, bool)                                                       //文档的数值字段，按字段排序时用
+	Facets(q *types.TermQuery, field string, onFlag uint64, offFlag uint64, orFlags []uint64) *Facets       //按BitsFeature的每个bit和field的每个word统计命中的文档数
+}
+
+// IPersistentReverseIndexer 能把倒排索引持久化到磁盘的实现。系统重启时直接从磁盘加载，不需要再从正排索引重建
+type IPersistentReverseIndexer interface {
+	IReverseIndexer
+	Loaded() bool             //是否从磁盘加载到了数据。为false时需要从正排索引重建
+	MaxIntId() uint64         //索引里最大的IntId
+	DocCount() int            //索引里的文档数
+	HasDoc(intId uint64) bool //文档是否在索引上。重放预写日志时用来判断文档是否已经写入
+	Flush() error             //把内存中的数据落盘
+	Close() error             //等后台任务结束，再把内存中的数据落盘
+}
+
+// 倒排索引的几种实现
+const (
+	SKIPLIST = iota //倒排链是跳表
+	ROARING         //倒排链是压缩位图
+	SEGMENT         //倒排链分段存储在磁盘上
+)
+
+// GetReverseIndexer 工厂模式，根据indexType创建具体的倒排索引。dir是持久化的倒排索引存放数据的目录，纯内存的实现用不到它。
+// withWal表示调用方有预写日志，持久化的实现在上次没有正常关闭时保留已落盘的数据，等调用方重放日志
+func GetReverseIndexer(indexType int, docNum int, dir string, withWal bool) (IReverseIndexer, error) {
+	switch indexType {
+	case ROARING:
+		return NewRoaringReverseIndex(docNum), nil
+	case SEGMENT:
+		indexer := NewSegmentReverseIndex(docNum).WithDataDir(dir)
+		if withWal {
+			indexer.WithWal()
+		}
+		if err := indexer.Open(); err != nil {
+			return nil, err
+		}
+		return indexer, nil
+	default: //默认使用跳表
+		return NewSkipListReverseIndex(docNum), nil
+	}
 }
@@ -0,0 +1,441 @@
+package reverse_index
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Roaring Bitmap：把uint64按高48位分桶，每个桶(container)存放低16位。
+// 桶里元素少时用有序的[]uint16存(array容器)，元素多时用65536个bit存(bitmap容器)，求交并差时bitmap容器之间按64位的字并行计算
+const (
+	arrayMaxSize = 4096       //array容器最多存多少个元素，超过就转成bitmap容器
+	bitmapWords  = 65536 / 64 //bitmap容器由多少个uint64组成
+)
+
+type container struct {
+	array  []uint16 //bitmap为nil时使用
+	bitmap []uint64
+	card   int //元素个数
+}
+
+func newArrayContainer(capacity int) *container {
+	return &container{array: make([]uint16, 0, capacity)}
+}
+
+func newBitmapContainer() *container {
+	return &container{bitmap: make([]uint64, bitmapWords)}
+}
+
+func (c *container) isBitmap() bool {
+	return c.bitmap != nil
+}
+
+// array中第一个>=low的元素的下标
+func (c *container) lowerBound(low uint16) int {
+	return sort.Search(len(c.array), func(i int) bool { return c.array[i] >= low })
+}
+
+func (c *container) contains(low uint16) bool {
+	if c.isBitmap() {
+		return c.bitmap[low>>6]&(1<<(low&63)) != 0
+	}
+	i := c.lowerBound(low)
+	return i < len(c.array) && c.array[i] == low
+}
+
+// 添加元素，返回之前是否不存在
+func (c *container) add(low uint16) bool {
+	if c.isBitmap() {
+		mask := uint64(1) << (low & 63)
+		if c.bitmap[low>>6]&mask != 0 {
+			return false
+		}
+		c.bitmap[low>>6] |= mask
+		c.card++
+		return true
+	}
+	i := c.lowerBound(low)
+	if i < len(c.array) && c.array[i] == low {
+		return false
+	}
+	c.array = append(c.array, 0)
+	copy(c.array[i+1:], c.array[i:])
+	c.array[i] = low
+	c.card++
+	if c.card > arrayMaxSize {
+		c.toBitmap()
+	}
+	return true
+}
+
+// 删除元素，返回之前是否存在
+func (c *container) remove(low uint16) bool {
+	if c.isBitmap() {
+		mask := uint64(1) << (low & 63)
+		if c.bitmap[low>>6]&mask == 0 {
+			return false
+		}
+		c.bitmap[low>>6] &^= mask
+		c.card--
+		if c.card <= arrayMaxSize {
+			c.toArray()
+		}
+		return true
+	}
+	i := c.lowerBound(low)
+	if i >= len(c.array) || c.array[i] != low {
+		return false
+	}
+	c.array = append(c.array[:i], c.array[i+1:]...)
+	c.card--
+	return true
+}
+
+func (c *container) toBitmap() {
+	bitmap := make([]uint64, bitmapWords)
+	for _, v := range c.array {
+		bitmap[v>>6] |= 1 << (v & 63)
+	}
+	c.bitmap = bitmap
+	c.array = nil
+}
+
+func (c *container) toArray() {
+	array := make([]uint16, 0, c.card)
+	for i, word := range c.bitmap {
+		for word != 0 {
+			t := bits.TrailingZeros64(word)
+			array = append(array, uint16(i*64+t))
+			word &= word - 1
+		}
+	}
+	c.array = array
+	c.bitmap = nil
+}
+
+// 根据元素个数选择合适的存储方式
+func (c *container) normalize() *container {
+	if c.isBitmap() && c.card <= arrayMaxSize {
+		c.toArray()
+	} else if !c.isBitmap() && c.card > arrayMaxSize {
+		c.toBitmap()
+	}
+	return c
+}
+
+// 从pos开始找下一个元素。array容器的pos是下标，bitmap容器的pos是低16位的取值。返回元素和它对应的pos
+func (c *container) next(pos int) (uint16, int, bool) {
+	if !c.isBitmap() {
+		if pos < len(c.array) {
+			return c.array[pos], pos, true
+		}
+		return 0, pos, false
+	}
+	for i := pos >> 6; i < bitmapWords; i++ {
+		word := c.bitmap[i]
+		if i == pos>>6 {
+			word &= ^uint64(0) << (pos & 63)
+		}
+		if word != 0 {
+			v := i*64 + bits.TrailingZeros64(word)
+			return uint16(v), v, true
+		}
+	}
+	return 0, pos, false
+}
+
+// 第一个>=low的元素对应的pos
+func (c *container) seekPos(low uint16) int {
+	if c.isBitmap() {
+		return int(low)
+	}
+	return c.lowerBound(low)
+}
+
+func (c *container) and(o *container) *container {
+	switch {
+	case c.isBitmap() && o.isBitmap():
+		res := newBitmapContainer()
+		for i := range res.bitmap {
+			res.bitmap[i] = c.bitmap[i] & o.bitmap[i]
+			res.card += bits.OnesCount64(res.bitmap[i])
+		}
+		return res.normalize()
+	case c.isBitmap():
+		return o.and(c)
+	case o.isBitmap():
+		res := newArrayContainer(len(c.array))
+		for _, v := range c.array {
+			if o.contains(v) {
+				res.array = append(res.array, v)
+			}
+		}
+		res.card = len(res.array)
+		return res
+	default:
+		res := newArrayContainer(min(len(c.array), len(o.array)))
+		i, j := 0, 0
+		for i < len(c.array) && j < len(o.array) {
+			if c.array[i] < o.array[j] {
+				i++
+			} else if c.array[i] > o.array[j] {
+				j++
+			} else {
+				res.array = append(res.array, c.array[i])
+				i++
+				j++
+			}
+		}
+		res.card = len(res.array)
+		return res
+	}
+}
+
+func (c *container) or(o *container) *container {
+	switch {
+	case c.isBitmap() && o.isBitmap():
+		res := newBitmapContainer()
+		for i := range res.bitmap {
+			res.bitmap[i] = c.bitmap[i] | o.bitmap[i]
+			res.card += bits.OnesCount64(res.bitmap[i])
+		}
+		return res
+	case c.isBitmap():
+		return o.or(c)
+	case o.isBitmap():
+		res := newBitmapContainer()
+		copy(res.bitmap, o.bitmap)
+		res.card = o.card
+		for _, v := range c.array {
+			if res.bitmap[v>>6]&(1<<(v&63)) == 0 {
+				res.bitmap[v>>6] |= 1 << (v & 63)
+				res.card++
+			}
+		}
+		return res
+	default:
+		res := newArrayContainer(len(c.array) + len(o.array))
+		i, j := 0, 0
+		for i < len(c.array) || j < len(o.array) {
+			if j >= len(o.array) || (i < len(c.array) && c.array[i] < o.array[j]) {
+				res.array = append(res.array, c.array[i])
+				i++
+			} else if i >= len(c.array) || c.array[i] > o.array[j] {
+				res.array = append(res.array, o.array[j])
+				j++
+			} else {
+				res.array = append(res.array, c.array[i])
+				i++
+				j++
+			}
+		}
+		res.card = len(res.array)
+		return res.normalize()
+	}
+}
+
+func (c *container) andNot(o *container) *container {
+	switch {
+	case c.isBitmap() && o.isBitmap():
+		res := newBitmapContainer()
+		for i := range res.bitmap {
+			res.bitmap[i] = c.bitmap[i] &^ o.bitmap[i]
+			res.card += bits.OnesCount64(res.bitmap[i])
+		}
+		return res.normalize()
+	case c.isBitmap():
+		res := newBitmapContainer()
+		copy(res.bitmap, c.bitmap)
+		res.card = c.card
+		for _, v := range o.array {
+			if res.bitmap[v>>6]&(1<<(v&63)) != 0 {
+				res.bitmap[v>>6] &^= 1 << (v & 63)
+				res.card--
+			}
+		}
+		return res.normalize()
+	default:
+		res := newArrayContainer(len(c.array))
+		for _, v := range c.array {
+			if !o.contains(v) {
+				res.array = append(res.array, v)
+			}
+		}
+		res.card = len(res.array)
+		return res
+	}
+}
+
+// Bitmap 压缩位图，存放一组不重复的uint64。不是并发安全的
+type Bitmap struct {
+	keys       []uint64 //每个container对应的高48位，从小到大排列
+	containers []*container
+}
+
+func NewBitmap() *Bitmap {
+	return new(Bitmap)
+}
+
+// 高48位为key的container的下标，以及它是否存在
+func (b *Bitmap) findContainer(key uint64) (int, bool) {
+	i := sort.Search(len(b.keys), func(i int) bool { return b.keys[i] >= key })
+	return i, i < len(b.keys) && b.keys[i] == key
+}
+
+// Add 添加元素，返回之前是否不存在
+func (b *Bitmap) Add(x uint64) bool {
+	key, low := x>>16, uint16(x)
+	i, ok := b.findContainer(key)
+	if !ok {
+		b.keys = append(b.keys, 0)
+		copy(b.keys[i+1:], b.keys[i:])
+		b.keys[i] = key
+		b.containers = append(b.containers, nil)
+		copy(b.containers[i+1:], b.containers[i:])
+		b.containers[i] = newArrayContainer(1)
+	}
+	return b.containers[i].add(low)
+}
+
+// Remove 删除元素，返回之前是否存在
+func (b *Bitmap) Remove(x uint64) bool {
+	key, low := x>>16, uint16(x)
+	i, ok := b.findContainer(key)
+	if !ok || !b.containers[i].remove(low) {
+		return false
+	}
+	if b.containers[i].card == 0 {
+		b.keys = append(b.keys[:i], b.keys[i+1:]...)
+		b.containers = append(b.containers[:i], b.containers[i+1:]...)
+	}
+	return true
+}
+
+func (b *Bitmap) Contains(x uint64) bool {
+	i, ok := b.findContainer(x >> 16)
+	return ok && b.containers[i].contains(uint16(x))
+}
+
+// Cardinality 元素个数
+func (b *Bitmap) Cardinality() int {
+	n := 0
+	for _, c := range b.containers {
+		n += c.card
+	}
+	return n
+}
+
+// And 交集，返回新的Bitmap
+func (b *Bitmap) And(o *Bitmap) *Bitmap {
+	res := NewBitmap()
+	i, j := 0, 0
+	for i < len(b.keys) && j < len(o.keys) {
+		if b.keys[i] < o.keys[j] {
+			i++
+		} else if b.keys[i] > o.keys[j] {
+			j++
+		} else {
+			if c := b.containers[i].and(o.containers[j]); c.card > 0 {
+				res.keys = append(res.keys, b.keys[i])
+				res.containers = append(res.containers, c)
+			}
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// Or 并集，返回新的Bitmap
+func (b *Bitmap) Or(o *Bitmap) *Bitmap {
+	res := NewBitmap()
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(o.keys) {
+		if j >= len(o.keys) || (i < len(b.keys) && b.keys[i] < o.keys[j]) {
+			res.keys = append(res.keys, b.keys[i])
+			res.containers = append(res.containers, b.containers[i].or(newArrayContainer(0)))
+			i++
+		} else if i >= len(b.keys) || b.keys[i] > o.keys[j] {
+			res.keys = append(res.keys, o.keys[j])
+			res.containers = append(res.containers, o.containers[j].or(newArrayContainer(0)))
+			j++
+		} else {
+			res.keys = append(res.keys, b.keys[i])
+			res.containers = append(res.containers, b.containers[i].or(o.containers[j]))
+			i++
+			j++
+		}
+	}
+	return res
+}
+
+// AndNot 差集，返回在b里但不在o里的元素
+func (b *Bitmap) AndNot(o *Bitmap) *Bitmap {
+	res := NewBitmap()
+	j := 0
+	for i, key := range b.keys {
+		for j < len(o.keys) && o.keys[j] < key {
+			j++
+		}
+		var c *container
+		if j < len(o.keys) && o.keys[j] == key {
+			c = b.containers[i].andNot(o.containers[j])
+		} else {
+			c = b.containers[i].or(newArrayContainer(0))
+		}
+		if c.card > 0 {
+			res.keys = append(res.keys, key)
+			res.containers = append(res.containers, c)
+		}
+	}
+	return res
+}
+
+// ToArray 按从小到大的顺序返回全部元素
+func (b *Bitmap) ToArray() []uint64 {
+	arr := make([]uint64, 0, b.Cardinality())
+	it := b.Iterator()
+	for x, ok := it.Next(); ok; x, ok = it.Next() {
+		arr = append(arr, x)
+	}
+	return arr
+}
+
+func (b *Bitmap) Iterator() *BitmapIterator {
+	return &BitmapIterator{b: b}
+}
+
+// BitmapIterator 按从小到大的顺序遍历Bitmap
+type BitmapIterator struct {
+	b   *Bitmap
+	ci  int //当前container的下标
+	pos int //下一个候选元素在当前container里的pos
+}
+
+// Next 返回下一个元素，没有了返回false
+func (it *BitmapIterator) Next() (uint64, bool) {
+	for it.ci < len(it.b.containers) {
+		if low, pos, ok := it.b.containers[it.ci].next(it.pos); ok {
+			it.pos = pos + 1
+			return it.b.keys[it.ci]<<16 | uint64(low), true
+		}
+		it.ci++
+		it.pos = 0
+	}
+	return 0, false
+}
+
+// Advance 返回第一个>=target的元素。不会往回走，target比上次返回的元素还小时等价于Next
+func (it *BitmapIterator) Advance(target uint64) (uint64, bool) {
+	key := target >> 16
+	if it.ci < len(it.b.keys) && it.b.keys[it.ci] < key {
+		//二分查找第一个key>=target高48位的container
+		offset := sort.Search(len(it.b.keys)-it.ci, func(i int) bool { return it.b.keys[it.ci+i] >= key })
+		it.ci += offset
+		it.pos = 0
+	}
+	if it.ci < len(it.b.keys) && it.b.keys[it.ci] == key {
+		it.pos = max(it.pos, it.b.containers[it.ci].seekPos(uint16(target)))
+	}
+	return it.Next()
+}
@@ -0,0 +1,566 @@
+package reverse_index
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+	"runtime"
+	"sync"
+)
+
+// roaringPosting 一个keyword的倒排链
+type roaringPosting struct {
+	bitmap    *Bitmap
+	positions map[uint64][]int32 //keyword在各文档中出现的位置，个数即词频
+}
+
+func (posting *roaringPosting) termFreq(intId uint64) int {
+	return len(posting.positions[intId])
+}
+
+// roaringDoc 文档表里的一个元素
+type roaringDoc struct {
+	id   string //业务Id
+	bits uint64 //BitsFeature
+}
+
+// RoaringReverseIndex 倒排链用压缩位图存储，key是keyword，位图里存放IntId。
+// 业务Id和BitsFeature不放在倒排链上，而是放在以IntId为key的文档表里，所有keyword共享一份。
+// IntId只增不减、删除后也不复用，所以用map而不是以IntId为下标的数组，删掉的文档不占空间
+type RoaringReverseIndex struct {
+	table    *util.ConcurrentHashMap //分段map，并发安全
+	locks    keyLocks                //修改倒排索引时，相同的key需要去竞争同一把锁
+	stats    *DocStats               //BM25打分需要的全局统计量
+	dict     *TermDict               //倒排链非空的key，前缀、通配符展开用
+	numerics *NumericStore           //文档的数值字段，Range查询用
+	docLock  sync.RWMutex
+	docs     map[uint64]roaringDoc //IntId -> 文档，所有posting都删掉后移除
+}
+
+func NewRoaringReverseIndex(docNum int) *RoaringReverseIndex {
+	indexer := new(RoaringReverseIndex)
+	indexer.table = util.NewConcurrentHashMap(runtime.NumCPU(), docNum)
+	indexer.locks = make(keyLocks, 1000)
+	indexer.stats = NewDocStats(docNum)
+	indexer.dict = NewTermDict()
+	indexer.numerics = NewNumericStore(docNum)
+	indexer.docs = make(map[uint64]roaringDoc, docNum)
+	return indexer
+}
+
+func (indexer *RoaringReverseIndex) setDoc(intId uint64, id string, bits uint64) {
+	indexer.docLock.Lock()
+	defer indexer.docLock.Unlock()
+	indexer.docs[intId] = roaringDoc{id: id, bits: bits}
+}
+
+// 调用方需要持有docLock的读锁。不存在时返回零值
+func (indexer *RoaringReverseIndex) getDoc(intId uint64) roaringDoc {
+	return indexer.docs[intId]
+}
+
+func (indexer *RoaringReverseIndex) Add(doc types.Document) {
+	indexer.AddBatch([]types.Document{doc})
+}
+
+// AddBatch 同一个keyword在文档中可能出现多次，合并成一条posting，出现次数即词频
+func (indexer *RoaringReverseIndex) AddBatch(docs []types.Document) {
+	groups := groupPostings(docs, func(doc *types.Document, termPositions map[string][]int32) {
+		indexer.stats.Add(doc.IntId, len(doc.Keywords), len(termPositions), doc.BitsFeature)
+		indexer.setDoc(doc.IntId, doc.Id, doc.BitsFeature) //不能在持有keyword锁的时候加docLock，否则可能跟Search死锁
+		indexer.numerics.Add(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+	})
+	for key, postings := range groups {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		var posting *roaringPosting
+		if val, ok := indexer.table.Get(key); ok {
+			posting = val.(*roaringPosting)
+		} else {
+			posting = &roaringPosting{bitmap: NewBitmap(), positions: make(map[uint64][]int32)}
+			indexer.table.Set(key, posting)
+		}
+		for _, p := range postings {
+			if posting.bitmap.Add(p.doc.IntId) && posting.bitmap.Cardinality() == 1 {
+				indexer.dict.Add(key)
+			}
+			posting.positions[p.doc.IntId] = p.positions
+		}
+		lock.Unlock()
+	}
+}
+
+func (indexer *RoaringReverseIndex) Delete(IntId uint64, keyword *types.Keyword) {
+	indexer.DeleteBatch([]*types.Document{{IntId: IntId, Keywords: []*types.Keyword{keyword}}})
+}
+
+func (indexer *RoaringReverseIndex) DeleteBatch(docs []*types.Document) {
+	var removed []uint64 //所有posting都删掉了的文档
+	for key, intIds := range groupDeletes(docs) {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		if val, ok := indexer.table.Get(key); ok {
+			posting := val.(*roaringPosting)
+			for _, intId := range intIds {
+				if posting.bitmap.Remove(intId) {
+					delete(posting.positions, intId)
+					if indexer.stats.RemovePosting(intId) {
+						removed = append(removed, intId)
+					}
+					if posting.bitmap.Cardinality() == 0 {
+						indexer.dict.Remove(key)
+					}
+				}
+			}
+		}
+		lock.Unlock()
+	}
+	for _, intId := range removed {
+		indexer.numerics.Remove(intId)
+	}
+	if len(removed) > 0 { //已经释放了keyword锁，可以加docLock
+		indexer.docLock.Lock()
+		for _, intId := range removed {
+			delete(indexer.docs, intId)
+		}
+		indexer.docLock.Unlock()
+	}
+}
+
+// Update 文档的IntId不变，只改动受影响的posting。BitsFeature在文档表里，直接替换
+func (indexer *RoaringReverseIndex) Update(old *types.Document, doc types.Document) {
+	oldPositions, newPositions := keywordPositions(*old), keywordPositions(doc)
+	if len(oldPositions) == 0 || len(newPositions) == 0 { //没有keyword的文档不在倒排索引上
+		indexer.DeleteBatch([]*types.Document{old})
+		indexer.AddBatch([]types.Document{doc})
+		return
+	}
+	removed, changed := diffPostings(oldPositions, newPositions, false)
+	indexer.stats.Add(doc.IntId, len(doc.Keywords), len(newPositions), doc.BitsFeature)
+	indexer.setDoc(doc.IntId, doc.Id, doc.BitsFeature)
+	indexer.numerics.Update(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+	for _, key := range removed {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		if val, ok := indexer.table.Get(key); ok {
+			posting := val.(*roaringPosting)
+			if posting.bitmap.Remove(doc.IntId) {
+				delete(posting.positions, doc.IntId)
+				if posting.bitmap.Cardinality() == 0 {
+					indexer.dict.Remove(key)
+				}
+			}
+		}
+		lock.Unlock()
+	}
+	for key, positions := range changed {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		var posting *roaringPosting
+		if val, ok := indexer.table.Get(key); ok {
+			posting = val.(*roaringPosting)
+		} else {
+			posting = &roaringPosting{bitmap: NewBitmap(), positions: make(map[uint64][]int32)}
+			indexer.table.Set(key, posting)
+		}
+		if posting.bitmap.Add(doc.IntId) && posting.bitmap.Cardinality() == 1 {
+			indexer.dict.Add(key)
+		}
+		posting.positions[doc.IntId] = positions
+		lock.Unlock()
+	}
+}
+
+// 调用方需要事先对keyword加读锁
+func (indexer *RoaringReverseIndex) getPosting(keyword *types.Keyword) *roaringPosting {
+	if val, ok := indexer.table.Get(keyword.ToString()); ok {
+		return val.(*roaringPosting)
+	}
+	return nil
+}
+
+// 用位图的交、并、差运算求出满足查询的文档集合，语义跟BuildIterator一致。查询为空时返回nil
+func (indexer *RoaringReverseIndex) evaluate(q *types.TermQuery) *Bitmap {
+	if q == nil {
+		return nil
+	}
+	var res *Bitmap
+	if q.Keyword != nil {
+		if posting := indexer.getPosting(q.Keyword); posting != nil {
+			res = posting.bitmap
+		} else {
+			res = NewBitmap()
+		}
+	} else if keywords, ordered, distance := proximityKeywords(q); len(keywords) > 0 {
+		res = indexer.evaluateProximity(keywords, ordered, distance)
+	} else if q.Range != nil {
+		res = indexer.numerics.Range(q.Range)
+	} else if len(q.Must) > 0 {
+		res = indexer.combine(q.Must, (*Bitmap).And)
+	} else if len(q.Should) > 0 {
+		res = indexer.combine(q.Should, (*Bitmap).Or)
+	}
+	if res != nil && len(q.MustNot) > 0 {
+		if excluded := indexer.combine(q.MustNot, (*Bitmap).Or); excluded != nil {
+			res = res.AndNot(excluded)
+		}
+	}
+	return res
+}
+
+// 短语和邻近查询先对各keyword的位图求交集，再逐个文档检查位置
+func (indexer *RoaringReverseIndex) evaluateProximity(keywords []*types.Keyword, ordered bool, distance int32) *Bitmap {
+	postings := make([]*roaringPosting, 0, len(keywords))
+	var candidates *Bitmap
+	for _, keyword := range keywords {
+		posting := indexer.getPosting(keyword)
+		if posting == nil {
+			return NewBitmap()
+		}
+		postings = append(postings, posting)
+		if candidates == nil {
+			candidates = posting.bitmap
+		} else {
+			candidates = candidates.And(posting.bitmap)
+		}
+	}
+	if len(postings) == 1 {
+		return candidates
+	}
+	res := NewBitmap()
+	positions := make([][]int32, len(postings))
+	it := candidates.Iterator()
+	for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+		for i, posting := range postings {
+			positions[i] = posting.positions[intId]
+		}
+		if matchProximity(positions, ordered, distance) {
+			res.Add(intId)
+		}
+	}
+	return res
+}
+
+// 子查询为空的直接忽略，跟combineIterators保持一致
+func (indexer *RoaringReverseIndex) combine(querys []*types.TermQuery, op func(*Bitmap, *Bitmap) *Bitmap) *Bitmap {
+	var res *Bitmap
+	for _, q := range querys {
+		if child := indexer.evaluate(q); child != nil {
+			if res == nil {
+				res = child
+			} else {
+				res = op(res, child)
+			}
+		}
+	}
+	return res
+}
+
+// roaringScorer 对位图运算得出的文档逐个打分
+type roaringScorer struct {
+	indexer   *RoaringReverseIndex
+	postings  map[*types.Keyword]*roaringPosting
+	idfs      map[*types.Keyword]float64
+	avgDocLen float64
+}
+
+// 计算文档在查询树上的得分，返回得分、是否命中、节点是否为空(为空的节点没有迭代器，跟combineIterators一样直接忽略)。
+// 各节点的命中规则和得分的累加顺序都跟迭代器保持一致，保证浮点数结果完全相同
+func (scorer *roaringScorer) score(q *types.TermQuery, intId uint64) (float64, bool, bool) {
+	if q == nil {
+		return 0, false, true
+	}
+	var score float64
+	var match bool
+	empty := true
+	if q.Keyword != nil {
+		empty = false
+		if posting := scorer.postings[q.Keyword]; posting != nil && posting.bitmap.Contains(intId) {
+			score = BM25TermScore(scorer.idfs[q.Keyword], posting.termFreq(intId), scorer.indexer.stats.DocLen(intId), scorer.avgDocLen)
+			if boost := leafBoost(q); boost != 1 {
+				score *= boost
+			}
+			match = true
+		}
+	} else if keywords, ordered, distance := proximityKeywords(q); len(keywords) > 0 {
+		empty = false
+		score, match = scorer.scoreProximity(keywords, ordered, distance, intId)
+	} else if q.Range != nil {
+		empty = false
+		match = scorer.indexer.numerics.Match(q.Range, intId) //只过滤不打分
+	} else if len(q.Must) > 0 {
+		match = true
+		for _, child := range q.Must {
+			childScore, childMatch, childEmpty := scorer.score(child, intId)
+			if childEmpty {
+				continue
+			}
+			empty = false
+			if !childMatch {
+				match = false
+				break
+			}
+			score += childScore
+		}
+	} else if len(q.Should) > 0 {
+		for _, child := range q.Should {
+			childScore, childMatch, childEmpty := scorer.score(child, intId)
+			if childEmpty {
+				continue
+			}
+			empty = false
+			if childMatch {
+				score += childScore
+				match = true
+			}
+		}
+	}
+	if empty {
+		return 0, false, true
+	}
+	if match {
+		for _, child := range q.MustNot {
+			if _, childMatch, _ := scorer.score(child, intId); childMatch {
+				return 0, false, false
+			}
+		}
+	}
+	return score, match, false
+}
+
+// 短语和邻近查询的得分是各keyword得分之和，跟proximityIterator一致
+func (scorer *roaringScorer) scoreProximity(keywords []*types.Keyword, ordered bool, distance int32, intId uint64) (float64, bool) {
+	var score float64
+	positions := make([][]int32, 0, len(keywords))
+	for _, keyword := range keywords {
+		posting := scorer.postings[keyword]
+		if posting == nil || !posting.bitmap.Contains(intId) {
+			return 0, false
+		}
+		score += BM25TermScore(scorer.idfs[keyword], posting.termFreq(intId), scorer.indexer.stats.DocLen(intId), scorer.avgDocLen)
+		positions = append(positions, posting.positions[intId])
+	}
+	if len(positions) > 1 && !matchProximity(positions, ordered, distance) {
+		return 0, false
+	}
+	return score, true
+}
+
+// Search 先用位图的字并行运算求出结果集合，再逐个文档按bits过滤、打分。结果跟SkipListReverseIndex完全相同
+func (indexer *RoaringReverseIndex) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	query = PlanQuery(query, indexer.leafOpener(0, 0, nil))
+	result := indexer.evaluate(query)
+	if result == nil {
+		return nil
+	}
+	keywords := QueryKeywords(query)
+	scorer := &roaringScorer{
+		indexer:   indexer,
+		postings:  make(map[*types.Keyword]*roaringPosting, len(keywords)),
+		idfs:      make(map[*types.Keyword]float64, len(keywords)),
+		avgDocLen: indexer.stats.AvgDocLen(),
+	}
+	docCount := indexer.stats.DocCount()
+	for _, keyword := range keywords {
+		if posting := indexer.getPosting(keyword); posting != nil {
+			scorer.postings[keyword] = posting
+			scorer.idfs[keyword] = BM25Idf(docCount, posting.bitmap.Cardinality()) //位图的元素个数就是包含该keyword的文档数
+		}
+	}
+
+	indexer.docLock.RLock()
+	defer indexer.docLock.RUnlock()
+	hits := make([]SearchHit, 0, min(result.Cardinality(), 1024))
+	it := result.Iterator()
+	for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+		doc := indexer.getDoc(intId)
+		if intId == 0 || !matchBits(doc.bits, onFlag, offFlag, orFlags) { //确保有效元素都大于0
+			continue
+		}
+		score, _, _ := scorer.score(query, intId)
+		hits = append(hits, SearchHit{Id: doc.id, IntId: intId, Score: score})
+	}
+	SortHits(hits)
+	return hits
+}
+
+// Count 位图运算求出结果集合后计数。没有bits过滤条件时直接取位图的元素个数
+func (indexer *RoaringReverseIndex) Count(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	query = PlanQuery(query, indexer.leafOpener(0, 0, nil))
+	result := indexer.evaluate(query)
+	if result == nil {
+		return 0
+	}
+	if noBitsFilter(onFlag, offFlag, orFlags) {
+		n := result.Cardinality()
+		if result.Contains(0) { //有效元素都大于0
+			n--
+		}
+		return n
+	}
+	indexer.docLock.RLock()
+	defer indexer.docLock.RUnlock()
+	n := 0
+	it := result.Iterator()
+	for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+		if intId > 0 && matchBits(indexer.getDoc(intId).bits, onFlag, offFlag, orFlags) {
+			n++
+		}
+	}
+	return n
+}
+
+// Explain 在迭代器树上逐个节点统计文档数和耗时
+func (indexer *RoaringReverseIndex) Explain(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64, intId uint64) *Explanation {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	indexer.docLock.RLock() //叶子迭代器按bits过滤和取业务Id时都要读docs
+	defer indexer.docLock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return ExplainQuery(PlanQuery(query, open), open, intId)
+}
+
+// SearchTopK 只返回得分最高的k个结果，结果跟Search的前k个完全一致
+func (indexer *RoaringReverseIndex) SearchTopK(query *types.TermQuery, k int, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	if k <= 0 || query == nil {
+		return nil
+	}
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	indexer.docLock.RLock()
+	defer indexer.docLock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return SearchTopK(PlanQuery(query, open), k, open)
+}
+
+// Expand 检索前先做的展开，返回给调用方用于高亮
+func (indexer *RoaringReverseIndex) Expand(query *types.TermQuery) *types.TermQuery {
+	return ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+}
+
+func (indexer *RoaringReverseIndex) Terms(field string, prefix string, limit int) []string {
+	return indexer.dict.Prefix(field, prefix, limit)
+}
+
+func (indexer *RoaringReverseIndex) Numeric(intId uint64, field string) (int64, bool) {
+	return indexer.numerics.Get(intId, field)
+}
+
+// Facets 位图运算求出结果集合后，逐个文档按bits过滤并统计bits，不打分；再拿field下每个word的倒排链跟命中的文档求交集。
+// 查询和field用到的keyword一次性加读锁，两步看到的是同一份数据
+func (indexer *RoaringReverseIndex) Facets(query *types.TermQuery, field string, onFlag uint64, offFlag uint64, orFlags []uint64) *Facets {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	words := facetWords(indexer.dict, field)
+	unlock := indexer.locks.rLockQuery(query, facetKeys(field, words)...)
+	defer unlock()
+	query = PlanQuery(query, indexer.leafOpener(0, 0, nil))
+	facets := &Facets{Values: make(map[string]int)}
+	result := indexer.evaluate(query)
+	if result == nil {
+		return facets
+	}
+	indexer.docLock.RLock()
+	defer indexer.docLock.RUnlock()
+	intIds := make([]uint64, 0, result.Cardinality())
+	it := result.Iterator()
+	for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+		if doc := indexer.getDoc(intId); intId > 0 && matchBits(doc.bits, onFlag, offFlag, orFlags) { //确保有效元素都大于0
+			intIds = append(intIds, intId)
+			facets.addBits(doc.bits)
+		}
+	}
+	facets.Total = len(intIds)
+	countFacetValues(facets, intIds, field, words, indexer.leafOpener(0, 0, nil))
+	return facets
+}
+
+// roaringIterator 遍历单个keyword的倒排位图。bits过滤下推到这里，不满足条件的文档直接跳过
+type roaringIterator struct {
+	indexer   *RoaringReverseIndex
+	posting   *roaringPosting
+	iter      *BitmapIterator
+	doc       uint64
+	filter    func(bits uint64) bool //为nil时不过滤
+	idf       float64
+	avgDocLen float64
+}
+
+func (it *roaringIterator) DocId() uint64 { return it.doc }
+
+func (it *roaringIterator) Next() uint64 {
+	if it.doc == NO_MORE_DOCS {
+		return it.doc
+	}
+	return it.skipFiltered(it.iter.Next())
+}
+
+func (it *roaringIterator) Advance(target uint64) uint64 {
+	if it.doc == NO_MORE_DOCS || target == NO_MORE_DOCS {
+		it.doc = NO_MORE_DOCS
+		return it.doc
+	}
+	if target <= it.doc { //已经在target上了。BitmapIterator会越过上次返回的元素，不能再调用它
+		return it.doc
+	}
+	return it.skipFiltered(it.iter.Advance(target))
+}
+
+// 从intId开始，跳过IntId无效或bits不满足条件的文档
+func (it *roaringIterator) skipFiltered(intId uint64, ok bool) uint64 {
+	for ; ok; intId, ok = it.iter.Next() {
+		if intId > 0 && (it.filter == nil || it.filter(it.indexer.getDoc(intId).bits)) { //确保有效元素都大于0
+			it.doc = intId
+			return it.doc
+		}
+	}
+	it.doc = NO_MORE_DOCS
+	return it.doc
+}
+
+func (it *roaringIterator) Cost() int64 { return int64(it.posting.bitmap.Cardinality()) }
+func (it *roaringIterator) Id() string  { return it.indexer.getDoc(it.doc).id }
+
+func (it *roaringIterator) Score() float64 {
+	return BM25TermScore(it.idf, it.posting.termFreq(it.doc), it.indexer.stats.DocLen(it.doc), it.avgDocLen)
+}
+
+func (it *roaringIterator) Positions() []int32 { return it.posting.positions[it.doc] }
+
+// MaxScore tf趋于无穷时BM25TermScore趋近于idf*(k1+1)
+func (it *roaringIterator) MaxScore() float64 {
+	return it.idf * (BM25_K1 + 1)
+}
+
+// 返回创建叶子迭代器的函数。调用方需要事先对用到的keyword加读锁，并持有docLock的读锁
+func (indexer *RoaringReverseIndex) leafOpener(onFlag uint64, offFlag uint64, orFlags []uint64) LeafOpener {
+	docCount := indexer.stats.DocCount()
+	avgDocLen := indexer.stats.AvgDocLen()
+	filter := func(bits uint64) bool {
+		return matchBits(bits, onFlag, offFlag, orFlags)
+	}
+	openKeyword := func(keyword *types.Keyword, filtered bool) PostingIterator {
+		posting := indexer.getPosting(keyword)
+		if posting == nil {
+			return &emptyIterator{}
+		}
+		it := &roaringIterator{
+			indexer:   indexer,
+			posting:   posting,
+			iter:      posting.bitmap.Iterator(),
+			idf:       BM25Idf(docCount, posting.bitmap.Cardinality()),
+			avgDocLen: avgDocLen,
+		}
+		if filtered {
+			it.filter = filter
+		}
+		return it
+	}
+	return leafOpener{openKeyword: openKeyword, numerics: indexer.numerics, filter: filter}
+}
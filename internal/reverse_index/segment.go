@@ -0,0 +1,529 @@
+package reverse_index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/Muoshu/myRadic/util"
+	"hash/crc32"
+	"sort"
+)
+
+var ErrCorruptSegment = errors.New("corrupt segment file")
+
+const (
+	segmentMagic     = "RSEG"
+	segmentVersion   = 3   //版本2在posting上增加了位置，版本3在文档表里增加了数值字段
+	postingBlockSize = 128 //倒排链每隔这么多条posting记一个跳表项，Advance时整块跳过
+)
+
+// segmentDoc 段上的一篇文档
+type segmentDoc struct {
+	id       string //业务Id
+	bits     uint64 //BitsFeature
+	length   int    //文档长度
+	postings int    //写段时该文档在段上有几条posting
+	numerics map[string]int64
+}
+
+// postingCursor 遍历一个段上某个keyword的倒排链。只能往后走
+type postingCursor interface {
+	next() (uint64, int, bool)                 //下一条posting的IntId和词频
+	advance(target uint64) (uint64, int, bool) //第一条IntId>=target的posting。总会越过上次返回的posting
+	positions() []int32                        //上次返回的posting上keyword出现的位置
+}
+
+// segment 倒排索引的一个分段，可能在内存里，也可能在磁盘上
+type segment interface {
+	postings(key string) (postingCursor, int) //keyword的倒排链及其长度，keyword不存在时返回nil
+	docInfo(intId uint64) (segmentDoc, bool)
+	forEachDoc(fun func(intId uint64, doc segmentDoc))
+	tombstones() map[string]*Bitmap //段上被删除的posting，key是keyword。修改时需要持有索引的写锁
+}
+
+// memPosting 内存段上的一条倒排链，按IntId从小到大排列
+type memPosting struct {
+	docs      []uint64
+	positions [][]int32 //个数即词频
+}
+
+// memSegment 内存段。新文档先写到这里，攒够一定数量后整体写成磁盘段
+type memSegment struct {
+	docs    map[uint64]segmentDoc
+	terms   map[string]*memPosting
+	deleted map[string]*Bitmap
+}
+
+func newMemSegment() *memSegment {
+	return &memSegment{docs: make(map[uint64]segmentDoc), terms: make(map[string]*memPosting), deleted: make(map[string]*Bitmap)}
+}
+
+func (seg *memSegment) add(intId uint64, doc segmentDoc, termPositions map[string][]int32) {
+	seg.docs[intId] = doc
+	for key, positions := range termPositions {
+		seg.put(intId, key, positions)
+	}
+}
+
+// 写入一条posting，已存在时替换位置，返回是否为新增的
+func (seg *memSegment) put(intId uint64, key string, positions []int32) bool {
+	posting, ok := seg.terms[key]
+	if !ok {
+		posting = new(memPosting)
+		seg.terms[key] = posting
+	}
+	//IntId基本是递增的，绝大多数情况下直接追加到末尾
+	i := len(posting.docs)
+	if i > 0 && posting.docs[i-1] >= intId {
+		i = sort.Search(len(posting.docs), func(j int) bool { return posting.docs[j] >= intId })
+		if posting.docs[i] == intId {
+			posting.positions[i] = positions
+			return false
+		}
+	}
+	posting.docs = append(posting.docs, 0)
+	copy(posting.docs[i+1:], posting.docs[i:])
+	posting.docs[i] = intId
+	posting.positions = append(posting.positions, nil)
+	copy(posting.positions[i+1:], posting.positions[i:])
+	posting.positions[i] = positions
+	return true
+}
+
+// update 文档更新后，把doc作为它最新的文档信息，termPositions里的posting写进来(已有的替换位置，被删除过的恢复)。
+// doc.postings会被改成文档在本段上的posting条数
+func (seg *memSegment) update(intId uint64, doc segmentDoc, termPositions map[string][]int32) {
+	doc.postings = seg.docs[intId].postings
+	for key, positions := range termPositions {
+		if seg.put(intId, key, positions) {
+			doc.postings++
+		} else if tomb := seg.deleted[key]; tomb != nil && tomb.Remove(intId) && tomb.Cardinality() == 0 {
+			delete(seg.deleted, key)
+		}
+	}
+	seg.docs[intId] = doc
+}
+
+func (seg *memSegment) postings(key string) (postingCursor, int) {
+	if posting, ok := seg.terms[key]; ok {
+		return &memCursor{posting: posting}, len(posting.docs)
+	}
+	return nil, 0
+}
+
+func (seg *memSegment) docInfo(intId uint64) (segmentDoc, bool) {
+	doc, ok := seg.docs[intId]
+	return doc, ok
+}
+
+func (seg *memSegment) forEachDoc(fun func(intId uint64, doc segmentDoc)) {
+	for intId, doc := range seg.docs {
+		fun(intId, doc)
+	}
+}
+
+func (seg *memSegment) tombstones() map[string]*Bitmap {
+	return seg.deleted
+}
+
+// 编码成磁盘段
+func (seg *memSegment) encode() []byte {
+	intIds := make([]uint64, 0, len(seg.docs))
+	for intId := range seg.docs {
+		intIds = append(intIds, intId)
+	}
+	sort.Slice(intIds, func(i, j int) bool { return intIds[i] < intIds[j] })
+	docs := make([]segmentDoc, len(intIds))
+	for i, intId := range intIds {
+		docs[i] = seg.docs[intId]
+	}
+	keys := make([]string, 0, len(seg.terms))
+	for key := range seg.terms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return encodeSegment(intIds, docs, keys, func(key string) ([]uint64, [][]int32) {
+		posting := seg.terms[key]
+		return posting.docs, posting.positions
+	})
+}
+
+type memCursor struct {
+	posting *memPosting
+	pos     int //下一条posting的下标
+}
+
+func (c *memCursor) next() (uint64, int, bool) {
+	if c.pos >= len(c.posting.docs) {
+		return 0, 0, false
+	}
+	c.pos++
+	return c.posting.docs[c.pos-1], len(c.posting.positions[c.pos-1]), true
+}
+
+func (c *memCursor) positions() []int32 {
+	return c.posting.positions[c.pos-1]
+}
+
+func (c *memCursor) advance(target uint64) (uint64, int, bool) {
+	docs := c.posting.docs[c.pos:]
+	c.pos += sort.Search(len(docs), func(i int) bool { return docs[i] >= target })
+	return c.next()
+}
+
+// 段文件的格式，整数都用uvarint编码：
+//
+//	"RSEG" 版本号(1字节)
+//	文档数 [IntId与上一篇的差值 业务Id长度 业务Id BitsFeature 文档长度 posting数 数值字段数 [字段名长度 字段名 数值(zigzag)]...]...
+//	keyword数 [keyword长度 keyword 倒排链长度 倒排链字节数 倒排链]...
+//	CRC32(4字节，小端)
+//
+// 文档和keyword都是排好序的。倒排链分成若干块，先是每一块的跳表项[块内最大IntId与上一块的差值 块的字节数]...，
+// 然后是各块的内容[IntId与上一条的差值 词频 位置的字节数 [位置与上一个位置的差值]...]...。不需要位置时按字节数整体跳过
+func encodeSegment(intIds []uint64, docs []segmentDoc, keys []string, postingsOf func(key string) ([]uint64, [][]int32)) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(segmentMagic)
+	buf.WriteByte(segmentVersion)
+	writeUvarint(&buf, uint64(len(intIds)))
+	var prev uint64
+	for i, intId := range intIds {
+		doc := docs[i]
+		writeUvarint(&buf, intId-prev)
+		prev = intId
+		writeString(&buf, doc.id)
+		writeUvarint(&buf, doc.bits)
+		writeUvarint(&buf, uint64(doc.length))
+		writeUvarint(&buf, uint64(doc.postings))
+		fields := make([]string, 0, len(doc.numerics))
+		for field := range doc.numerics {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields) //保证同样的数据写出同样的文件
+		writeUvarint(&buf, uint64(len(fields)))
+		for _, field := range fields {
+			writeString(&buf, field)
+			writeVarint(&buf, doc.numerics[field])
+		}
+	}
+	writeUvarint(&buf, uint64(len(keys)))
+	var posting bytes.Buffer
+	for _, key := range keys {
+		ids, positions := postingsOf(key)
+		posting.Reset()
+		encodePosting(&posting, ids, positions)
+		writeString(&buf, key)
+		writeUvarint(&buf, uint64(len(ids)))
+		writeUvarint(&buf, uint64(posting.Len()))
+		buf.Write(posting.Bytes())
+	}
+	binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+func encodePosting(buf *bytes.Buffer, ids []uint64, positions [][]int32) {
+	blockCount := (len(ids) + postingBlockSize - 1) / postingBlockSize
+	writeUvarint(buf, uint64(blockCount))
+	var block, pos bytes.Buffer
+	var prevLast, prev uint64
+	for b := 0; b < blockCount; b++ {
+		end := min((b+1)*postingBlockSize, len(ids))
+		start := block.Len()
+		for i := b * postingBlockSize; i < end; i++ {
+			writeUvarint(&block, ids[i]-prev)
+			writeUvarint(&block, uint64(len(positions[i])))
+			pos.Reset()
+			var prevPos int32
+			for _, p := range positions[i] {
+				writeUvarint(&pos, uint64(p-prevPos))
+				prevPos = p
+			}
+			writeUvarint(&block, uint64(pos.Len()))
+			block.Write(pos.Bytes())
+			prev = ids[i]
+		}
+		writeUvarint(buf, ids[end-1]-prevLast)
+		writeUvarint(buf, uint64(block.Len()-start))
+		prevLast = ids[end-1]
+	}
+	buf.Write(block.Bytes())
+}
+
+func writeUvarint(buf *bytes.Buffer, x uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	buf.Write(tmp[:n])
+}
+
+func writeVarint(buf *bytes.Buffer, x int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], x)
+	buf.Write(tmp[:n])
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeUvarint(buf, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+// segmentReader 顺序解码段文件，遇到错误后的读取都返回零值
+type segmentReader struct {
+	data []byte
+	off  int
+	err  error
+}
+
+func (r *segmentReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.data[r.off:])
+	if n <= 0 {
+		r.err = ErrCorruptSegment
+		return 0
+	}
+	r.off += n
+	return x
+}
+
+func (r *segmentReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(r.data[r.off:])
+	if n <= 0 {
+		r.err = ErrCorruptSegment
+		return 0
+	}
+	r.off += n
+	return x
+}
+
+func (r *segmentReader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data)-r.off) {
+		r.err = ErrCorruptSegment
+		return nil
+	}
+	b := r.data[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b
+}
+
+func (r *segmentReader) string() string {
+	return string(r.bytes(r.uvarint()))
+}
+
+type termEntry struct {
+	count      int //倒排链长度
+	start, end int //倒排链在文件中的位置
+}
+
+// diskSegment 磁盘段。段文件映射到内存，只解析文档表和keyword字典，倒排链在检索时才从映射里解码
+type diskSegment struct {
+	name    string   //文件名
+	mapped  []byte   //整个段文件的映射，释放段时解除
+	data    []byte   //去掉末尾crc的部分
+	intIds  []uint64 //从小到大排列
+	docs    []segmentDoc
+	keys    []string //从小到大排列
+	terms   map[string]termEntry
+	deleted map[string]*Bitmap //记在manifest里
+}
+
+func openSegment(path string, name string) (*diskSegment, error) {
+	data, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seg, err := parseSegment(name, data)
+	if err != nil {
+		unmapFile(data)
+		return nil, err
+	}
+	seg.mapped = data
+	return seg, nil
+}
+
+// release 解除段文件的映射。调用方需要保证已经没有检索在读这个段
+func (seg *diskSegment) release() {
+	if seg.mapped != nil {
+		if err := unmapFile(seg.mapped); err != nil {
+			util.Log.Printf("unmap segment %s failed: %s", seg.name, err)
+		}
+		seg.mapped, seg.data = nil, nil
+	}
+}
+
+// size 段文件的字节数，挑选要合并的段时用
+func (seg *diskSegment) size() int {
+	return len(seg.data)
+}
+
+func parseSegment(name string, data []byte) (*diskSegment, error) {
+	if len(data) < len(segmentMagic)+1+4 || string(data[:len(segmentMagic)]) != segmentMagic || data[len(segmentMagic)] != segmentVersion {
+		return nil, ErrCorruptSegment
+	}
+	body := data[:len(data)-4]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, ErrCorruptSegment
+	}
+	r := &segmentReader{data: body, off: len(segmentMagic) + 1}
+	seg := &diskSegment{name: name, data: body, deleted: make(map[string]*Bitmap)}
+	docCount := r.uvarint()
+	if docCount > uint64(len(body)) {
+		return nil, ErrCorruptSegment
+	}
+	seg.intIds = make([]uint64, 0, docCount)
+	seg.docs = make([]segmentDoc, 0, docCount)
+	var prev uint64
+	for i := uint64(0); i < docCount && r.err == nil; i++ {
+		prev += r.uvarint()
+		seg.intIds = append(seg.intIds, prev)
+		doc := segmentDoc{id: r.string(), bits: r.uvarint(), length: int(r.uvarint()), postings: int(r.uvarint())}
+		n := r.uvarint()
+		if n > uint64(len(body)) {
+			return nil, ErrCorruptSegment
+		}
+		if n > 0 {
+			doc.numerics = make(map[string]int64, n)
+			for ; n > 0 && r.err == nil; n-- {
+				field := r.string()
+				doc.numerics[field] = r.varint()
+			}
+		}
+		seg.docs = append(seg.docs, doc)
+	}
+	termCount := r.uvarint()
+	if termCount > uint64(len(body)) {
+		return nil, ErrCorruptSegment
+	}
+	seg.keys = make([]string, 0, termCount)
+	seg.terms = make(map[string]termEntry, termCount)
+	for i := uint64(0); i < termCount && r.err == nil; i++ {
+		key := r.string()
+		count := int(r.uvarint())
+		size := r.uvarint()
+		start := r.off
+		r.bytes(size)
+		seg.keys = append(seg.keys, key)
+		seg.terms[key] = termEntry{count: count, start: start, end: r.off}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return seg, nil
+}
+
+func (seg *diskSegment) postings(key string) (postingCursor, int) {
+	entry, ok := seg.terms[key]
+	if !ok {
+		return nil, 0
+	}
+	return newDiskCursor(seg.data[entry.start:entry.end]), entry.count
+}
+
+func (seg *diskSegment) docInfo(intId uint64) (segmentDoc, bool) {
+	i := sort.Search(len(seg.intIds), func(i int) bool { return seg.intIds[i] >= intId })
+	if i < len(seg.intIds) && seg.intIds[i] == intId {
+		return seg.docs[i], true
+	}
+	return segmentDoc{}, false
+}
+
+func (seg *diskSegment) forEachDoc(fun func(intId uint64, doc segmentDoc)) {
+	for i, intId := range seg.intIds {
+		fun(intId, seg.docs[i])
+	}
+}
+
+func (seg *diskSegment) tombstones() map[string]*Bitmap {
+	return seg.deleted
+}
+
+type postingBlock struct {
+	base       uint64 //上一块的最大IntId
+	last       uint64 //本块的最大IntId
+	start, end int    //本块在倒排链中的位置
+}
+
+// diskCursor 遍历磁盘段上的一条倒排链，边走边解码
+type diskCursor struct {
+	data   []byte
+	blocks []postingBlock
+	bi     int    //当前块
+	off    int    //下一条posting的位置
+	prev   uint64 //上一条posting的IntId
+	tf     int    //上一条posting的词频
+	posOff int    //上一条posting的位置信息从哪里开始
+}
+
+func newDiskCursor(data []byte) *diskCursor {
+	r := &segmentReader{data: data}
+	blocks := make([]postingBlock, r.uvarint())
+	var last uint64
+	for i := range blocks {
+		blocks[i].base = last
+		last += r.uvarint()
+		blocks[i].last = last
+		blocks[i].end = int(r.uvarint()) //先记下块的字节数
+	}
+	off := r.off
+	for i := range blocks {
+		blocks[i].start = off
+		off += blocks[i].end
+		blocks[i].end = off
+	}
+	c := &diskCursor{data: data, blocks: blocks}
+	if len(blocks) > 0 {
+		c.off = blocks[0].start
+	}
+	return c
+}
+
+func (c *diskCursor) next() (uint64, int, bool) {
+	for c.bi < len(c.blocks) {
+		if c.off < c.blocks[c.bi].end {
+			r := &segmentReader{data: c.data, off: c.off}
+			c.prev += r.uvarint()
+			c.tf = int(r.uvarint())
+			size := r.uvarint()
+			c.posOff = r.off
+			r.bytes(size)
+			c.off = r.off
+			return c.prev, c.tf, true
+		}
+		c.bi++ //各块是连续存放的，off和prev正好对应下一块的开头
+	}
+	return 0, 0, false
+}
+
+func (c *diskCursor) advance(target uint64) (uint64, int, bool) {
+	if c.bi < len(c.blocks) && c.blocks[c.bi].last < target {
+		//最大IntId比target小的块整块跳过
+		c.bi += sort.Search(len(c.blocks)-c.bi, func(i int) bool { return c.blocks[c.bi+i].last >= target })
+		if c.bi >= len(c.blocks) {
+			return 0, 0, false
+		}
+		c.off = c.blocks[c.bi].start
+		c.prev = c.blocks[c.bi].base
+	}
+	for {
+		intId, tf, ok := c.next()
+		if !ok || intId >= target {
+			return intId, tf, ok
+		}
+	}
+}
+
+func (c *diskCursor) positions() []int32 {
+	r := &segmentReader{data: c.data, off: c.posOff}
+	positions := make([]int32, 0, c.tf)
+	var p int32
+	for i := 0; i < c.tf && r.err == nil; i++ {
+		p += int32(r.uvarint())
+		positions = append(positions, p)
+	}
+	return positions
+}
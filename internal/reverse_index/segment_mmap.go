@@ -0,0 +1,29 @@
+//go:build unix
+
+package reverse_index
+
+import (
+	"os"
+	"syscall"
+)
+
+// 把段文件只读映射到内存。倒排链由操作系统按页换入换出，不占用Go的堆
+func mapFile(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //映射建立之后关掉文件不影响映射
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return nil, ErrCorruptSegment
+	}
+	return syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package reverse_index
+
+import "os"
+
+// 不支持mmap的平台上整个读进内存
+func mapFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
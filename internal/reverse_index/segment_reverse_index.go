@@ -0,0 +1,989 @@
+package reverse_index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"github.com/Muoshu/myRadic/types"
+	"github.com/Muoshu/myRadic/util"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	manifestFile   = "manifest" //记录当前有哪些段，以及各段上被删除的posting
+	dirtyFile      = "dirty"    //存在时说明内存里有数据还没落盘，重启时磁盘上的数据不完整
+	manifestMagic  = "RMA2"     //版本2起删除的posting按段记录，读不了老版本时丢弃磁盘上的数据重建
+	segmentFileExt = ".seg"
+)
+
+// SegmentReverseIndex 分段存储的倒排索引。
+//
+// 新文档先写进内存段，攒够flushThreshold篇后在后台写成一个不可变的磁盘段，段数超过maxSegments时在后台合并成一个段。
+// 删除不修改段，而是在posting所在段的tombstones里记下被删除的posting，合并时才真正清除。
+// 更新文档时IntId不变，变化了的posting在原来的段上标记删除，新的posting写进内存段。同一篇文档在一个keyword上只有一个段上的posting有效。
+// 系统重启时只需读入各段的文档表和keyword字典，倒排链在检索时才解码，不需要再从正排索引逐篇重建
+type SegmentReverseIndex struct {
+	dir            string
+	flushThreshold int  //内存段攒够这么多篇文档就写成磁盘段
+	maxSegments    int  //磁盘段超过这么多个就合并
+	withWal        bool //调用方有预写日志，没落盘的修改可以靠重放日志补上
+
+	lock       sync.RWMutex
+	segments   []*diskSegment
+	frozen     *memSegment           //正在写盘的内存段，不再修改
+	buffer     *memSegment           //正在接收新文档的内存段
+	latest     map[uint64]segmentDoc //更新过、出现在多个段上的文档的最新信息，旧段上的bits已经过时
+	stats      *DocStats             //BM25打分需要的全局统计量
+	dict       *TermDict             //还有posting的key，前缀、通配符展开用
+	numerics   *NumericStore         //文档的数值字段，Range查询用
+	maxIntId   uint64
+	generation int    //下一个段文件的编号
+	loaded     bool   //是否从磁盘加载到了数据
+	dirty      bool   //内存里是否有还没落盘的修改
+	version    uint64 //每次修改都加1
+
+	flushLock  sync.Mutex     //flush和merge不能同时进行
+	flushing   int32          //后台是否有flush在排队或进行中
+	background sync.WaitGroup //后台的flush
+}
+
+func NewSegmentReverseIndex(docNum int) *SegmentReverseIndex {
+	return &SegmentReverseIndex{
+		flushThreshold: 10000,
+		maxSegments:    8,
+		buffer:         newMemSegment(),
+		latest:         make(map[uint64]segmentDoc),
+		stats:          NewDocStats(docNum),
+		dict:           NewTermDict(),
+		numerics:       NewNumericStore(docNum),
+	}
+}
+
+// Builder模式
+func (indexer *SegmentReverseIndex) WithDataDir(dir string) *SegmentReverseIndex {
+	indexer.dir = dir
+	return indexer
+}
+
+func (indexer *SegmentReverseIndex) WithFlushThreshold(n int) *SegmentReverseIndex {
+	indexer.flushThreshold = n
+	return indexer
+}
+
+func (indexer *SegmentReverseIndex) WithMaxSegments(n int) *SegmentReverseIndex {
+	indexer.maxSegments = n
+	return indexer
+}
+
+// WithWal 调用方的预写日志里有上次落盘之后的全部修改。上次没有正常关闭时保留最后一个manifest里的段，由调用方重放日志
+func (indexer *SegmentReverseIndex) WithWal() *SegmentReverseIndex {
+	indexer.withWal = true
+	return indexer
+}
+
+// Open 加载磁盘上的段。段文件损坏，或者上次没有正常关闭且没有预写日志时，丢弃磁盘上的数据，由调用方从正排索引重建
+func (indexer *SegmentReverseIndex) Open() error {
+	if err := os.MkdirAll(indexer.dir, os.ModePerm); err != nil {
+		return err
+	}
+	if _, err := os.Stat(indexer.path(dirtyFile)); err == nil {
+		if !indexer.withWal {
+			util.Log.Printf("reverse index %s was not closed properly, discard it", indexer.dir)
+			return indexer.reset()
+		}
+		//manifest里的段是完整的，之后的修改在预写日志里。dirty文件留到下次落盘时再删
+		util.Log.Printf("reverse index %s was not closed properly, load the last manifest", indexer.dir)
+		indexer.dirty = true
+	}
+	names, tombstones, err := indexer.readManifest()
+	if err != nil {
+		util.Log.Printf("read manifest of %s failed: %s, discard it", indexer.dir, err)
+		return indexer.reset()
+	}
+	segments := make([]*diskSegment, 0, len(names))
+	for _, name := range names {
+		seg, err := openSegment(indexer.path(name), name)
+		if err != nil {
+			util.Log.Printf("open segment %s failed: %s, discard reverse index %s", name, err, indexer.dir)
+			for _, seg := range segments {
+				seg.release()
+			}
+			return indexer.reset()
+		}
+		if deleted, ok := tombstones[name]; ok {
+			seg.deleted = deleted
+		}
+		segments = append(segments, seg)
+	}
+	indexer.removeUnusedFiles(names)
+
+	indexer.segments = segments
+	//更新过的文档出现在多个段上，以最新的段上的文档信息为准，posting数是各段之和
+	type docEntry struct {
+		doc      segmentDoc
+		postings int
+		segments int
+	}
+	docs := make(map[uint64]*docEntry, 1024)
+	for _, seg := range segments {
+		seg.forEachDoc(func(intId uint64, doc segmentDoc) {
+			entry, ok := docs[intId]
+			if !ok {
+				entry = new(docEntry)
+				docs[intId] = entry
+			}
+			entry.doc = doc
+			entry.postings += doc.postings
+			entry.segments++
+		})
+	}
+	for intId, entry := range docs {
+		indexer.maxIntId = max(indexer.maxIntId, intId)
+		if entry.segments > 1 {
+			indexer.latest[intId] = entry.doc
+		}
+		if entry.postings > 0 {
+			indexer.stats.Add(intId, entry.doc.length, entry.postings, entry.doc.bits)
+			indexer.numerics.Add(intId, entry.doc.id, entry.doc.bits, entry.doc.numerics)
+		}
+	}
+	for _, seg := range segments {
+		for _, tomb := range seg.deleted {
+			it := tomb.Iterator()
+			for intId, ok := it.Next(); ok; intId, ok = it.Next() {
+				if indexer.stats.RemovePosting(intId) {
+					indexer.numerics.Remove(intId)
+				}
+			}
+		}
+	}
+	for _, seg := range segments {
+		for _, key := range seg.keys {
+			if indexer.docFreq(key) > 0 {
+				indexer.dict.Add(key)
+			}
+		}
+	}
+	indexer.loaded = len(segments) > 0
+	return nil
+}
+
+func (indexer *SegmentReverseIndex) path(name string) string {
+	return filepath.Join(indexer.dir, name)
+}
+
+// 清空磁盘上的数据
+func (indexer *SegmentReverseIndex) reset() error {
+	entries, err := os.ReadDir(indexer.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.Remove(indexer.path(entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 删除不在manifest里的段文件，它们是合并或flush到一半时留下的
+func (indexer *SegmentReverseIndex) removeUnusedFiles(names []string) {
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+		var generation int
+		if _, err := fmt.Sscanf(name, "%d"+segmentFileExt, &generation); err == nil {
+			indexer.generation = max(indexer.generation, generation+1)
+		}
+	}
+	entries, _ := os.ReadDir(indexer.dir)
+	for _, entry := range entries {
+		if name := entry.Name(); strings.HasSuffix(name, segmentFileExt) && !used[name] {
+			os.Remove(indexer.path(name))
+		}
+	}
+}
+
+// manifest的格式，整数都用uvarint编码：
+//
+//	"RMA2" 段数 [文件名长度 文件名 keyword数 [keyword长度 keyword 删除数 [IntId与上一个的差值]...]...]... CRC32(4字节，小端)
+func (indexer *SegmentReverseIndex) encodeManifest() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(manifestMagic)
+	writeUvarint(&buf, uint64(len(indexer.segments)))
+	for _, seg := range indexer.segments {
+		writeString(&buf, seg.name)
+		keys := make([]string, 0, len(seg.deleted))
+		for key, tomb := range seg.deleted {
+			if tomb.Cardinality() > 0 {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		writeUvarint(&buf, uint64(len(keys)))
+		for _, key := range keys {
+			intIds := seg.deleted[key].ToArray()
+			writeString(&buf, key)
+			writeUvarint(&buf, uint64(len(intIds)))
+			var prev uint64
+			for _, intId := range intIds {
+				writeUvarint(&buf, intId-prev)
+				prev = intId
+			}
+		}
+	}
+	binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+	return buf.Bytes()
+}
+
+// 返回段的文件名，以及每个段上被删除的posting
+func (indexer *SegmentReverseIndex) readManifest() ([]string, map[string]map[string]*Bitmap, error) {
+	tombstones := make(map[string]map[string]*Bitmap)
+	data, err := os.ReadFile(indexer.path(manifestFile))
+	if os.IsNotExist(err) {
+		return nil, tombstones, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < len(manifestMagic)+4 || string(data[:len(manifestMagic)]) != manifestMagic ||
+		crc32.ChecksumIEEE(data[:len(data)-4]) != binary.LittleEndian.Uint32(data[len(data)-4:]) {
+		return nil, nil, ErrCorruptSegment
+	}
+	r := &segmentReader{data: data[:len(data)-4], off: len(manifestMagic)}
+	names := make([]string, 0, 16)
+	for i := r.uvarint(); i > 0 && r.err == nil; i-- {
+		name := r.string()
+		names = append(names, name)
+		deleted := make(map[string]*Bitmap)
+		for j := r.uvarint(); j > 0 && r.err == nil; j-- {
+			key := r.string()
+			tomb := NewBitmap()
+			var prev uint64
+			for k := r.uvarint(); k > 0 && r.err == nil; k-- {
+				prev += r.uvarint()
+				tomb.Add(prev)
+			}
+			deleted[key] = tomb
+		}
+		tombstones[name] = deleted
+	}
+	return names, tombstones, r.err
+}
+
+// 先写临时文件再改名，保证文件要么是旧的要么是新的
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// 第一次修改时创建dirty文件。调用方需要持有写锁
+func (indexer *SegmentReverseIndex) markDirty() {
+	indexer.version++
+	if !indexer.dirty {
+		indexer.dirty = true
+		if err := os.WriteFile(indexer.path(dirtyFile), nil, 0o644); err != nil {
+			util.Log.Printf("create dirty file failed: %s", err)
+		}
+	}
+}
+
+// 按从旧到新的顺序返回所有段。调用方需要持有读锁
+func (indexer *SegmentReverseIndex) allSegments() []segment {
+	segments := make([]segment, 0, len(indexer.segments)+2)
+	for _, seg := range indexer.segments {
+		segments = append(segments, seg)
+	}
+	if indexer.frozen != nil {
+		segments = append(segments, indexer.frozen)
+	}
+	return append(segments, indexer.buffer)
+}
+
+// Add 同一个IntId只能添加一次(Indexer每次添加文档都会分配新的IntId)
+func (indexer *SegmentReverseIndex) Add(doc types.Document) {
+	indexer.AddBatch([]types.Document{doc})
+}
+
+// AddBatch 整批文档只加一次锁写入内存段，内存段写满时在后台落盘
+func (indexer *SegmentReverseIndex) AddBatch(docs []types.Document) {
+	indexer.lock.Lock()
+	for _, doc := range docs {
+		//同一个keyword在文档中可能出现多次，合并成一条posting，出现次数即词频
+		termPositions := keywordPositions(doc)
+		if len(termPositions) == 0 {
+			continue
+		}
+		indexer.markDirty()
+		indexer.stats.Add(doc.IntId, len(doc.Keywords), len(termPositions), doc.BitsFeature)
+		indexer.numerics.Add(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+		indexer.buffer.add(doc.IntId, segmentDoc{id: doc.Id, bits: doc.BitsFeature, length: len(doc.Keywords), postings: len(termPositions), numerics: doc.Numerics}, termPositions)
+		for key := range termPositions {
+			indexer.dict.Add(key)
+		}
+		indexer.maxIntId = max(indexer.maxIntId, doc.IntId)
+	}
+	full := len(indexer.buffer.docs) >= indexer.flushThreshold
+	indexer.lock.Unlock()
+	if full {
+		indexer.flushInBackground()
+	}
+}
+
+// 内存段写满时在后台落盘，落盘后段数太多就合并
+func (indexer *SegmentReverseIndex) flushInBackground() {
+	if atomic.CompareAndSwapInt32(&indexer.flushing, 0, 1) {
+		indexer.background.Add(1)
+		go func() {
+			defer indexer.background.Done()
+			atomic.StoreInt32(&indexer.flushing, 0)
+			if err := indexer.Flush(); err != nil {
+				util.Log.Printf("flush reverse index failed: %s", err)
+				return
+			}
+			if err := indexer.mergeIfNeeded(); err != nil {
+				util.Log.Printf("merge reverse index failed: %s", err)
+			}
+		}()
+	}
+}
+
+// Delete 在posting所在段的tombstones里记下被删除的posting，段本身不修改
+func (indexer *SegmentReverseIndex) Delete(IntId uint64, keyword *types.Keyword) {
+	indexer.lock.Lock()
+	defer indexer.lock.Unlock()
+	indexer.delete(IntId, keyword.ToString())
+}
+
+// DeleteBatch 整批文档只加一次锁
+func (indexer *SegmentReverseIndex) DeleteBatch(docs []*types.Document) {
+	indexer.lock.Lock()
+	defer indexer.lock.Unlock()
+	for key, intIds := range groupDeletes(docs) {
+		for _, intId := range intIds {
+			indexer.delete(intId, key)
+		}
+	}
+}
+
+// 调用方需要持有锁
+func (indexer *SegmentReverseIndex) delete(IntId uint64, key string) {
+	seg := indexer.livePosting(IntId, key)
+	if seg == nil {
+		return
+	}
+	indexer.tombstone(seg, IntId, key)
+	if indexer.stats.RemovePosting(IntId) { //文档的所有posting都删掉了
+		indexer.numerics.Remove(IntId)
+	}
+	if indexer.docFreq(key) == 0 {
+		indexer.dict.Remove(key)
+	}
+}
+
+// 文档在key上有效的posting所在的段，没有时返回nil。更新过的文档有效的posting在较新的段上，所以从新往旧找。调用方需要持有锁
+func (indexer *SegmentReverseIndex) livePosting(IntId uint64, key string) segment {
+	segments := indexer.allSegments()
+	for i := len(segments) - 1; i >= 0; i-- {
+		seg := segments[i]
+		if _, ok := seg.docInfo(IntId); !ok {
+			continue
+		}
+		if tomb := seg.tombstones()[key]; tomb != nil && tomb.Contains(IntId) {
+			continue
+		}
+		if cursor, _ := seg.postings(key); cursor != nil {
+			if intId, _, ok := cursor.advance(IntId); ok && intId == IntId {
+				return seg
+			}
+		}
+	}
+	return nil
+}
+
+// 在seg上标记删除一条posting。调用方需要持有写锁
+func (indexer *SegmentReverseIndex) tombstone(seg segment, IntId uint64, key string) {
+	tombstones := seg.tombstones()
+	tomb, ok := tombstones[key]
+	if !ok {
+		tomb = NewBitmap()
+		tombstones[key] = tomb
+	}
+	tomb.Add(IntId)
+	indexer.markDirty()
+}
+
+// 包含key的文档数：各段上的倒排链长度减去该段上已删除的，再求和。调用方需要持有锁
+func (indexer *SegmentReverseIndex) docFreq(key string) int {
+	docFreq := 0
+	for _, seg := range indexer.allSegments() {
+		if _, count := seg.postings(key); count > 0 {
+			docFreq += count
+			if tomb := seg.tombstones()[key]; tomb != nil {
+				docFreq -= tomb.Cardinality()
+			}
+		}
+	}
+	return docFreq
+}
+
+// Update 变化了的posting在原来的段上标记删除，新的posting写进内存段。BitsFeature、文档长度和数值字段以内存段上的为准
+func (indexer *SegmentReverseIndex) Update(old *types.Document, doc types.Document) {
+	oldPositions, newPositions := keywordPositions(*old), keywordPositions(doc)
+	if len(newPositions) == 0 { //没有keyword的文档不在倒排索引上
+		indexer.DeleteBatch([]*types.Document{old})
+		return
+	}
+	//旧文档没有keyword时段上可能还留着它被删除的posting，不能当成新文档Add
+	removed, changed := diffPostings(oldPositions, newPositions, false)
+	indexer.lock.Lock()
+	for _, key := range removed {
+		if seg := indexer.livePosting(doc.IntId, key); seg != nil {
+			indexer.tombstone(seg, doc.IntId, key)
+		}
+	}
+	for key := range changed {
+		//内存段上的posting直接替换
+		if seg := indexer.livePosting(doc.IntId, key); seg != nil && seg != segment(indexer.buffer) {
+			indexer.tombstone(seg, doc.IntId, key)
+		}
+		indexer.dict.Add(key)
+	}
+	indexer.markDirty()
+	indexer.buffer.update(doc.IntId, segmentDoc{id: doc.Id, bits: doc.BitsFeature, length: len(doc.Keywords), numerics: doc.Numerics}, changed)
+	latest, _ := indexer.buffer.docInfo(doc.IntId)
+	for _, seg := range indexer.allSegments() {
+		if _, ok := seg.docInfo(doc.IntId); ok && seg != segment(indexer.buffer) {
+			indexer.latest[doc.IntId] = latest
+			break
+		}
+	}
+	indexer.stats.Add(doc.IntId, len(doc.Keywords), len(newPositions), doc.BitsFeature)
+	indexer.numerics.Update(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+	for _, key := range removed {
+		if indexer.docFreq(key) == 0 {
+			indexer.dict.Remove(key)
+		}
+	}
+	full := len(indexer.buffer.docs) >= indexer.flushThreshold
+	indexer.lock.Unlock()
+	if full {
+		indexer.flushInBackground()
+	}
+}
+
+// Flush 把内存段写成磁盘段，并把tombstones写进manifest
+func (indexer *SegmentReverseIndex) Flush() error {
+	indexer.flushLock.Lock()
+	defer indexer.flushLock.Unlock()
+
+	indexer.lock.Lock()
+	if !indexer.dirty {
+		indexer.lock.Unlock()
+		return nil
+	}
+	if indexer.frozen == nil && len(indexer.buffer.docs) > 0 { //frozen不为nil说明上次写盘失败了，先把它写下去
+		indexer.frozen = indexer.buffer
+		indexer.buffer = newMemSegment()
+	}
+	frozen := indexer.frozen
+	name := fmt.Sprintf("%08d"+segmentFileExt, indexer.generation)
+	indexer.generation++
+	indexer.lock.Unlock()
+
+	//frozen不会再被修改，写盘时不需要持有锁
+	if frozen != nil {
+		data := frozen.encode()
+		if err := writeFileAtomic(indexer.path(name), data); err != nil {
+			return err
+		}
+		seg, err := openSegment(indexer.path(name), name) //刚写的段也映射进来，不在堆上留一份
+		if err != nil {
+			return err
+		}
+		indexer.lock.Lock()
+		seg.deleted = frozen.deleted //写盘期间标记的删除也在里面
+		indexer.segments = append(indexer.segments, seg)
+		indexer.frozen = nil
+		indexer.lock.Unlock()
+	}
+	return indexer.commit()
+}
+
+// 写manifest。如果写的过程中没有新的修改，就删掉dirty文件
+func (indexer *SegmentReverseIndex) commit() error {
+	indexer.lock.RLock()
+	manifest := indexer.encodeManifest()
+	version := indexer.version
+	indexer.lock.RUnlock()
+	if err := writeFileAtomic(indexer.path(manifestFile), manifest); err != nil {
+		return err
+	}
+	indexer.lock.Lock()
+	defer indexer.lock.Unlock()
+	if indexer.dirty && indexer.version == version && indexer.frozen == nil && len(indexer.buffer.docs) == 0 {
+		if err := os.Remove(indexer.path(dirtyFile)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		indexer.dirty = false
+	}
+	return nil
+}
+
+// 段数超过maxSegments时，只合并挑出来的几个相邻的段
+func (indexer *SegmentReverseIndex) mergeIfNeeded() error {
+	indexer.flushLock.Lock()
+	defer indexer.flushLock.Unlock()
+	indexer.lock.RLock()
+	from, to := pickMerge(indexer.segments, indexer.maxSegments)
+	indexer.lock.RUnlock()
+	if to-from < 2 {
+		return nil
+	}
+	return indexer.merge(from, to)
+}
+
+// pickMerge 段数超过maxSegments时，在相邻的段里挑总字节数最小的一组，合并后段数正好回到maxSegments。
+// 更新过的文档以较新的段为准，段的先后顺序不能变，所以只合并相邻的段。新flush出来的小段总是先跟旁边的小段合并，
+// 大段要等周围的段涨到跟它差不多大时才会被重写，一次小的flush不会把整个索引重写一遍。返回[from,to)
+func pickMerge(segments []*diskSegment, maxSegments int) (int, int) {
+	width := len(segments) - max(maxSegments, 1) + 1
+	if width < 2 {
+		return 0, 0
+	}
+	from, minSize := 0, -1
+	size := 0
+	for i, seg := range segments {
+		size += seg.size()
+		if i >= width {
+			size -= segments[i-width].size()
+		}
+		if i >= width-1 && (minSize < 0 || size < minSize) {
+			from, minSize = i-width+1, size
+		}
+	}
+	return from, from + width
+}
+
+// Merge 把所有磁盘段合并成一个，同时清除被删除的posting
+func (indexer *SegmentReverseIndex) Merge() error {
+	indexer.flushLock.Lock()
+	defer indexer.flushLock.Unlock()
+	indexer.lock.RLock()
+	n := len(indexer.segments)
+	indexer.lock.RUnlock()
+	return indexer.merge(0, n)
+}
+
+// 把segments[from:to]合并成一个段，放在原来的位置上。调用方需要持有flushLock
+func (indexer *SegmentReverseIndex) merge(from, to int) error {
+	//flushLock保证合并期间segments只会被自己修改，只需要给参与合并的段的tombstones拍个快照
+	indexer.lock.Lock()
+	olds := slices.Clone(indexer.segments[from:to])
+	snapshots := make([]map[string]*Bitmap, len(olds))
+	deleted := 0
+	for i, seg := range olds {
+		snapshots[i] = make(map[string]*Bitmap, len(seg.deleted))
+		for key, tomb := range seg.deleted {
+			snapshots[i][key] = tomb.Or(NewBitmap())
+		}
+		deleted += len(seg.deleted)
+	}
+	if len(olds) == 0 || (len(olds) == 1 && deleted == 0) {
+		indexer.lock.Unlock()
+		return nil
+	}
+	name := fmt.Sprintf("%08d"+segmentFileExt, indexer.generation)
+	indexer.generation++
+	indexer.lock.Unlock()
+
+	if err := writeFileAtomic(indexer.path(name), mergeSegments(olds, snapshots)); err != nil {
+		return err
+	}
+	merged, err := openSegment(indexer.path(name), name)
+	if err != nil {
+		return err
+	}
+
+	indexer.lock.Lock()
+	//合并期间在旧段上标记的删除，对应的posting都已经写进了新段
+	for i, seg := range olds {
+		for key, tomb := range seg.deleted {
+			rest := tomb
+			if snapshot, ok := snapshots[i][key]; ok {
+				rest = tomb.AndNot(snapshot)
+			}
+			if rest.Cardinality() == 0 {
+				continue
+			}
+			if current, ok := merged.deleted[key]; ok {
+				rest = current.Or(rest)
+			}
+			merged.deleted[key] = rest
+		}
+	}
+	segments := make([]*diskSegment, 0, len(indexer.segments)-len(olds)+1)
+	segments = append(segments, indexer.segments[:from]...)
+	segments = append(segments, merged)
+	indexer.segments = append(segments, indexer.segments[to:]...)
+	indexer.refreshLatest()
+	indexer.lock.Unlock()
+
+	//检索都在读锁下进行，换掉段之后就没有人再读旧段了
+	for _, seg := range olds {
+		seg.release()
+	}
+	if err := indexer.commit(); err != nil {
+		return err
+	}
+	for _, seg := range olds {
+		os.Remove(indexer.path(seg.name))
+	}
+	return nil
+}
+
+// 合并后有的文档只剩在一个段上了，不用再记它的最新信息。调用方需要持有写锁
+func (indexer *SegmentReverseIndex) refreshLatest() {
+	segments := indexer.allSegments()
+	for intId := range indexer.latest {
+		var latest segmentDoc
+		n := 0
+		for _, seg := range segments {
+			if doc, ok := seg.docInfo(intId); ok {
+				latest = doc
+				n++
+			}
+		}
+		if n > 1 {
+			indexer.latest[intId] = latest
+		} else {
+			delete(indexer.latest, intId)
+		}
+	}
+}
+
+// 合并多个磁盘段，跳过各段tombstones里的posting，posting全被删除的文档也一并去掉。tombstones[i]是segments[i]上被删除的posting
+func mergeSegments(segments []*diskSegment, tombstones []map[string]*Bitmap) []byte {
+	keySet := make(map[string]struct{}, 1024)
+	for _, seg := range segments {
+		for _, key := range seg.keys {
+			keySet[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for key := range keySet {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	livePostings := make(map[uint64]int, 1024) //每篇文档还剩几条posting
+	postings := make(map[string]*memPosting, len(keys))
+	liveKeys := keys[:0]
+	for _, key := range keys {
+		posting := new(memPosting)
+		for i, seg := range segments {
+			cursor, _ := seg.postings(key)
+			if cursor == nil {
+				continue
+			}
+			tomb := tombstones[i][key]
+			for intId, _, ok := cursor.next(); ok; intId, _, ok = cursor.next() {
+				if tomb != nil && tomb.Contains(intId) {
+					continue
+				}
+				posting.docs = append(posting.docs, intId)
+				posting.positions = append(posting.positions, cursor.positions())
+				livePostings[intId]++
+			}
+		}
+		if len(posting.docs) == 0 {
+			continue
+		}
+		sort.Sort(posting)
+		postings[key] = posting
+		liveKeys = append(liveKeys, key)
+	}
+
+	intIds := make([]uint64, 0, len(livePostings))
+	for _, seg := range segments {
+		for _, intId := range seg.intIds {
+			if livePostings[intId] > 0 {
+				intIds = append(intIds, intId)
+			}
+		}
+	}
+	sort.Slice(intIds, func(i, j int) bool { return intIds[i] < intIds[j] })
+	docs := make([]segmentDoc, 0, len(intIds))
+	for _, intId := range intIds {
+		for i := len(segments) - 1; i >= 0; i-- { //更新过的文档以最新的段为准
+			if doc, ok := segments[i].docInfo(intId); ok {
+				doc.postings = livePostings[intId]
+				docs = append(docs, doc)
+				break
+			}
+		}
+	}
+	return encodeSegment(intIds, docs, liveKeys, func(key string) ([]uint64, [][]int32) {
+		return postings[key].docs, postings[key].positions
+	})
+}
+
+func (p *memPosting) Len() int           { return len(p.docs) }
+func (p *memPosting) Less(i, j int) bool { return p.docs[i] < p.docs[j] }
+func (p *memPosting) Swap(i, j int) {
+	p.docs[i], p.docs[j] = p.docs[j], p.docs[i]
+	p.positions[i], p.positions[j] = p.positions[j], p.positions[i]
+}
+
+// Close 等后台的flush结束，再把内存里的数据全部落盘。落盘成功后解除段文件的映射，之后不能再检索
+func (indexer *SegmentReverseIndex) Close() error {
+	indexer.background.Wait()
+	if err := indexer.Flush(); err != nil {
+		return err
+	}
+	indexer.lock.Lock()
+	defer indexer.lock.Unlock()
+	for _, seg := range indexer.segments {
+		seg.release()
+	}
+	indexer.segments = nil
+	return nil
+}
+
+// Loaded 是否从磁盘加载到了数据。为false时需要从正排索引重建
+func (indexer *SegmentReverseIndex) Loaded() bool {
+	return indexer.loaded
+}
+
+func (indexer *SegmentReverseIndex) MaxIntId() uint64 {
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	return indexer.maxIntId
+}
+
+func (indexer *SegmentReverseIndex) DocCount() int {
+	return indexer.stats.DocCount()
+}
+
+func (indexer *SegmentReverseIndex) HasDoc(intId uint64) bool {
+	return indexer.stats.Has(intId)
+}
+
+// segmentIterator 遍历某个keyword在一个段上的倒排链。bits过滤和tombstones都下推到这里
+type segmentIterator struct {
+	seg       segment
+	cursor    postingCursor
+	count     int                   //本段上有效的posting数，跟其他实现的倒排链长度一致，查询规划才会得到同样的顺序
+	tomb      *Bitmap               //本段上被删除的posting，为nil时没有
+	latest    map[uint64]segmentDoc //更新过的文档的最新信息
+	doc       uint64
+	tf        int
+	id        string
+	filter    func(bits uint64) bool //为nil时不过滤
+	stats     *DocStats
+	idf       float64
+	avgDocLen float64
+}
+
+func (it *segmentIterator) DocId() uint64 { return it.doc }
+
+func (it *segmentIterator) Next() uint64 {
+	if it.doc == NO_MORE_DOCS {
+		return it.doc
+	}
+	return it.skipInvalid(it.cursor.next())
+}
+
+func (it *segmentIterator) Advance(target uint64) uint64 {
+	if it.doc == NO_MORE_DOCS || target == NO_MORE_DOCS {
+		it.doc = NO_MORE_DOCS
+		return it.doc
+	}
+	if target <= it.doc { //已经在target上了。cursor会越过上次返回的posting，不能再调用它
+		return it.doc
+	}
+	return it.skipInvalid(it.cursor.advance(target))
+}
+
+// 跳过IntId无效、已删除或bits不满足条件的posting
+func (it *segmentIterator) skipInvalid(intId uint64, tf int, ok bool) uint64 {
+	for ; ok; intId, tf, ok = it.cursor.next() {
+		if intId == 0 || (it.tomb != nil && it.tomb.Contains(intId)) { //确保有效元素都大于0
+			continue
+		}
+		doc, _ := it.seg.docInfo(intId)
+		if newer, ok := it.latest[intId]; ok {
+			doc = newer
+		}
+		if it.filter == nil || it.filter(doc.bits) {
+			it.doc, it.tf, it.id = intId, tf, doc.id
+			return it.doc
+		}
+	}
+	it.doc = NO_MORE_DOCS
+	return it.doc
+}
+
+func (it *segmentIterator) Cost() int64 { return int64(it.count) }
+func (it *segmentIterator) Id() string  { return it.id }
+
+func (it *segmentIterator) Score() float64 {
+	return BM25TermScore(it.idf, it.tf, it.stats.DocLen(it.doc), it.avgDocLen)
+}
+
+func (it *segmentIterator) Positions() []int32 { return it.cursor.positions() }
+
+// MaxScore tf趋于无穷时BM25TermScore趋近于idf*(k1+1)
+func (it *segmentIterator) MaxScore() float64 {
+	return it.idf * (BM25_K1 + 1)
+}
+
+// multiSegmentIterator 同一个keyword分布在多个段上。各段上有效的文档互不重复，所以得分上界是各段上界的最大值而不是和
+type multiSegmentIterator struct {
+	*disjunctionIterator
+}
+
+func (it *multiSegmentIterator) MaxScore() float64 {
+	var score float64
+	for _, child := range it.children {
+		score = max(score, child.MaxScore())
+	}
+	return score
+}
+
+// Positions 各段上有效的文档互不重复，当前文档只会在一个段上
+func (it *multiSegmentIterator) Positions() []int32 {
+	for _, child := range it.children {
+		if child.DocId() == it.doc {
+			return child.(*segmentIterator).Positions()
+		}
+	}
+	return nil
+}
+
+// 返回创建叶子迭代器的函数。调用方需要持有读锁
+func (indexer *SegmentReverseIndex) leafOpener(onFlag uint64, offFlag uint64, orFlags []uint64) LeafOpener {
+	segments := indexer.allSegments()
+	docCount := indexer.stats.DocCount()
+	avgDocLen := indexer.stats.AvgDocLen()
+	filter := func(bits uint64) bool {
+		return matchBits(bits, onFlag, offFlag, orFlags)
+	}
+	openKeyword := func(keyword *types.Keyword, filtered bool) PostingIterator {
+		key := keyword.ToString()
+		children := make([]*segmentIterator, 0, len(segments))
+		docFreq := 0
+		for _, seg := range segments {
+			if cursor, count := seg.postings(key); cursor != nil {
+				tomb := seg.tombstones()[key]
+				if tomb != nil {
+					count -= tomb.Cardinality()
+				}
+				it := &segmentIterator{seg: seg, cursor: cursor, count: count, tomb: tomb, latest: indexer.latest, stats: indexer.stats, avgDocLen: avgDocLen}
+				if filtered {
+					it.filter = filter
+				}
+				children = append(children, it)
+				docFreq += count
+			}
+		}
+		if len(children) == 0 {
+			return &emptyIterator{}
+		}
+		its := make([]PostingIterator, 0, len(children))
+		for _, it := range children {
+			it.idf = BM25Idf(docCount, docFreq)
+			its = append(its, it)
+		}
+		if len(its) == 1 {
+			return its[0]
+		}
+		return &multiSegmentIterator{newDisjunctionIterator(its)}
+	}
+	return leafOpener{openKeyword: openKeyword, numerics: indexer.numerics, filter: filter}
+}
+
+// Search 把查询翻译成迭代器树，各段上的倒排链边解码边求交、并、差集
+func (indexer *SegmentReverseIndex) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return CollectAll(BuildIterator(PlanQuery(query, open), open))
+}
+
+// Count 只遍历迭代器树计数，不打分
+func (indexer *SegmentReverseIndex) Count(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return CountAll(BuildIterator(PlanQuery(query, open), open))
+}
+
+// Explain 在迭代器树上逐个节点统计文档数和耗时
+func (indexer *SegmentReverseIndex) Explain(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64, intId uint64) *Explanation {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return ExplainQuery(PlanQuery(query, open), open, intId)
+}
+
+// SearchTopK 只返回得分最高的k个结果，结果跟Search的前k个完全一致
+func (indexer *SegmentReverseIndex) SearchTopK(query *types.TermQuery, k int, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	if k <= 0 || query == nil {
+		return nil
+	}
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return SearchTopK(PlanQuery(query, open), k, open)
+}
+
+// Expand 检索前先做的展开，返回给调用方用于高亮
+func (indexer *SegmentReverseIndex) Expand(query *types.TermQuery) *types.TermQuery {
+	return ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+}
+
+func (indexer *SegmentReverseIndex) Terms(field string, prefix string, limit int) []string {
+	return indexer.dict.Prefix(field, prefix, limit)
+}
+
+func (indexer *SegmentReverseIndex) Numeric(intId uint64, field string) (int64, bool) {
+	return indexer.numerics.Get(intId, field)
+}
+
+// Facets 遍历迭代器树统计命中的文档和bits，不打分；再拿field下每个word在各段上的倒排链跟命中的文档求交集
+func (indexer *SegmentReverseIndex) Facets(query *types.TermQuery, field string, onFlag uint64, offFlag uint64, orFlags []uint64) *Facets {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	words := facetWords(indexer.dict, field)
+	indexer.lock.RLock()
+	defer indexer.lock.RUnlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	facets, intIds := collectMatches(BuildIterator(PlanQuery(query, open), open), indexer.stats)
+	countFacetValues(facets, intIds, field, words, indexer.leafOpener(0, 0, nil))
+	return facets
+}
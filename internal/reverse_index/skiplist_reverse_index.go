@@ -4,67 +4,177 @@ import (
 	"github.com/Muoshu/myRadic/types"
 	"github.com/Muoshu/myRadic/util"
 	"github.com/huandu/skiplist"
-	farmhash "github.com/leemcloughlin/gofarmhash"
 	"runtime"
-	"sync"
+	"sort"
 )
 
 // SkipListReverseIndex 倒排索引整体上是个map，map的value是一个SkipList
 type SkipListReverseIndex struct {
-	table *util.ConcurrentHashMap //分段map，并发安全
-	locks []sync.RWMutex          //修改倒排索引时，相同的key需要去竞争同一把锁
+	table    *util.ConcurrentHashMap //分段map，并发安全
+	locks    keyLocks                //修改倒排索引时，相同的key需要去竞争同一把锁
+	stats    *DocStats               //BM25打分需要的全局统计量
+	dict     *TermDict               //倒排链非空的key，前缀、通配符展开用
+	numerics *NumericStore           //文档的数值字段，Range查询用
 }
 
 func NewSkipListReverseIndex(docNum int) *SkipListReverseIndex {
 	indexer := new(SkipListReverseIndex)
 	indexer.table = util.NewConcurrentHashMap(runtime.NumCPU(), docNum)
-	indexer.locks = make([]sync.RWMutex, 1000)
+	indexer.locks = make(keyLocks, 1000)
+	indexer.stats = NewDocStats(docNum)
+	indexer.dict = NewTermDict()
+	indexer.numerics = NewNumericStore(docNum)
 	return indexer
 }
 
-func (indexer *SkipListReverseIndex) getLock(key string) *sync.RWMutex {
-	n := int(farmhash.Hash32WithSeed([]byte(key), 0))
-	return &indexer.locks[n%len(indexer.locks)]
-}
-
 type SkipListValue struct {
 	Id         string //业务Id
 	BitFeature uint64
+	TermFreq   int     //keyword在文档中出现的次数
+	Positions  []int32 //keyword在文档中出现的位置，短语和邻近查询用
+	Score      float64 //检索时算出的BM25得分，建索引时不用管
 }
 
 func (indexer *SkipListReverseIndex) Add(doc types.Document) {
-	for _, keyword := range doc.Keywords {
-		key := keyword.ToString()
-		lock := indexer.getLock(key)
+	indexer.AddBatch([]types.Document{doc})
+}
+
+// AddBatch 同一个keyword在文档中可能出现多次，合并成一条posting，出现次数即词频
+func (indexer *SkipListReverseIndex) AddBatch(docs []types.Document) {
+	groups := groupPostings(docs, func(doc *types.Document, termPositions map[string][]int32) {
+		indexer.stats.Add(doc.IntId, len(doc.Keywords), len(termPositions), doc.BitsFeature)
+		indexer.numerics.Add(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+	})
+	for key, postings := range groups {
+		lock := indexer.locks.getLock(key)
 		lock.Lock()
-		skpVal := SkipListValue{doc.Id, doc.BitsFeature}
+		var list *skiplist.SkipList
 		if val, ok := indexer.table.Get(key); ok {
-			list := val.(*skiplist.SkipList)
-			list.Set(doc.IntId, skpVal) //IntId作为SkipList的key，而value里则包含了业务侧的文档id和BitsFeature
+			list = val.(*skiplist.SkipList)
 		} else {
-			list := skiplist.New(skiplist.Uint64)
-			list.Set(doc.IntId, skpVal)
+			list = skiplist.New(skiplist.Uint64)
 			indexer.table.Set(key, list)
 		}
+		if list.Len() == 0 {
+			indexer.dict.Add(key)
+		}
+		for _, posting := range postings {
+			doc := posting.doc
+			//IntId作为SkipList的key，而value里则包含了业务侧的文档id和BitsFeature
+			list.Set(doc.IntId, SkipListValue{Id: doc.Id, BitFeature: doc.BitsFeature, TermFreq: len(posting.positions), Positions: posting.positions})
+		}
 		lock.Unlock()
+	}
+}
+
+func (indexer *SkipListReverseIndex) Delete(IntId uint64, keyword *types.Keyword) {
+	indexer.DeleteBatch([]*types.Document{{IntId: IntId, Keywords: []*types.Keyword{keyword}}})
+}
 
+func (indexer *SkipListReverseIndex) DeleteBatch(docs []*types.Document) {
+	var removed []uint64 //所有posting都删掉了的文档
+	for key, intIds := range groupDeletes(docs) {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		if val, ok := indexer.table.Get(key); ok {
+			list := val.(*skiplist.SkipList)
+			changed := false
+			for _, intId := range intIds {
+				if list.Remove(intId) != nil {
+					changed = true
+					if indexer.stats.RemovePosting(intId) {
+						removed = append(removed, intId)
+					}
+				}
+			}
+			if changed && list.Len() == 0 {
+				indexer.dict.Remove(key)
+			}
+		}
+		lock.Unlock()
+	}
+	for _, intId := range removed {
+		indexer.numerics.Remove(intId)
 	}
+}
 
+// Update 文档的IntId不变，只改动受影响的posting。BitsFeature存在每条posting上，变了时所有posting都要重写
+func (indexer *SkipListReverseIndex) Update(old *types.Document, doc types.Document) {
+	oldPositions, newPositions := keywordPositions(*old), keywordPositions(doc)
+	if len(oldPositions) == 0 || len(newPositions) == 0 { //没有keyword的文档不在倒排索引上
+		indexer.DeleteBatch([]*types.Document{old})
+		indexer.AddBatch([]types.Document{doc})
+		return
+	}
+	removed, changed := diffPostings(oldPositions, newPositions, old.BitsFeature != doc.BitsFeature)
+	indexer.stats.Add(doc.IntId, len(doc.Keywords), len(newPositions), doc.BitsFeature)
+	indexer.numerics.Update(doc.IntId, doc.Id, doc.BitsFeature, doc.Numerics)
+	for _, key := range removed {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		if val, ok := indexer.table.Get(key); ok {
+			list := val.(*skiplist.SkipList)
+			if list.Remove(doc.IntId) != nil && list.Len() == 0 {
+				indexer.dict.Remove(key)
+			}
+		}
+		lock.Unlock()
+	}
+	for key, positions := range changed {
+		lock := indexer.locks.getLock(key)
+		lock.Lock()
+		var list *skiplist.SkipList
+		if val, ok := indexer.table.Get(key); ok {
+			list = val.(*skiplist.SkipList)
+		} else {
+			list = skiplist.New(skiplist.Uint64)
+			indexer.table.Set(key, list)
+		}
+		if list.Len() == 0 {
+			indexer.dict.Add(key)
+		}
+		list.Set(doc.IntId, SkipListValue{Id: doc.Id, BitFeature: doc.BitsFeature, TermFreq: len(positions), Positions: positions})
+		lock.Unlock()
+	}
 }
 
-func (indexer *SkipListReverseIndex) Delete(IntId uint64, keyword *types.Keyword) {
-	key := keyword.ToString()
-	lock := indexer.getLock(key)
-	lock.Lock()
-	if val, ok := indexer.table.Get(key); ok {
-		list := val.(*skiplist.SkipList)
-		list.Remove(IntId)
+// 把多个节点上的BM25得分累加到第一个节点的value上
+func sumScore(nodes []*skiplist.Element) any {
+	val := nodes[0].Value
+	skpVal, ok := val.(SkipListValue)
+	if !ok {
+		return val
+	}
+	for _, node := range nodes[1:] {
+		if v, ok := node.Value.(SkipListValue); ok {
+			skpVal.Score += v.Score
+		}
 	}
-	lock.Unlock()
+	return skpVal
+}
 
+// 跳跃查找前先线性试探的步数。目标离得近时线性前进比从头查找更快
+const linearProbeSteps = 8
+
+// SeekSkipList 从node开始往后找第一个key>=target的元素，找不到返回nil。
+// 先线性试探几步，目标离得远时再借助跳表的多层索引从当前位置往后查找，复杂度O(log(N))
+func SeekSkipList(list *skiplist.SkipList, node *skiplist.Element, target uint64) *skiplist.Element {
+	for i := 0; i < linearProbeSteps && node != nil; i++ {
+		if node.Key().(uint64) >= target {
+			return node
+		}
+		node = node.Next()
+	}
+	if node == nil || node.Key().(uint64) >= target {
+		return node
+	}
+	return list.FindNext(node, target)
 }
 
-// 多个跳表求交集
+// 多个跳表求交集，交集元素的得分是各跳表上得分之和。
+//
+// 以最短的跳表为驱动，依次取出它的每个元素，到其他跳表上跳跃查找。某个跳表上找到的元素比目标大时，驱动表直接跳到该元素处。
+// 当某个跳表很短而其他跳表很长时，复杂度约为O(m*log(N))，m为最短跳表的长度
 func IntersectionOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 	if len(lists) == 0 {
 		return nil
@@ -73,8 +183,7 @@ func IntersectionOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 		return lists[0]
 	}
 
-	res := skiplist.New(skiplist.Uint64)
-	//给每条SkipList分配一个指针，从前往后遍历
+	//每条SkipList上有一个指针，只会往后移动
 	currNodes := make([]*skiplist.Element, len(lists))
 	for i, list := range lists {
 		if list == nil || list.Len() == 0 {
@@ -82,44 +191,39 @@ func IntersectionOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 		}
 		currNodes[i] = list.Front()
 	}
+	//按长度从短到长排列。currNodes保持原来的顺序，累加得分时跟参数顺序一致
+	order := make([]int, len(lists))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return lists[order[i]].Len() < lists[order[j]].Len() })
 
-	for {
-		//此刻，哪个指针对应的值最大（最大者可能存在多个，所以用map）
-		maxList := make(map[int]struct{}, len(currNodes))
-		var maxValue uint64 = 0
-		for i, node := range currNodes {
-			if node.Key().(uint64) > maxValue {
-				maxValue = node.Key().(uint64)
-				maxList = map[int]struct{}{i: {}} //可以用一对大括号表示空结构体实例
-			} else if node.Key().(uint64) == maxValue {
-				maxList[i] = struct{}{}
+	res := skiplist.New(skiplist.Uint64)
+	lead := order[0]
+	for currNodes[lead] != nil {
+		target := currNodes[lead].Key().(uint64)
+		matched := true
+		for _, i := range order[1:] {
+			currNodes[i] = SeekSkipList(lists[i], currNodes[i], target)
+			if currNodes[i] == nil {
+				return res
 			}
-		}
-		//所有node的值都一样大，则新诞生一个交集
-		if len(maxList) == len(currNodes) {
-			res.Set(currNodes[0].Key(), currNodes[0].Value)
-			for i, node := range currNodes {
-				if node.Next() == nil {
-					return res
-				}
-				currNodes[i] = node.Next()
-			}
-		} else {
-			for i, node := range currNodes {
-				//值大的不动，小的往后移
-				if _, ok := maxList[i]; !ok {
-					if node.Next() == nil {
-						return res
-					}
-					currNodes[i] = node.Next()
-
-				}
+			if key := currNodes[i].Key().(uint64); key != target {
+				//比target小的元素都不可能在交集里，驱动表跳过去
+				currNodes[lead] = SeekSkipList(lists[lead], currNodes[lead], key)
+				matched = false
+				break
 			}
 		}
+		if matched {
+			res.Set(target, sumScore(currNodes))
+			currNodes[lead] = currNodes[lead].Next()
+		}
 	}
+	return res
 }
 
-// 多个跳表求并集
+// 多个跳表求并集，元素的得分是它所在的各跳表上得分之和
 func UnionSetOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 	if len(lists) == 0 {
 		return nil
@@ -129,16 +233,17 @@ func UnionSetOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 	}
 
 	res := skiplist.New(skiplist.Uint64)
-	keySet := make(map[any]struct{}, 1000)
+	keySet := make(map[any]*skiplist.Element, 1000)
 	for _, list := range lists {
 		if list == nil {
 			continue
 		}
 		node := list.Front()
 		for node != nil {
-			if _, ok := keySet[node.Key()]; !ok {
-				res.Set(node.Key(), node.Value)
-				keySet[node.Key()] = struct{}{}
+			if elem, ok := keySet[node.Key()]; !ok {
+				keySet[node.Key()] = res.Set(node.Key(), node.Value)
+			} else {
+				elem.Value = sumScore([]*skiplist.Element{elem, node})
 			}
 			node = node.Next()
 		}
@@ -146,7 +251,33 @@ func UnionSetOfSkipList(lists ...*skiplist.SkipList) *skiplist.SkipList {
 	return res
 }
 
+// 跳表求差集，返回在list里但不在exclude里的元素
+func DifferenceOfSkipList(list, exclude *skiplist.SkipList) *skiplist.SkipList {
+	if list == nil || exclude == nil || exclude.Len() == 0 {
+		return list
+	}
+	res := skiplist.New(skiplist.Uint64)
+	excludeNode := exclude.Front()
+	for node := list.Front(); node != nil; node = node.Next() {
+		key := node.Key().(uint64)
+		//两个跳表都是有序的，exclude的指针只需要往后移
+		for excludeNode != nil && excludeNode.Key().(uint64) < key {
+			excludeNode = excludeNode.Next()
+		}
+		if excludeNode != nil && excludeNode.Key().(uint64) == key {
+			continue
+		}
+		res.Set(key, node.Value)
+	}
+	return res
+}
+
 func (indexer SkipListReverseIndex) FilterByBits(bits uint64, onFlag uint64, offFlag uint64, orFlags []uint64) bool {
+	return matchBits(bits, onFlag, offFlag, orFlags)
+}
+
+// 判断文档的bits是否满足onFlag、offFlag、orFlags的要求
+func matchBits(bits uint64, onFlag uint64, offFlag uint64, orFlags []uint64) bool {
 	//onFlag所有bit必须全部命中
 	if bits&onFlag != onFlag {
 		return false
@@ -164,51 +295,189 @@ func (indexer SkipListReverseIndex) FilterByBits(bits uint64, onFlag uint64, off
 	return true
 }
 
-func (indexer SkipListReverseIndex) search(q *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) *skiplist.SkipList {
-	if q.Keyword != nil {
-		keyword := q.Keyword.ToString()
-		if val, ok := indexer.table.Get(keyword); ok {
-			res := skiplist.New(skiplist.Uint64)
-			list := val.(*skiplist.SkipList)
-			node := list.Front()
-			for node != nil {
-				intId := node.Key().(uint64)
-				skpVal, _ := node.Value.(SkipListValue)
-				flag := skpVal.BitFeature
-				if intId > 0 && indexer.FilterByBits(flag, onFlag, offFlag, orFlags) { //确保有效元素都大于0
-					res.Set(intId, skpVal)
-				}
-				node = node.Next()
-			}
-			return res
+// 是否没有任何bits过滤条件
+func noBitsFilter(onFlag uint64, offFlag uint64, orFlags []uint64) bool {
+	for _, orFlag := range orFlags {
+		if orFlag > 0 {
+			return false
 		}
-	} else if len(q.Must) > 0 {
-		res := make([]*skiplist.SkipList, 0, len(q.Must))
-		for _, q := range q.Must {
-			res = append(res, indexer.search(q, onFlag, offFlag, orFlags))
+	}
+	return onFlag == 0 && offFlag == 0
+}
+
+// skipListIterator 遍历单个keyword的倒排链。bits过滤下推到这里，不满足条件的文档直接跳过
+type skipListIterator struct {
+	list      *skiplist.SkipList
+	node      *skiplist.Element
+	doc       uint64
+	filter    func(bits uint64) bool //为nil时不过滤
+	stats     *DocStats
+	idf       float64
+	avgDocLen float64
+}
+
+func (it *skipListIterator) DocId() uint64 { return it.doc }
+
+func (it *skipListIterator) Next() uint64 {
+	if it.doc == NO_MORE_DOCS {
+		return it.doc
+	}
+	if it.node == nil {
+		it.node = it.list.Front()
+	} else {
+		it.node = it.node.Next()
+	}
+	return it.skipFiltered()
+}
+
+func (it *skipListIterator) Advance(target uint64) uint64 {
+	if it.doc == NO_MORE_DOCS || target == NO_MORE_DOCS {
+		it.doc = NO_MORE_DOCS
+		return it.doc
+	}
+	if it.node == nil { //还没开始遍历
+		it.node = it.list.Front()
+	}
+	it.node = SeekSkipList(it.list, it.node, target)
+	return it.skipFiltered()
+}
+
+// 从当前节点开始，跳过IntId无效或bits不满足条件的节点
+func (it *skipListIterator) skipFiltered() uint64 {
+	for it.node != nil {
+		intId := it.node.Key().(uint64)
+		skpVal, _ := it.node.Value.(SkipListValue)
+		if intId > 0 && (it.filter == nil || it.filter(skpVal.BitFeature)) { //确保有效元素都大于0
+			it.doc = intId
+			return it.doc
 		}
-		return IntersectionOfSkipList(res...)
-	} else if len(q.Should) > 0 {
-		res := make([]*skiplist.SkipList, 0, len(q.Should))
-		for _, q := range q.Should {
-			res = append(res, indexer.search(q, onFlag, offFlag, orFlags))
+		it.node = it.node.Next()
+	}
+	it.doc = NO_MORE_DOCS
+	return it.doc
+}
+
+func (it *skipListIterator) Cost() int64 { return int64(it.list.Len()) }
+
+func (it *skipListIterator) Id() string {
+	skpVal, _ := it.node.Value.(SkipListValue)
+	return skpVal.Id
+}
+
+func (it *skipListIterator) Score() float64 {
+	skpVal, _ := it.node.Value.(SkipListValue)
+	return BM25TermScore(it.idf, skpVal.TermFreq, it.stats.DocLen(it.doc), it.avgDocLen)
+}
+
+func (it *skipListIterator) Positions() []int32 {
+	skpVal, _ := it.node.Value.(SkipListValue)
+	return skpVal.Positions
+}
+
+// MaxScore tf趋于无穷时BM25TermScore趋近于idf*(k1+1)
+func (it *skipListIterator) MaxScore() float64 {
+	return it.idf * (BM25_K1 + 1)
+}
+
+// 返回创建叶子迭代器的函数。调用方需要事先对用到的keyword加读锁
+func (indexer SkipListReverseIndex) leafOpener(onFlag uint64, offFlag uint64, orFlags []uint64) LeafOpener {
+	docCount := indexer.stats.DocCount()
+	avgDocLen := indexer.stats.AvgDocLen()
+	filter := func(bits uint64) bool {
+		return indexer.FilterByBits(bits, onFlag, offFlag, orFlags)
+	}
+	openKeyword := func(keyword *types.Keyword, filtered bool) PostingIterator {
+		val, ok := indexer.table.Get(keyword.ToString())
+		if !ok {
+			return &emptyIterator{}
+		}
+		list := val.(*skiplist.SkipList)
+		it := &skipListIterator{
+			list:      list,
+			stats:     indexer.stats,
+			idf:       BM25Idf(docCount, list.Len()), //list的长度就是包含该keyword的文档数
+			avgDocLen: avgDocLen,
+		}
+		if filtered {
+			it.filter = filter
 		}
-		return UnionSetOfSkipList(res...)
+		return it
 	}
-	return nil
+	return leafOpener{openKeyword: openKeyword, numerics: indexer.numerics, filter: filter}
+}
+
+// Search 把查询翻译成迭代器树，边遍历边求交、并、差集，中间不生成任何跳表
+func (indexer SkipListReverseIndex) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return CollectAll(BuildIterator(PlanQuery(query, open), open))
+}
+
+// Count 只遍历迭代器树计数，不打分
+func (indexer SkipListReverseIndex) Count(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) int {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return CountAll(BuildIterator(PlanQuery(query, open), open))
 }
 
-func (indexer SkipListReverseIndex) Search(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64) []string {
-	res := indexer.search(query, onFlag, offFlag, orFlags)
-	if res == nil {
+// Explain 在迭代器树上逐个节点统计文档数和耗时
+func (indexer SkipListReverseIndex) Explain(query *types.TermQuery, onFlag uint64, offFlag uint64, orFlags []uint64, intId uint64) *Explanation {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return ExplainQuery(PlanQuery(query, open), open, intId)
+}
+
+// SearchTopK 只返回得分最高的k个结果，结果跟Search的前k个完全一致
+func (indexer SkipListReverseIndex) SearchTopK(query *types.TermQuery, k int, onFlag uint64, offFlag uint64, orFlags []uint64) []SearchHit {
+	if k <= 0 || query == nil {
 		return nil
 	}
-	arr := make([]string, 0, res.Len())
-	node := res.Front()
-	for node != nil {
-		skpVal, _ := node.Value.(SkipListValue)
-		arr = append(arr, skpVal.Id)
-		node = node.Next()
-	}
-	return arr
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	unlock := indexer.locks.rLockQuery(query)
+	defer unlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	return SearchTopK(PlanQuery(query, open), k, open)
+}
+
+// Expand 检索前先做的展开，返回给调用方用于高亮
+func (indexer SkipListReverseIndex) Expand(query *types.TermQuery) *types.TermQuery {
+	return ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+}
+
+func (indexer SkipListReverseIndex) Terms(field string, prefix string, limit int) []string {
+	return indexer.dict.Prefix(field, prefix, limit)
+}
+
+func (indexer SkipListReverseIndex) Numeric(intId uint64, field string) (int64, bool) {
+	return indexer.numerics.Get(intId, field)
+}
+
+// Facets 遍历迭代器树统计命中的文档和bits，不打分；再拿field下每个word的倒排链跟命中的文档求交集。
+// 查询和field用到的keyword一次性加读锁，两步看到的是同一份数据
+func (indexer SkipListReverseIndex) Facets(query *types.TermQuery, field string, onFlag uint64, offFlag uint64, orFlags []uint64) *Facets {
+	query = ExpandQuery(query, indexer.dict, MAX_EXPANSIONS)
+	words := facetWords(indexer.dict, field)
+	unlock := indexer.locks.rLockQuery(query, facetKeys(field, words)...)
+	defer unlock()
+	open := indexer.leafOpener(onFlag, offFlag, orFlags)
+	facets, intIds := collectMatches(BuildIterator(PlanQuery(query, open), open), indexer.stats)
+	countFacetValues(facets, intIds, field, words, indexer.leafOpener(0, 0, nil))
+	return facets
+}
+
+// SortHits 按得分从高到低排序，得分相同时按业务Id从小到大。
+// 不用IntId打破平局：各分片的IntId互不可比，Sentinel归并时也是按业务Id打破平局，两边必须一致
+func SortHits(hits []SearchHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Id < hits[j].Id
+	})
 }
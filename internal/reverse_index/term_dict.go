@@ -0,0 +1,305 @@
+package reverse_index
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// TermDict 有序的term字典，跟倒排索引的map放在一起，用来做前缀、通配符展开和自动补全。
+// 底层是压缩前缀树(radix tree)，公共前缀只存一份，按字节序遍历即得到排好序的term
+type TermDict struct {
+	lock sync.RWMutex
+	root trieNode
+	size int
+}
+
+type trieNode struct {
+	label    string      //从父节点到本节点这条边上的字节
+	children []*trieNode //按label的首字节从小到大排列
+	term     bool        //从根到本节点是否构成一个完整的term
+}
+
+func NewTermDict() *TermDict {
+	return new(TermDict)
+}
+
+// 首字节为b的孩子的下标，不存在时返回应该插入的位置和false
+func (node *trieNode) find(b byte) (int, bool) {
+	i := sort.Search(len(node.children), func(i int) bool { return node.children[i].label[0] >= b })
+	return i, i < len(node.children) && node.children[i].label[0] == b
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// Add 添加一个term(倒排索引里的key)，已存在时什么也不做
+func (dict *TermDict) Add(key string) {
+	dict.lock.Lock()
+	defer dict.lock.Unlock()
+	node := &dict.root
+	for len(key) > 0 {
+		i, ok := node.find(key[0])
+		if !ok {
+			node.children = append(node.children, nil)
+			copy(node.children[i+1:], node.children[i:])
+			node.children[i] = &trieNode{label: key, term: true}
+			dict.size++
+			return
+		}
+		child := node.children[i]
+		n := commonPrefixLen(child.label, key)
+		if n < len(child.label) { //边只匹配了一部分，从中间劈开
+			mid := &trieNode{label: child.label[:n], children: []*trieNode{child}}
+			child.label = child.label[n:]
+			node.children[i] = mid
+			child = mid
+		}
+		node = child
+		key = key[n:]
+	}
+	if !node.term {
+		node.term = true
+		dict.size++
+	}
+}
+
+// Remove 删除一个term。倒排链上没有文档时调用
+func (dict *TermDict) Remove(key string) {
+	dict.lock.Lock()
+	defer dict.lock.Unlock()
+	if dict.root.remove(key) {
+		dict.size--
+	}
+}
+
+func (node *trieNode) remove(key string) bool {
+	if len(key) == 0 {
+		if !node.term {
+			return false
+		}
+		node.term = false
+		return true
+	}
+	i, ok := node.find(key[0])
+	if !ok {
+		return false
+	}
+	child := node.children[i]
+	if !strings.HasPrefix(key, child.label) || !child.remove(key[len(child.label):]) {
+		return false
+	}
+	//删掉空的叶子，只有一个孩子的中间节点跟孩子合并
+	if !child.term {
+		switch len(child.children) {
+		case 0:
+			node.children = append(node.children[:i], node.children[i+1:]...)
+		case 1:
+			grandchild := child.children[0]
+			grandchild.label = child.label + grandchild.label
+			node.children[i] = grandchild
+		}
+	}
+	return true
+}
+
+// Len term的个数
+func (dict *TermDict) Len() int {
+	dict.lock.RLock()
+	defer dict.lock.RUnlock()
+	return dict.size
+}
+
+// 按字典序遍历所有以prefix开头的term，fun返回false时停止
+func (dict *TermDict) walkPrefix(prefix string, fun func(key string) bool) {
+	dict.lock.RLock()
+	defer dict.lock.RUnlock()
+	node := &dict.root
+	path := make([]byte, 0, 64)
+	for len(prefix) > 0 {
+		i, ok := node.find(prefix[0])
+		if !ok {
+			return
+		}
+		child := node.children[i]
+		if strings.HasPrefix(prefix, child.label) {
+			prefix = prefix[len(child.label):]
+		} else if strings.HasPrefix(child.label, prefix) { //prefix在这条边的中间结束
+			prefix = ""
+		} else {
+			return
+		}
+		path = append(path, child.label...)
+		node = child
+	}
+	node.walk(path, fun)
+}
+
+func (node *trieNode) walk(path []byte, fun func(key string) bool) bool {
+	if node.term && !fun(string(path)) {
+		return false
+	}
+	for _, child := range node.children {
+		if !child.walk(append(path, child.label...), fun) {
+			return false
+		}
+	}
+	return true
+}
+
+func fieldPrefix(field string) string {
+	return field + "\001"
+}
+
+// Prefix field里以prefix开头的word，按字典序排列。limit<=0时不限个数
+func (dict *TermDict) Prefix(field, prefix string, limit int) []string {
+	fp := fieldPrefix(field)
+	words := make([]string, 0)
+	dict.walkPrefix(fp+prefix, func(key string) bool {
+		words = append(words, key[len(fp):])
+		return limit <= 0 || len(words) < limit
+	})
+	return words
+}
+
+// Wildcard field里符合pattern的word，按字典序排列。pattern里*匹配任意个字符，?匹配一个字符。limit<=0时不限个数
+func (dict *TermDict) Wildcard(field, pattern string, limit int) []string {
+	fp := fieldPrefix(field)
+	literal := pattern
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		literal = pattern[:i] //只遍历通配符前面那段固定前缀下的子树
+	}
+	words := make([]string, 0)
+	dict.walkPrefix(fp+literal, func(key string) bool {
+		word := key[len(fp):]
+		if matchWildcard(pattern, word) {
+			words = append(words, word)
+		}
+		return limit <= 0 || len(words) < limit
+	})
+	return words
+}
+
+// matchWildcard 按字符(而不是字节)匹配，这样?能匹配一个汉字。遇到*时记下位置，后面匹配失败就回到这里让*多吃一个字符
+func matchWildcard(pattern, s string) bool {
+	p, i := 0, 0
+	starP, starI := -1, 0
+	for i < len(s) {
+		if p < len(pattern) && pattern[p] == '*' {
+			starP, starI = p, i
+			p++
+			continue
+		}
+		if p < len(pattern) {
+			pc, pn := utf8.DecodeRuneInString(pattern[p:])
+			sc, sn := utf8.DecodeRuneInString(s[i:])
+			if pc == '?' || pc == sc {
+				p += pn
+				i += sn
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		_, sn := utf8.DecodeRuneInString(s[starI:])
+		starI += sn
+		p, i = starP+1, starI
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// FuzzyTerm 模糊匹配到的word及其跟目标的编辑距离
+type FuzzyTerm struct {
+	Word     string
+	Distance int
+}
+
+// Fuzzy field里跟word的编辑距离不超过maxEdits的word，距离近的排在前面，距离相同时按字典序。limit<=0时不限个数。
+//
+// 沿着前缀树做深度优先遍历，每走过一个字符就在Levenshtein距离矩阵上往下算一行，相当于模拟Levenshtein自动机。
+// 公共前缀只算一次，某一行的最小值超过maxEdits时整棵子树都不可能匹配，直接剪掉
+func (dict *TermDict) Fuzzy(field, word string, maxEdits int, limit int) []FuzzyTerm {
+	search := &fuzzySearch{fp: fieldPrefix(field), target: []rune(word), maxEdits: maxEdits}
+	row := make([]int, len(search.target)+1) //空串到target各前缀的距离
+	for i := range row {
+		row[i] = i
+	}
+	dict.lock.RLock()
+	search.walk(&dict.root, make([]byte, 0, 64), len(search.fp), row)
+	dict.lock.RUnlock()
+	terms := search.terms
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Distance != terms[j].Distance {
+			return terms[i].Distance < terms[j].Distance
+		}
+		return terms[i].Word < terms[j].Word
+	})
+	if limit > 0 && len(terms) > limit {
+		terms = terms[:limit]
+	}
+	return terms
+}
+
+type fuzzySearch struct {
+	fp       string //field前缀，必须完全匹配
+	target   []rune
+	maxEdits int
+	terms    []FuzzyTerm
+}
+
+// path是从根到node的字节。runeStart是word部分还没凑成完整字符的字节从哪开始(多字节字符可能被劈在两条边上)，
+// row是path里最后一个完整字符对应的那一行
+func (s *fuzzySearch) walk(node *trieNode, path []byte, runeStart int, row []int) {
+	if node.term && len(path) >= len(s.fp) && runeStart == len(path) && row[len(row)-1] <= s.maxEdits {
+		s.terms = append(s.terms, FuzzyTerm{Word: string(path[len(s.fp):]), Distance: row[len(row)-1]})
+	}
+	for _, child := range node.children {
+		childPath, childStart, childRow := path, runeStart, row
+		matched := true
+		for i := 0; i < len(child.label) && matched; i++ {
+			b := child.label[i]
+			childPath = append(childPath, b)
+			if len(childPath) <= len(s.fp) { //还在field前缀上
+				matched = b == s.fp[len(childPath)-1]
+				continue
+			}
+			if !utf8.FullRune(childPath[childStart:]) {
+				continue
+			}
+			c, _ := utf8.DecodeRune(childPath[childStart:])
+			childStart = len(childPath)
+			childRow = s.nextRow(childRow, c)
+			matched = slices.Min(childRow) <= s.maxEdits
+		}
+		if matched {
+			s.walk(child, childPath, childStart, childRow)
+		}
+	}
+}
+
+// 在prev(已匹配部分到target各前缀的距离)的基础上再多匹配一个字符c
+func (s *fuzzySearch) nextRow(prev []int, c rune) []int {
+	row := make([]int, len(prev))
+	row[0] = prev[0] + 1
+	for j, t := range s.target {
+		cost := 1
+		if t == c {
+			cost = 0
+		}
+		row[j+1] = min(row[j]+1, prev[j+1]+1, prev[j]+cost)
+	}
+	return row
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"testing"
+)
+
+func newDoc(id string, intId uint64, words ...string) types.Document {
+	keywords := make([]*types.Keyword, 0, len(words))
+	for _, word := range words {
+		keywords = append(keywords, &types.Keyword{Field: "content", Word: word})
+	}
+	return types.Document{Id: id, IntId: intId, Keywords: keywords}
+}
+
+func TestBM25Order(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newDoc("a", 1, "go", "java", "python", "c"))
+		indexer.Add(newDoc("b", 2, "go", "go", "go", "rust")) //go的词频高
+		indexer.Add(newDoc("c", 3, "java", "rust"))
+		indexer.Add(newDoc("d", 4, "python"))
+
+		hits := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil)
+		if len(hits) != 2 || hits[0].Id != "b" || hits[1].Id != "a" {
+			t.Fatalf("词频高的文档应排在前面 %v", hits)
+		}
+
+		//b同时命中go和rust，得分应最高
+		q := types.NewTermQuery("content", "go").Or(types.NewTermQuery("content", "rust"))
+		hits = indexer.Search(q, 0, 0, nil)
+		if len(hits) != 3 || hits[0].Id != "b" {
+			t.Fatalf("同时命中多个词的文档应排在最前面 %v", hits)
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Fatalf("结果没有按得分降序 %v", hits)
+			}
+		}
+
+		//交集的得分是各子句得分之和
+		single := indexer.Search(types.NewTermQuery("content", "rust"), 0, 0, nil)
+		both := indexer.Search(types.NewTermQuery("content", "java").And(types.NewTermQuery("content", "rust")), 0, 0, nil)
+		if len(both) != 1 || both[0].Id != "c" {
+			t.Fatalf("交集结果错误 %v", both)
+		}
+		var rustScore float64
+		for _, hit := range single {
+			if hit.Id == "c" {
+				rustScore = hit.Score
+			}
+		}
+		if both[0].Score <= rustScore {
+			t.Fatalf("交集得分%f应大于单个子句的得分%f", both[0].Score, rustScore)
+		}
+	})
+}
+
+func TestBM25DeleteStats(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		doc := newDoc("a", 1, "go", "go", "java")
+		indexer.Add(doc)
+		indexer.Add(newDoc("b", 2, "java"))
+		for _, keyword := range doc.Keywords { //keyword重复出现时，重复删除不应出错
+			indexer.Delete(doc.IntId, keyword)
+		}
+		if hits := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil); len(hits) != 0 {
+			t.Fatalf("文档已删除，却仍能检索到 %v", hits)
+		}
+		hits := indexer.Search(types.NewTermQuery("content", "java"), 0, 0, nil)
+		if len(hits) != 1 || hits[0].Id != "b" {
+			t.Fatalf("检索结果错误 %v", hits)
+		}
+	})
+}
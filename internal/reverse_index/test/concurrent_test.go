@@ -0,0 +1,67 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// 一边写入新的IntId，一边调用所有会遍历迭代器的读方法。配合go test -race检查读写之间有没有数据竞争
+func TestConcurrentReadWrite(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 4) //容量很小，写入时会扩容
+		newWordDoc := func(i int) types.Document {
+			doc := newDoc("doc"+strconv.Itoa(i), uint64(i), "go", "w"+strconv.Itoa(i%3))
+			doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: "a" + strconv.Itoa(i%2)})
+			doc.BitsFeature = uint64(1 << (i % 3))
+			return doc
+		}
+		for i := 1; i <= 20; i++ {
+			indexer.Add(newWordDoc(i))
+		}
+
+		query := types.NewTermQuery("content", "go").And(types.NewTermQuery("content", "w1").Or(types.NewTermQuery("content", "w2")))
+		//规划器和展开都会生成带MustNot的Keyword节点，被排除的keyword也要加读锁
+		exclude := &types.TermQuery{Keyword: &types.Keyword{Field: "content", Word: "go"}, MustNot: []*types.TermQuery{types.NewTermQuery("content", "w1")}}
+		reads := []func(){
+			func() { indexer.Search(exclude, 0, 0, nil) },
+			func() { indexer.Count(exclude, 0, 0, nil) },
+			func() { indexer.Search(query, 0, 0, []uint64{3}) },
+			func() { indexer.SearchTopK(query, 5, 0, 0, []uint64{3}) },
+			func() { indexer.Count(query, 0, 4, nil) },
+			func() { indexer.Explain(query, 0, 0, []uint64{3}, 2) },
+			func() { indexer.Facets(query, "author", 0, 0, []uint64{3}) },
+		}
+		var wg sync.WaitGroup
+		done := make(chan struct{})
+		for _, read := range reads {
+			wg.Add(1)
+			go func(read func()) {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						read()
+					}
+				}
+			}(read)
+		}
+		for i := 21; i <= 1500; i++ {
+			indexer.Add(newWordDoc(i))
+			if i%50 == 0 {
+				indexer.DeleteBatch([]*types.Document{{IntId: uint64(i - 30), Keywords: newWordDoc(i - 30).Keywords}})
+			}
+			if persistent, ok := indexer.(reverseindex.IPersistentReverseIndexer); ok && i%200 == 0 {
+				if err := persistent.Flush(); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+		close(done)
+		wg.Wait()
+	})
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// 参与一致性测试的倒排索引实现
+var indexTypes = []struct {
+	name      string
+	indexType int
+}{
+	{"skiplist", reverseindex.SKIPLIST},
+	{"roaring", reverseindex.ROARING},
+	{"segment", reverseindex.SEGMENT},
+}
+
+// 创建倒排索引，持久化的实现把数据放在临时目录里，测试结束时关闭
+func newReverseIndexer(tb testing.TB, indexType int, docNum int) reverseindex.IReverseIndexer {
+	indexer, err := reverseindex.GetReverseIndexer(indexType, docNum, tb.TempDir(), false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if persistent, ok := indexer.(reverseindex.IPersistentReverseIndexer); ok {
+		tb.Cleanup(func() { persistent.Close() })
+	}
+	return indexer
+}
+
+// 对每种倒排索引实现各跑一遍测试
+func forEachIndexType(t *testing.T, test func(t *testing.T, indexType int)) {
+	for _, it := range indexTypes {
+		t.Run(it.name, func(t *testing.T) {
+			test(t, it.indexType)
+		})
+	}
+}
+
+// 检索结果必须完全相同，包括得分的每一个bit
+func identicalHits(a, b []reverseindex.SearchHit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 向各种实现写入相同的数据(包括删除)，同一查询的检索结果必须跟跳表实现完全一致
+func TestReverseIndexConformance(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	indexers := make([]reverseindex.IReverseIndexer, 0, len(indexTypes))
+	for _, it := range indexTypes {
+		indexers = append(indexers, newReverseIndexer(t, it.indexType, 1000))
+	}
+	//IntId跨越多个container，且部分keyword足够密集，会用到bitmap容器
+	docs := make(map[uint64]types.Document, 20000)
+	for i := 1; i <= 20000; i++ {
+		intId := uint64(i)
+		if i%2 == 0 {
+			intId += 1 << 20
+		}
+		n := 1 + rnd.Intn(6)
+		words := make([]string, 0, n)
+		for j := 0; j < n; j++ {
+			words = append(words, "w"+strconv.Itoa(rnd.Intn(1+rnd.Intn(25))))
+		}
+		doc := newDoc("doc"+strconv.Itoa(i), intId, words...)
+		doc.BitsFeature = uint64(rnd.Intn(8))
+		docs[intId] = doc
+		for _, indexer := range indexers {
+			indexer.Add(doc)
+		}
+	}
+	for intId, doc := range docs {
+		if rnd.Intn(10) > 0 {
+			continue
+		}
+		for _, keyword := range doc.Keywords {
+			for _, indexer := range indexers {
+				indexer.Delete(intId, keyword)
+			}
+		}
+	}
+
+	flags := []struct {
+		onFlag, offFlag uint64
+		orFlags         []uint64
+	}{
+		{0, 0, nil},
+		{1, 0, nil},
+		{0, 2, []uint64{5}},
+	}
+	for round := 0; round < 200; round++ {
+		query := randomQuery(rnd, 3)
+		for _, flag := range flags {
+			expect := indexers[0].Search(query, flag.onFlag, flag.offFlag, flag.orFlags)
+			for i, indexer := range indexers {
+				if n := indexer.Count(query, flag.onFlag, flag.offFlag, flag.orFlags); n != len(expect) {
+					t.Fatalf("%s %s Count=%d，Search命中%d个", indexTypes[i].name, query.ToString(), n, len(expect))
+				}
+			}
+			for i, indexer := range indexers[1:] {
+				name := indexTypes[i+1].name
+				if got := indexer.Search(query, flag.onFlag, flag.offFlag, flag.orFlags); !identicalHits(expect, got) {
+					t.Fatalf("%s %s Search结果跟skiplist不一致，expect %d hits, got %d", name, query.ToString(), len(expect), len(got))
+				}
+				k := 1 + rnd.Intn(20)
+				topK := expect
+				if len(topK) > k {
+					topK = topK[:k]
+				}
+				if got := indexer.SearchTopK(query, k, flag.onFlag, flag.offFlag, flag.orFlags); !identicalHits(topK, got) {
+					t.Fatalf("%s %s k=%d SearchTopK结果跟skiplist不一致", name, query.ToString(), k)
+				}
+			}
+		}
+	}
+}
+
+func benchmarkNestedQuery(b *testing.B, indexType int) {
+	indexer := buildRandomIndex(b, indexType, 100000)
+	w := func(i int) *types.TermQuery { return types.NewTermQuery("content", "w"+strconv.Itoa(i)) }
+	query := w(1).Or(w(2), w(3)).And(w(4).Or(w(5)).Not(w(6)), w(0).Or(w(7), w(8)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexer.Search(query, 0, 0, nil)
+	}
+}
+
+func BenchmarkNestedQuerySkipList(b *testing.B) {
+	benchmarkNestedQuery(b, reverseindex.SKIPLIST)
+}
+
+func BenchmarkNestedQueryRoaring(b *testing.B) {
+	benchmarkNestedQuery(b, reverseindex.ROARING)
+}
+
+func BenchmarkNestedQuerySegment(b *testing.B) {
+	benchmarkNestedQuery(b, reverseindex.SEGMENT)
+}
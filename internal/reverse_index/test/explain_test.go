@@ -0,0 +1,85 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 把执行情况写成 Op PostingLength/FilteredRows/Rows(子节点...)，MustNot里的节点前面加-
+func explainString(e *reverseindex.Explanation) string {
+	s := fmt.Sprintf("%s %d/%d/%d", e.Op, e.PostingLength, e.FilteredRows, e.Rows)
+	if e.Negated {
+		s = "-" + s
+	}
+	if len(e.Children) > 0 {
+		children := make([]string, 0, len(e.Children))
+		for _, child := range e.Children {
+			children = append(children, explainString(child))
+		}
+		s += "(" + strings.Join(children, " ") + ")"
+	}
+	return s
+}
+
+func TestExplain(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		for i, words := range [][]string{{"go", "java"}, {"go", "python"}, {"go", "java", "rust"}, {"java"}} {
+			doc := newDoc(strconv.Itoa(i+1), uint64(i+1), words...)
+			doc.BitsFeature = uint64(1 + i%2*2)
+			indexer.Add(doc)
+		}
+		w := func(word string) *types.TermQuery { return types.NewTermQuery("content", word) }
+		query := w("go").And(w("java").Or(w("python"))).Not(w("rust"))
+		explanation := indexer.Explain(query, 1, 2, nil, 1)
+		//外层Must只有一个子节点，规划后被拍平成一层
+		expect := "MUST 4/4/1(KEYWORD 3/2/2 SHOULD 2/2/2(KEYWORD 3/2/2 KEYWORD 1/0/0) -KEYWORD 1/1/1)"
+		if s := explainString(explanation); s != expect {
+			t.Fatalf("执行情况错误\n期望%s\n实际%s", expect, s)
+		}
+		hits := indexer.Search(query, 1, 2, nil)
+		if len(hits) != 1 || !explanation.Matched || explanation.Score != hits[0].Score {
+			t.Fatalf("文档1的得分错误 %v %t %g", hits, explanation.Matched, explanation.Score)
+		}
+		if should := explanation.Children[1]; !should.Children[0].Matched || should.Children[1].Matched || explanation.Children[2].Matched {
+			t.Fatal("文档1只应命中java，不应命中python和rust")
+		}
+		if indexer.Explain(&types.TermQuery{}, 0, 0, nil, 0) != nil {
+			t.Fatal("空查询应返回nil")
+		}
+	})
+}
+
+// 根节点的文档数跟Count相同，命中文档在根节点上的得分跟Search相同
+func TestExplainRandom(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		rnd := rand.New(rand.NewSource(1))
+		indexer := buildRandomIndex(t, indexType, 2000)
+		for round := 0; round < 50; round++ {
+			query := randomQuery(rnd, 3)
+			onFlag := uint64(rnd.Intn(3))
+			hits := indexer.Search(query, onFlag, 0, nil)
+			var intId uint64
+			if len(hits) > 0 {
+				intId = hits[rnd.Intn(len(hits))].IntId
+			}
+			explanation := indexer.Explain(query, onFlag, 0, nil, intId)
+			if explanation == nil {
+				continue
+			}
+			if explanation.Rows != len(hits) {
+				t.Fatalf("%s 根节点产出%d个文档，Search命中%d个", query.ToString(), explanation.Rows, len(hits))
+			}
+			for _, hit := range hits {
+				if hit.IntId == intId && (!explanation.Matched || explanation.Score != hit.Score) {
+					t.Fatalf("%s 文档%d的得分%g跟Search的%g不同", query.ToString(), intId, explanation.Score, hit.Score)
+				}
+			}
+		}
+	})
+}
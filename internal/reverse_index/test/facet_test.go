@@ -0,0 +1,103 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestFacets(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		add := func(id string, intId uint64, bits uint64, author string, words ...string) {
+			doc := newDoc(id, intId, words...)
+			doc.BitsFeature = bits
+			doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: author})
+			indexer.Add(doc)
+		}
+		add("a", 1, 0b011, "张三", "go")
+		add("b", 2, 0b001, "李四", "go", "java")
+		add("c", 3, 0b100, "张三", "go")
+		add("d", 4, 0b110, "王五", "java")
+
+		facets := indexer.Facets(types.NewTermQuery("content", "go"), "author", 0, 0, nil)
+		if facets.Total != 3 || facets.Bits[0] != 2 || facets.Bits[1] != 1 || facets.Bits[2] != 1 || facets.Bits[3] != 0 {
+			t.Fatalf("bit统计错误 %d %v", facets.Total, facets.Bits[:4])
+		}
+		if s := fmt.Sprint(facets.Values); s != "map[张三:2 李四:1]" {
+			t.Fatalf("author统计错误 %s", s)
+		}
+		facets = indexer.Facets(types.NewTermQuery("content", "go"), "", 0b100, 0, nil)
+		if facets.Total != 1 || facets.Bits[2] != 1 || len(facets.Values) != 0 {
+			t.Fatalf("按bits过滤后统计错误 %d %v %v", facets.Total, facets.Bits[:4], facets.Values)
+		}
+		indexer.Delete(3, &types.Keyword{Field: "author", Word: "张三"})
+		facets = indexer.Facets(types.NewTermQuery("content", "go"), "author", 0, 0, nil)
+		if s := fmt.Sprint(facets.Values); s != "map[张三:1 李四:1]" {
+			t.Fatalf("删除后author统计错误 %s", s)
+		}
+		//更新文档后按新的keyword统计
+		old := newDoc("b", 2, "go", "java")
+		old.BitsFeature = 0b001
+		old.Keywords = append(old.Keywords, &types.Keyword{Field: "author", Word: "李四"})
+		doc := newDoc("b", 2, "go", "java")
+		doc.BitsFeature = 0b001
+		doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: "王五"})
+		indexer.Update(&old, doc)
+		facets = indexer.Facets(types.NewTermQuery("content", "go"), "author", 0, 0, nil)
+		if s := fmt.Sprint(facets.Values); s != "map[张三:1 王五:1]" {
+			t.Fatalf("更新后author统计错误 %s", s)
+		}
+	})
+}
+
+// 随机数据上的分面统计跟暴力计算一致
+func TestFacetsRandom(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		rnd := rand.New(rand.NewSource(1))
+		indexer := newReverseIndexer(t, indexType, 1000)
+		type docData struct {
+			words  map[string]bool
+			author string
+			bits   uint64
+		}
+		docs := make([]docData, 0, 1000)
+		for i := 1; i <= 1000; i++ {
+			data := docData{words: make(map[string]bool), author: "u" + strconv.Itoa(rnd.Intn(30)), bits: uint64(rnd.Intn(16))}
+			words := make([]string, 0, 4)
+			for j := rnd.Intn(4); j >= 0; j-- {
+				word := "w" + strconv.Itoa(rnd.Intn(10))
+				words = append(words, word)
+				data.words[word] = true
+			}
+			doc := newDoc(strconv.Itoa(i), uint64(i), words...)
+			doc.BitsFeature = data.bits
+			doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "author", Word: data.author})
+			indexer.Add(doc)
+			docs = append(docs, data)
+		}
+		for round := 0; round < 30; round++ {
+			word := "w" + strconv.Itoa(rnd.Intn(10))
+			expect := &reverseindex.Facets{Values: make(map[string]int)}
+			for _, data := range docs {
+				if !data.words[word] {
+					continue
+				}
+				expect.Total++
+				expect.Values[data.author]++
+				for b := 0; b < 64; b++ {
+					if data.bits&(1<<b) != 0 {
+						expect.Bits[b]++
+					}
+				}
+			}
+			got := indexer.Facets(types.NewTermQuery("content", word), "author", 0, 0, nil)
+			if fmt.Sprint(got) != fmt.Sprint(expect) {
+				t.Fatalf("%s 分面统计跟暴力计算不一致\n期望%v\n实际%v", word, expect, got)
+			}
+		}
+	})
+}
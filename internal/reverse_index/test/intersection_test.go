@@ -0,0 +1,179 @@
+package test
+
+import (
+	"encoding/csv"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/util"
+	"github.com/huandu/skiplist"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 逐个节点线性前进的求交集算法，作为对照组
+func linearIntersection(lists ...*skiplist.SkipList) *skiplist.SkipList {
+	if len(lists) == 0 {
+		return nil
+	}
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	res := skiplist.New(skiplist.Uint64)
+	currNodes := make([]*skiplist.Element, len(lists))
+	for i, list := range lists {
+		if list == nil || list.Len() == 0 {
+			return nil
+		}
+		currNodes[i] = list.Front()
+	}
+	for {
+		maxList := make(map[int]struct{}, len(currNodes))
+		var maxValue uint64 = 0
+		for i, node := range currNodes {
+			if node.Key().(uint64) > maxValue {
+				maxValue = node.Key().(uint64)
+				maxList = map[int]struct{}{i: {}}
+			} else if node.Key().(uint64) == maxValue {
+				maxList[i] = struct{}{}
+			}
+		}
+		if len(maxList) == len(currNodes) {
+			res.Set(currNodes[0].Key(), currNodes[0].Value)
+			for i, node := range currNodes {
+				if node.Next() == nil {
+					return res
+				}
+				currNodes[i] = node.Next()
+			}
+		} else {
+			for i, node := range currNodes {
+				if _, ok := maxList[i]; !ok {
+					if node.Next() == nil {
+						return res
+					}
+					currNodes[i] = node.Next()
+				}
+			}
+		}
+	}
+}
+
+func sameKeys(a, b *skiplist.SkipList) bool {
+	if a == nil || b == nil {
+		return (a == nil || a.Len() == 0) && (b == nil || b.Len() == 0)
+	}
+	if a.Len() != b.Len() {
+		return false
+	}
+	for x, y := a.Front(), b.Front(); x != nil; x, y = x.Next(), y.Next() {
+		if x.Key() != y.Key() {
+			return false
+		}
+	}
+	return true
+}
+
+// 随机生成一个跳表，元素取自[1, maxKey]，n不能超过maxKey
+func randomSkipList(rnd *rand.Rand, n int, maxKey int) *skiplist.SkipList {
+	list := skiplist.New(skiplist.Uint64)
+	for list.Len() < n {
+		list.Set(uint64(1+rnd.Intn(maxKey)), reverseindex.SkipListValue{})
+	}
+	return list
+}
+
+// 把bili_video.csv里每个关键词的倒排链构建成跳表，行号作为IntId
+func loadCorpusLists() map[string]*skiplist.SkipList {
+	file, err := os.Open(util.RootPath + "data/bili_video.csv")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		panic(err)
+	}
+	lists := make(map[string]*skiplist.SkipList, 1000)
+	for i, record := range records {
+		if len(record) < 10 {
+			continue
+		}
+		for _, word := range strings.Split(record[9], ",") {
+			word = strings.ToLower(strings.TrimSpace(word))
+			if len(word) == 0 {
+				continue
+			}
+			list, ok := lists[word]
+			if !ok {
+				list = skiplist.New(skiplist.Uint64)
+				lists[word] = list
+			}
+			list.Set(uint64(i+1), reverseindex.SkipListValue{Id: record[0]})
+		}
+	}
+	return lists
+}
+
+func TestIntersectionOfSkipList(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 200; round++ {
+		lists := make([]*skiplist.SkipList, 2+rnd.Intn(3))
+		for i := range lists {
+			n := 1 + rnd.Intn(300)
+			lists[i] = randomSkipList(rnd, n, n+rnd.Intn(1000))
+		}
+		if !sameKeys(linearIntersection(lists...), reverseindex.IntersectionOfSkipList(lists...)) {
+			t.Fatalf("round %d: 求交集结果跟对照组不一致", round)
+		}
+	}
+
+	lists := loadCorpusLists()
+	for _, words := range [][]string{{"go", "golang"}, {"编程", "go语言", "教程"}, {"golang", "java"}, {"知识", "不存在的词"}} {
+		arr := make([]*skiplist.SkipList, 0, len(words))
+		for _, word := range words {
+			arr = append(arr, lists[word])
+		}
+		if !sameKeys(linearIntersection(arr...), reverseindex.IntersectionOfSkipList(arr...)) {
+			t.Fatalf("%v: 求交集结果跟对照组不一致", words)
+		}
+	}
+}
+
+func benchmarkIntersection(b *testing.B, lists []*skiplist.SkipList, intersection func(...*skiplist.SkipList) *skiplist.SkipList) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		intersection(lists...)
+	}
+}
+
+func corpusBenchLists() []*skiplist.SkipList {
+	lists := loadCorpusLists()
+	return []*skiplist.SkipList{lists["golang"], lists["go语言"], lists["编程"]}
+}
+
+// 一条很短的跳表和两条很长的跳表
+func skewedBenchLists() []*skiplist.SkipList {
+	rnd := rand.New(rand.NewSource(1))
+	return []*skiplist.SkipList{
+		randomSkipList(rnd, 20, 1000000),
+		randomSkipList(rnd, 500000, 1000000),
+		randomSkipList(rnd, 300000, 1000000),
+	}
+}
+
+func BenchmarkLinearIntersectionCorpus(b *testing.B) {
+	benchmarkIntersection(b, corpusBenchLists(), linearIntersection)
+}
+
+func BenchmarkSeekIntersectionCorpus(b *testing.B) {
+	benchmarkIntersection(b, corpusBenchLists(), reverseindex.IntersectionOfSkipList)
+}
+
+func BenchmarkLinearIntersectionSkewed(b *testing.B) {
+	benchmarkIntersection(b, skewedBenchLists(), linearIntersection)
+}
+
+func BenchmarkSeekIntersectionSkewed(b *testing.B) {
+	benchmarkIntersection(b, skewedBenchLists(), reverseindex.IntersectionOfSkipList)
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// 逐篇文档判断是否满足查询，作为迭代器求值的对照组
+func matchDoc(q *types.TermQuery, words map[string]bool) bool {
+	var match bool
+	if q.Keyword != nil {
+		match = words[q.Keyword.Word]
+	} else if len(q.Must) > 0 {
+		match = true
+		for _, child := range q.Must {
+			if !matchDoc(child, words) {
+				match = false
+				break
+			}
+		}
+	} else if len(q.Should) > 0 {
+		for _, child := range q.Should {
+			if matchDoc(child, words) {
+				match = true
+				break
+			}
+		}
+	}
+	if match {
+		for _, child := range q.MustNot {
+			if matchDoc(child, words) {
+				return false
+			}
+		}
+	}
+	return match
+}
+
+// 随机生成一棵深度不超过depth的查询树
+func randomQuery(rnd *rand.Rand, depth int) *types.TermQuery {
+	leaf := func() *types.TermQuery {
+		return types.NewTermQuery("content", "w"+strconv.Itoa(rnd.Intn(25)))
+	}
+	if depth == 0 || rnd.Intn(4) == 0 {
+		return leaf()
+	}
+	children := make([]*types.TermQuery, 1+rnd.Intn(3))
+	for i := range children {
+		children[i] = randomQuery(rnd, depth-1)
+	}
+	var q *types.TermQuery
+	if rnd.Intn(2) == 0 {
+		q = &types.TermQuery{Must: children}
+	} else {
+		q = &types.TermQuery{Should: children}
+	}
+	if rnd.Intn(3) == 0 {
+		q.MustNot = []*types.TermQuery{randomQuery(rnd, depth-1)}
+	}
+	return q
+}
+
+func TestIteratorSearch(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		rnd := rand.New(rand.NewSource(1))
+		indexer := newReverseIndexer(t, indexType, 1000)
+		docs := make([]map[string]bool, 0, 1000)
+		for i := 1; i <= 1000; i++ {
+			words := make([]string, 0, 8)
+			set := make(map[string]bool, 8)
+			for j := rnd.Intn(8); j >= 0; j-- {
+				word := "w" + strconv.Itoa(rnd.Intn(25))
+				words = append(words, word)
+				set[word] = true
+			}
+			indexer.Add(newDoc(strconv.Itoa(i), uint64(i), words...))
+			docs = append(docs, set)
+		}
+
+		for round := 0; round < 300; round++ {
+			query := randomQuery(rnd, 4)
+			expect := make([]string, 0, len(docs))
+			for i, words := range docs {
+				if matchDoc(query, words) {
+					expect = append(expect, strconv.Itoa(i+1))
+				}
+			}
+			sort.Strings(expect)
+			got := hitIds(indexer.Search(query, 0, 0, nil))
+			if len(got) != len(expect) {
+				t.Fatalf("%s expect %d docs, got %d", query.ToString(), len(expect), len(got))
+			}
+			for i := range got {
+				if got[i] != expect[i] {
+					t.Fatalf("%s expect %v, got %v", query.ToString(), expect, got)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"sort"
+	"testing"
+)
+
+func hitIds(hits []reverseindex.SearchHit) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.Id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestMustNot(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newDoc("a", 1, "golang", "java"))
+		indexer.Add(newDoc("b", 2, "golang"))
+		indexer.Add(newDoc("c", 3, "golang", "python"))
+		indexer.Add(newDoc("d", 4, "java", "python"))
+
+		golang := types.NewTermQuery("content", "golang")
+		java := types.NewTermQuery("content", "java")
+		python := types.NewTermQuery("content", "python")
+
+		cases := []struct {
+			query  *types.TermQuery
+			expect []string
+		}{
+			{golang.Not(java), []string{"b", "c"}},
+			{golang.Not(java, python), []string{"b"}},
+			{golang.Or(java).Not(python), []string{"a", "b"}},
+			{golang.Not(types.NewTermQuery("content", "rust")), []string{"a", "b", "c"}},
+			{java.Not(golang.Not(python)), []string{"d"}}, //排除子句里嵌套排除
+			{new(types.TermQuery).Not(java), []string{}},  //单独的排除子句检索不到任何结果
+		}
+		for _, c := range cases {
+			ids := hitIds(indexer.Search(c.query, 0, 0, nil))
+			if len(ids) != len(c.expect) {
+				t.Fatalf("%s expect %v, got %v", c.query.ToString(), c.expect, ids)
+			}
+			for i := range ids {
+				if ids[i] != c.expect[i] {
+					t.Fatalf("%s expect %v, got %v", c.query.ToString(), c.expect, ids)
+				}
+			}
+		}
+	})
+}
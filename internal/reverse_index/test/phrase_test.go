@@ -0,0 +1,141 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestPhraseQuery(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newDoc("a", 1, "go语言", "入门", "教程"))
+		indexer.Add(newDoc("b", 2, "入门", "go语言"))
+		indexer.Add(newDoc("c", 3, "go语言", "高级", "并发", "入门"))
+		doc := newDoc("d", 4, "go语言")
+		doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "title", Word: "入门"}) //不同field的位置各自计数
+		indexer.Add(doc)
+
+		cases := []struct {
+			query  *types.TermQuery
+			expect string
+		}{
+			{types.NewPhraseQuery("content", "go语言", "入门"), "[a]"},
+			{types.NewPhraseQuery("content", "go语言", "入门", "教程"), "[a]"},
+			{types.NewPhraseQuery("content", "入门", "go语言"), "[b]"},
+			{types.NewPhraseQuery("content", "go语言", "教程"), "[]"},
+			{types.NewPhraseQuery("content", "go语言", "不存在"), "[]"},
+			{types.NewNearQuery("content", 1, "go语言", "入门"), "[a b]"},
+			{types.NewNearQuery("content", 3, "入门", "go语言"), "[a b c]"},
+			{types.NewNearQuery("content", 2, "go语言", "入门"), "[a b]"},
+			{types.NewNearQuery("content", 3, "go语言", "go语言"), "[]"}, //重复的keyword要占不同的位置
+			{types.NewNearQuery("content", 1, "go语言", "go语言", "入门"), "[]"},
+			{types.NewPhraseQuery("content", "go语言", "入门").Or(types.NewTermQuery("content", "并发")), "[a c]"},
+			{types.NewNearQuery("content", 3, "go语言", "入门").Not(types.NewTermQuery("content", "教程")), "[b c]"},
+		}
+		for _, c := range cases {
+			if ids := fmt.Sprint(hitIds(indexer.Search(c.query, 0, 0, nil))); ids != c.expect {
+				t.Fatalf("%s 应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+			if ids := fmt.Sprint(hitIds(indexer.SearchTopK(c.query, 10, 0, 0, nil))); ids != c.expect {
+				t.Fatalf("%s SearchTopK应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+		}
+	})
+}
+
+// 暴力检查各keyword在文档中的位置
+func bruteProximity(words []string, terms []string, ordered bool, distance int) bool {
+	for start := range words {
+		if ordered {
+			matched := start+len(terms) <= len(words)
+			for i := 0; matched && i < len(terms); i++ {
+				matched = words[start+i] == terms[i]
+			}
+			if matched {
+				return true
+			}
+			continue
+		}
+		//以start为窗口起点，看窗口内是否包含所有term。同一个term出现多次时，窗口里也要有那么多个不同的位置
+		counts := make(map[string]int, len(terms))
+		for p := start; p < len(words) && p <= start+distance; p++ {
+			counts[words[p]]++
+		}
+		matched := true
+		for _, term := range terms {
+			if counts[term]--; counts[term] < 0 {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// 随机文档上的短语和邻近查询，结果跟暴力匹配一致，各实现的得分跟跳表实现完全一致
+func TestProximityRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	docWords := make([][]string, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		words := make([]string, 1+rnd.Intn(12))
+		for j := range words {
+			words[j] = "w" + strconv.Itoa(rnd.Intn(6))
+		}
+		docWords = append(docWords, words)
+	}
+	indexers := make([]reverseindex.IReverseIndexer, 0, len(indexTypes))
+	for _, it := range indexTypes {
+		indexer := newReverseIndexer(t, it.indexType, 2000)
+		for i, words := range docWords {
+			indexer.Add(newDoc("doc"+strconv.Itoa(i+1), uint64(i+1), words...))
+			if persistent, ok := indexer.(reverseindex.IPersistentReverseIndexer); ok && i%700 == 699 {
+				if err := persistent.Flush(); err != nil { //一部分数据在磁盘段上，一部分在内存里
+					t.Fatal(err)
+				}
+			}
+		}
+		indexers = append(indexers, indexer)
+	}
+
+	for round := 0; round < 200; round++ {
+		terms := make([]string, 2+rnd.Intn(2))
+		for i := range terms {
+			terms[i] = "w" + strconv.Itoa(rnd.Intn(6))
+		}
+		ordered := rnd.Intn(2) == 0
+		distance := rnd.Intn(5)
+		var query *types.TermQuery
+		if ordered {
+			query = types.NewPhraseQuery("content", terms...)
+		} else {
+			query = types.NewNearQuery("content", uint32(distance), terms...)
+		}
+		expect := make([]string, 0)
+		for i, words := range docWords {
+			if bruteProximity(words, terms, ordered, distance) {
+				expect = append(expect, "doc"+strconv.Itoa(i+1))
+			}
+		}
+		sort.Strings(expect)
+		base := indexers[0].Search(query, 0, 0, nil)
+		if fmt.Sprint(hitIds(base)) != fmt.Sprint(expect) {
+			t.Fatalf("%s 结果跟暴力匹配不一致，期望%d个，实际%d个", query.ToString(), len(expect), len(base))
+		}
+		for i, indexer := range indexers[1:] {
+			if !identicalHits(base, indexer.Search(query, 0, 0, nil)) {
+				t.Fatalf("%s %s的Search结果跟跳表不一致", indexTypes[i+1].name, query.ToString())
+			}
+			if !identicalHits(indexers[0].SearchTopK(query, 10, 0, 0, nil), indexer.SearchTopK(query, 10, 0, 0, nil)) {
+				t.Fatalf("%s %s的SearchTopK结果跟跳表不一致", indexTypes[i+1].name, query.ToString())
+			}
+		}
+	}
+}
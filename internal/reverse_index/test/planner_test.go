@@ -0,0 +1,119 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// sizeOpener 只提供倒排链长度，给PlanQuery用
+type sizeOpener map[string]int64
+
+func (o sizeOpener) OpenKeyword(keyword *types.Keyword, filtered bool) reverseindex.PostingIterator {
+	return sizeIterator(o[keyword.Word])
+}
+
+func (o sizeOpener) OpenRange(r *types.Range, filtered bool) reverseindex.PostingIterator {
+	return sizeIterator(0)
+}
+
+// sizeIterator 只有Cost()有意义
+type sizeIterator int64
+
+func (it sizeIterator) DocId() uint64                { return reverseindex.NO_MORE_DOCS }
+func (it sizeIterator) Next() uint64                 { return reverseindex.NO_MORE_DOCS }
+func (it sizeIterator) Advance(target uint64) uint64 { return reverseindex.NO_MORE_DOCS }
+func (it sizeIterator) Cost() int64                  { return int64(it) }
+func (it sizeIterator) Id() string                   { return "" }
+func (it sizeIterator) Score() float64               { return 0 }
+func (it sizeIterator) MaxScore() float64            { return 0 }
+
+func TestPlanQuery(t *testing.T) {
+	opener := sizeOpener{"a": 5, "b": 2, "c": 9, "e": 1}
+	w := func(word string) *types.TermQuery { return types.NewTermQuery("content", word) }
+	cases := []struct {
+		query  *types.TermQuery
+		expect *types.TermQuery
+	}{
+		{w("a").And(w("b")).And(w("c"), w("a")), &types.TermQuery{Must: []*types.TermQuery{w("b"), w("a"), w("c")}}}, //拍平、去重、按倒排链长度排序
+		{w("a").Or(w("b")).Or(w("c"), w("b")), &types.TermQuery{Should: []*types.TermQuery{w("a"), w("b"), w("c")}}},
+		{w("a").Or(w("d")), w("a")}, //Should里一定不命中的子节点去掉，只剩一个子节点时剥掉Should
+		{w("a").And(w("b")).Not(w("d")), &types.TermQuery{Must: []*types.TermQuery{w("b"), w("a")}}},
+		{w("a").And(w("b").And(w("c")).Not(w("e"))), &types.TermQuery{Must: []*types.TermQuery{w("b"), w("a"), w("c")}, MustNot: []*types.TermQuery{w("e")}}},
+		{w("c").Or(w("a").Not(w("e"))), &types.TermQuery{Should: []*types.TermQuery{w("c"), w("a").Not(w("e"))}}}, //带MustNot的子节点不能拍平
+	}
+	for _, c := range cases {
+		if got := reverseindex.PlanQuery(c.query, opener); got.ToString() != c.expect.ToString() {
+			t.Fatalf("%s 规划结果错误\n期望%s\n实际%s", c.query.ToString(), c.expect.ToString(), got.ToString())
+		}
+	}
+	//任何一个Must子节点一定不命中时，整个查询直接不命中
+	for _, query := range []*types.TermQuery{w("a").And(w("b"), w("d")), w("d").Or(w("a").And(w("d"))), w("d").Not(w("a"))} {
+		got := reverseindex.PlanQuery(query, opener)
+		if got == nil || got.Keyword == nil || opener[got.Keyword.Word] != 0 || len(got.MustNot) > 0 {
+			t.Fatalf("%s 应规划成一个不命中的keyword，实际%v", query.ToString(), got)
+		}
+	}
+	if reverseindex.PlanQuery(&types.TermQuery{}, opener) != nil || reverseindex.PlanQuery((&types.TermQuery{}).Not(w("a")), opener) != nil {
+		t.Fatal("空查询应规划成nil")
+	}
+}
+
+// 规划前后的查询在每篇文档上的命中情况相同，检索结果也相同
+func TestPlanQueryRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	docs := make([]map[string]bool, 0, 1000)
+	opener := make(sizeOpener)
+	for i := 1; i <= 1000; i++ {
+		set := make(map[string]bool, 8)
+		for j := rnd.Intn(8); j >= 0; j-- {
+			set["w"+strconv.Itoa(rnd.Intn(20))] = true //w20到w24不出现在任何文档里
+		}
+		for word := range set {
+			opener[word]++
+		}
+		docs = append(docs, set)
+	}
+	indexers := make([]reverseindex.IReverseIndexer, 0, len(indexTypes))
+	for _, it := range indexTypes {
+		indexer := newReverseIndexer(t, it.indexType, 1000)
+		for i, set := range docs {
+			words := make([]string, 0, len(set))
+			for word := range set {
+				words = append(words, word)
+			}
+			indexer.Add(newDoc(strconv.Itoa(i+1), uint64(i+1), words...))
+		}
+		indexers = append(indexers, indexer)
+	}
+
+	for round := 0; round < 300; round++ {
+		query := randomQuery(rnd, 4)
+		planned := reverseindex.PlanQuery(query, opener)
+		expect := make([]string, 0, len(docs))
+		for i, words := range docs {
+			match := matchDoc(query, words)
+			if planned == nil && match || planned != nil && matchDoc(planned, words) != match {
+				t.Fatalf("%s 规划成%v后文档%d的命中情况变了", query.ToString(), planned, i+1)
+			}
+			if match {
+				expect = append(expect, strconv.Itoa(i+1))
+			}
+		}
+		sort.Strings(expect)
+		for i, indexer := range indexers {
+			got := hitIds(indexer.Search(query, 0, 0, nil))
+			if len(got) != len(expect) {
+				t.Fatalf("%s %s expect %d docs, got %d", indexTypes[i].name, query.ToString(), len(expect), len(got))
+			}
+			for j := range got {
+				if got[j] != expect[j] {
+					t.Fatalf("%s %s expect %v, got %v", indexTypes[i].name, query.ToString(), expect, got)
+				}
+			}
+		}
+	}
+}
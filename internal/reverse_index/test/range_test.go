@@ -0,0 +1,145 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func newNumericDoc(id string, intId uint64, view int64, words ...string) types.Document {
+	doc := newDoc(id, intId, words...)
+	doc.Numerics = map[string]int64{"view": view, "like": view / 10}
+	return doc
+}
+
+func TestRangeQuery(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newNumericDoc("a", 1, 50, "go", "java"))
+		indexer.Add(newNumericDoc("b", 2, 500, "go"))
+		indexer.Add(newNumericDoc("c", 3, 5000, "go", "rust"))
+		doc := newNumericDoc("d", 4, 800, "python")
+		doc.BitsFeature = 1
+		indexer.Add(doc)
+		indexer.Add(newDoc("e", 5, "go")) //没有数值字段
+
+		cases := []struct {
+			query  *types.TermQuery
+			onFlag uint64
+			expect string
+		}{
+			{types.NewRangeQuery("view", 100, 1000), 0, "[b d]"},
+			{types.NewRangeQuery("view", 500, 500), 0, "[b]"}, //闭区间
+			{types.NewRangeQuery("view", 1000, 100), 0, "[]"},
+			{types.NewRangeQuery("favorite", 0, 1000), 0, "[]"},
+			{types.NewRangeQuery("view", 100, 1000), 1, "[d]"},
+			{types.NewTermQuery("content", "go").And(types.NewRangeQuery("view", 0, 1000)), 0, "[a b]"},
+			{types.NewTermQuery("content", "go").And(types.NewRangeQuery("view", 0, 1000), types.NewRangeQuery("like", 10, 100)), 0, "[b]"},
+			{types.NewTermQuery("content", "go").Not(types.NewRangeQuery("view", 0, 1000)), 0, "[c e]"},
+			{types.NewRangeQuery("view", 0, 10000).Not(types.NewTermQuery("content", "go")), 0, "[d]"},
+			{types.NewTermQuery("content", "python").Or(types.NewRangeQuery("view", 4000, 6000)), 0, "[c d]"},
+		}
+		for _, c := range cases {
+			if ids := fmt.Sprint(hitIds(indexer.Search(c.query, c.onFlag, 0, nil))); ids != c.expect {
+				t.Fatalf("%s 应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+			if ids := fmt.Sprint(hitIds(indexer.SearchTopK(c.query, 10, c.onFlag, 0, nil))); ids != c.expect {
+				t.Fatalf("%s SearchTopK应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+		}
+
+		//Range只过滤不打分
+		plain := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil)
+		ranged := indexer.Search(types.NewTermQuery("content", "go").And(types.NewRangeQuery("view", 0, 10000)), 0, 0, nil)
+		withView := make([]reverseindex.SearchHit, 0, len(plain))
+		for _, hit := range plain {
+			if hit.Id != "e" { //e没有数值字段
+				withView = append(withView, hit)
+			}
+		}
+		if !identicalHits(withView, ranged) {
+			t.Fatalf("Range不应改变得分 %v %v", plain, ranged)
+		}
+
+		//文档的posting全部删除后，Range也不再命中它
+		indexer.Delete(2, &types.Keyword{Field: "content", Word: "go"})
+		if ids := fmt.Sprint(hitIds(indexer.Search(types.NewRangeQuery("view", 100, 1000), 0, 0, nil))); ids != "[d]" {
+			t.Fatalf("删除后应命中[d]，实际命中%s", ids)
+		}
+		indexer.Delete(1, &types.Keyword{Field: "content", Word: "go"})
+		if ids := fmt.Sprint(hitIds(indexer.Search(types.NewRangeQuery("view", 0, 100), 0, 0, nil))); ids != "[a]" {
+			t.Fatalf("文档还有别的posting时Range应照常命中，实际命中%s", ids)
+		}
+	})
+}
+
+// 随机文档上keyword和Range混合的查询，结果跟暴力计算一致，各实现的结果跟跳表实现完全一致，段式索引重启后也一样
+func TestRangeRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	type docData struct {
+		words map[string]bool
+		view  int64
+	}
+	docs := make([]docData, 0, 2000)
+	expect := reverseindex.NewSkipListReverseIndex(2000)
+	dir := t.TempDir()
+	segment := openSegmentIndex(t, dir)
+	roaring := newReverseIndexer(t, reverseindex.ROARING, 2000)
+	for i := 1; i <= 2000; i++ {
+		data := docData{words: make(map[string]bool), view: int64(rnd.Intn(1000) - 100)}
+		words := make([]string, 0, 4)
+		for j := rnd.Intn(4); j >= 0; j-- {
+			word := "w" + strconv.Itoa(rnd.Intn(10))
+			words = append(words, word)
+			data.words[word] = true
+		}
+		doc := newDoc("doc"+strconv.Itoa(i), uint64(i), words...)
+		doc.Numerics = map[string]int64{"view": data.view}
+		for _, indexer := range []reverseindex.IReverseIndexer{expect, segment, roaring} {
+			indexer.Add(doc)
+		}
+		docs = append(docs, data)
+	}
+
+	check := func(stage string, indexers ...reverseindex.IReverseIndexer) {
+		for round := 0; round < 100; round++ {
+			word := "w" + strconv.Itoa(rnd.Intn(10))
+			low := int64(rnd.Intn(1200) - 200)
+			high := low + int64(rnd.Intn(300))
+			query := types.NewTermQuery("content", word).And(types.NewRangeQuery("view", low, high))
+			want := make([]string, 0)
+			for i, data := range docs {
+				if data.words[word] && data.view >= low && data.view <= high {
+					want = append(want, "doc"+strconv.Itoa(i+1))
+				}
+			}
+			sort.Strings(want)
+			base := expect.Search(query, 0, 0, nil)
+			if fmt.Sprint(hitIds(base)) != fmt.Sprint(want) {
+				t.Fatalf("%s: %s 结果跟暴力计算不一致", stage, query.ToString())
+			}
+			for _, indexer := range indexers {
+				if !identicalHits(base, indexer.Search(query, 0, 0, nil)) {
+					t.Fatalf("%s: %s Search结果跟跳表不一致", stage, query.ToString())
+				}
+				if !identicalHits(expect.SearchTopK(query, 10, 0, 0, nil), indexer.SearchTopK(query, 10, 0, 0, nil)) {
+					t.Fatalf("%s: %s SearchTopK结果跟跳表不一致", stage, query.ToString())
+				}
+			}
+		}
+	}
+	check("写入后", segment, roaring)
+	if err := segment.Close(); err != nil {
+		t.Fatal(err)
+	}
+	segment = openSegmentIndex(t, dir)
+	if !segment.Loaded() {
+		t.Fatal("重启后应该从磁盘加载数据")
+	}
+	check("重启后", segment)
+	segment.Close()
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// 随机生成一个Bitmap，同时返回map形式的对照组。元素集中在少数几个container里，密集的container会变成bitmap容器
+func randomBitmap(rnd *rand.Rand, n int) (*reverseindex.Bitmap, map[uint64]bool) {
+	bitmap := reverseindex.NewBitmap()
+	set := make(map[uint64]bool, n)
+	for i := 0; i < n; i++ {
+		x := uint64(rnd.Intn(4))<<16 | uint64(rnd.Intn(1<<(4+rnd.Intn(13))))
+		if bitmap.Add(x) == set[x] {
+			panic("Add的返回值错误")
+		}
+		set[x] = true
+	}
+	return bitmap, set
+}
+
+func sortedKeys(set map[uint64]bool) []uint64 {
+	keys := make([]uint64, 0, len(set))
+	for k, ok := range set {
+		if ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func sameElements(t *testing.T, op string, bitmap *reverseindex.Bitmap, set map[uint64]bool) {
+	expect := sortedKeys(set)
+	got := bitmap.ToArray()
+	if len(got) != len(expect) || bitmap.Cardinality() != len(expect) {
+		t.Fatalf("%s expect %d elements, got %d", op, len(expect), len(got))
+	}
+	for i := range got {
+		if got[i] != expect[i] {
+			t.Fatalf("%s 第%d个元素 expect %d, got %d", op, i, expect[i], got[i])
+		}
+	}
+}
+
+func TestBitmap(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		a, setA := randomBitmap(rnd, rnd.Intn(30000))
+		b, setB := randomBitmap(rnd, rnd.Intn(30000))
+		sameElements(t, "Add", a, setA)
+
+		and, or, andNot := make(map[uint64]bool), make(map[uint64]bool), make(map[uint64]bool)
+		for x := range setA {
+			or[x] = true
+			if setB[x] {
+				and[x] = true
+			} else {
+				andNot[x] = true
+			}
+		}
+		for x := range setB {
+			or[x] = true
+		}
+		sameElements(t, "And", a.And(b), and)
+		sameElements(t, "Or", a.Or(b), or)
+		sameElements(t, "AndNot", a.AndNot(b), andNot)
+		sameElements(t, "Add", a, setA) //集合运算不能修改参与运算的Bitmap
+
+		//Advance返回第一个>=target的元素
+		expect := sortedKeys(setA)
+		it := a.Iterator()
+		var target uint64
+		for {
+			target += uint64(rnd.Intn(3000))
+			got, ok := it.Advance(target)
+			i := sort.Search(len(expect), func(i int) bool { return expect[i] >= target })
+			if i == len(expect) {
+				if ok {
+					t.Fatalf("Advance(%d) expect nothing, got %d", target, got)
+				}
+				break
+			}
+			if !ok || got != expect[i] {
+				t.Fatalf("Advance(%d) expect %d, got %d", target, expect[i], got)
+			}
+			target = got + 1
+		}
+
+		//删除一半元素，bitmap容器会退化成array容器
+		for x := range setA {
+			if rnd.Intn(2) == 0 {
+				if !a.Remove(x) {
+					t.Fatalf("Remove(%d)应该返回true", x)
+				}
+				setA[x] = false
+			}
+		}
+		if a.Remove(1 << 40) {
+			t.Fatalf("删除不存在的元素应该返回false")
+		}
+		sameElements(t, "Remove", a, setA)
+		for x, ok := range setA {
+			if a.Contains(x) != ok {
+				t.Fatalf("Contains(%d) expect %t", x, ok)
+			}
+		}
+	}
+}
+
+// IntId很稀疏时文档表不能按最大的IntId分配空间。删掉的文档也要从文档表里移除
+func TestRoaringSparseIntId(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	indexer := reverseindex.NewRoaringReverseIndex(100)
+	docs := make([]*types.Document, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		doc := newDoc("doc"+strconv.Itoa(i), uint64(i)*10000+1, "go")
+		indexer.Add(doc)
+		docs = append(docs, &doc)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if grown := int64(after.HeapAlloc) - int64(before.HeapAlloc); grown > 32<<20 {
+		t.Fatalf("1000 docs with sparse IntIds take %d MB", grown>>20)
+	}
+	if n := indexer.Count(types.NewTermQuery("content", "go"), 0, 0, nil); n != 1000 {
+		t.Fatalf("count %d, expect 1000", n)
+	}
+
+	indexer.DeleteBatch(docs)
+	if hits := indexer.Search(types.NewTermQuery("content", "go"), 0, 0, nil); len(hits) != 0 {
+		t.Fatalf("deleted docs still found: %v", hitIds(hits))
+	}
+	indexer.Add(newDoc("new", 1, "rust")) //IntId为1的文档已经删掉了，不能再带出旧的业务Id
+	if hits := indexer.Search(types.NewTermQuery("content", "rust"), 0, 0, nil); !reflect.DeepEqual(hitIds(hits), []string{"new"}) {
+		t.Fatalf("search rust got %v", hitIds(hits))
+	}
+}
@@ -0,0 +1,231 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func openSegmentIndex(t *testing.T, dir string) *reverseindex.SegmentReverseIndex {
+	indexer := reverseindex.NewSegmentReverseIndex(1000).WithDataDir(dir).WithFlushThreshold(300).WithMaxSegments(3)
+	if err := indexer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return indexer
+}
+
+// 检查各种查询在两个索引上的结果完全相同，包括分面统计
+func checkSameResults(t *testing.T, stage string, expect, got reverseindex.IReverseIndexer) {
+	rnd := rand.New(rand.NewSource(2))
+	for round := 0; round < 100; round++ {
+		query := randomQuery(rnd, 3)
+		if !identicalHits(expect.Search(query, 0, 0, nil), got.Search(query, 0, 0, nil)) {
+			t.Fatalf("%s: %s Search结果不一致", stage, query.ToString())
+		}
+		if !identicalHits(expect.SearchTopK(query, 10, 1, 0, nil), got.SearchTopK(query, 10, 1, 0, nil)) {
+			t.Fatalf("%s: %s SearchTopK结果不一致", stage, query.ToString())
+		}
+		if fmt.Sprint(expect.Facets(query, "content", 0, 0, nil)) != fmt.Sprint(got.Facets(query, "content", 0, 0, nil)) {
+			t.Fatalf("%s: %s Facets结果不一致", stage, query.ToString())
+		}
+	}
+}
+
+func TestSegmentReverseIndex(t *testing.T) {
+	dir := t.TempDir()
+	rnd := rand.New(rand.NewSource(1))
+	expect := reverseindex.NewSkipListReverseIndex(1000)
+	indexer := openSegmentIndex(t, dir)
+	if indexer.Loaded() {
+		t.Fatal("空目录不应该加载到数据")
+	}
+
+	//边写边删，过程中会在后台flush出多个段并合并
+	var intId uint64
+	for i := 0; i < 3000; i++ {
+		intId++
+		words := make([]string, 0, 8)
+		for j := rnd.Intn(8); j >= 0; j-- {
+			words = append(words, "w"+strconv.Itoa(rnd.Intn(25)))
+		}
+		doc := newDoc("doc"+strconv.Itoa(i), intId, words...)
+		doc.BitsFeature = uint64(rnd.Intn(4))
+		expect.Add(doc)
+		indexer.Add(doc)
+		if rnd.Intn(5) == 0 {
+			victim := newDoc("", 1+uint64(rnd.Intn(int(intId))), words...)
+			for _, keyword := range victim.Keywords {
+				expect.Delete(victim.IntId, keyword)
+				indexer.Delete(victim.IntId, keyword)
+			}
+		}
+	}
+	checkSameResults(t, "写入后", expect, indexer)
+	if err := indexer.Merge(); err != nil {
+		t.Fatal(err)
+	}
+	checkSameResults(t, "合并后", expect, indexer)
+	if err := indexer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	//重启后直接从磁盘加载
+	indexer = openSegmentIndex(t, dir)
+	if !indexer.Loaded() || indexer.MaxIntId() != intId {
+		t.Fatalf("重启后应该从磁盘加载数据，MaxIntId=%d", indexer.MaxIntId())
+	}
+	checkSameResults(t, "重启后", expect, indexer)
+	if got, want := indexer.Terms("content", "w", 0), expect.Terms("content", "w", 0); len(want) == 0 || fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("重启后字典应为%v，实际为%v", want, got)
+	}
+
+	//没有正常关闭，但调用方有预写日志时，保留最后一个manifest里的段，没落盘的修改由调用方重放
+	indexer.Add(newDoc("new", intId+1, "w1"))
+	if _, err := os.Stat(filepath.Join(dir, "dirty")); err != nil {
+		t.Fatalf("修改之后应该有dirty文件: %s", err)
+	}
+	indexer = reverseindex.NewSegmentReverseIndex(1000).WithDataDir(dir).WithWal()
+	if err := indexer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	if !indexer.Loaded() || indexer.MaxIntId() != intId {
+		t.Fatalf("有预写日志时应该加载最后一个manifest里的段，MaxIntId=%d", indexer.MaxIntId())
+	}
+	checkSameResults(t, "异常退出后", expect, indexer)
+
+	//没有预写日志时，磁盘上的数据不完整，需要由调用方重建
+	indexer = openSegmentIndex(t, dir)
+	if indexer.Loaded() {
+		t.Fatal("没有正常关闭且没有预写日志时不应该加载磁盘上的数据")
+	}
+	indexer.Close()
+}
+
+// 段数超过上限时只合并相邻的小段，大段不会因为一次小的flush被整个重写
+func TestSegmentTieredMerge(t *testing.T) {
+	dir := t.TempDir()
+	rnd := rand.New(rand.NewSource(3))
+	expect := reverseindex.NewSkipListReverseIndex(1000)
+	indexer := reverseindex.NewSegmentReverseIndex(1000).WithDataDir(dir).WithFlushThreshold(20).WithMaxSegments(3)
+	if err := indexer.Open(); err != nil {
+		t.Fatal(err)
+	}
+	var intId uint64
+	addDocs := func(n int) {
+		for i := 0; i < n; i++ {
+			intId++
+			words := make([]string, 0, 8)
+			for j := rnd.Intn(8); j >= 0; j-- {
+				words = append(words, "w"+strconv.Itoa(rnd.Intn(25)))
+			}
+			doc := newDoc("doc"+strconv.Itoa(int(intId)), intId, words...)
+			expect.Add(doc)
+			indexer.Add(doc)
+			if rnd.Intn(5) == 0 {
+				victim := newDoc("", 1+uint64(rnd.Intn(int(intId))), words...)
+				for _, keyword := range victim.Keywords {
+					expect.Delete(victim.IntId, keyword)
+					indexer.Delete(victim.IntId, keyword)
+				}
+			}
+		}
+	}
+	addDocs(2000)
+	if err := indexer.Merge(); err != nil {
+		t.Fatal(err)
+	}
+	big, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(big) != 1 {
+		t.Fatalf("合并后应该只有1个段文件，实际有%d个", len(big))
+	}
+	for i := 0; i < 5; i++ { //攒出几个小段，段数超过上限
+		addDocs(10)
+		if err := indexer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	addDocs(30) //触发后台flush，随后合并
+	if err := indexer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(big[0]); err != nil {
+		t.Fatalf("小段合并时不应该重写大段%s: %s", filepath.Base(big[0]), err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.seg")); len(files) > 4 { //最多比上限多出Close时flush的1个段
+		t.Fatalf("小段应该已经合并，实际有%d个段文件", len(files))
+	}
+	indexer = openSegmentIndex(t, dir)
+	defer indexer.Close()
+	checkSameResults(t, "部分合并后重启", expect, indexer)
+}
+
+func TestSegmentCorrupted(t *testing.T) {
+	dir := t.TempDir()
+	indexer := openSegmentIndex(t, dir)
+	indexer.Add(newDoc("a", 1, "go", "java"))
+	if err := indexer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(files) != 1 {
+		t.Fatalf("应该有1个段文件，实际有%d个", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	data[len(data)/2] ^= 0xff
+	os.WriteFile(files[0], data, 0o644)
+	indexer = openSegmentIndex(t, dir)
+	if indexer.Loaded() {
+		t.Fatal("段文件损坏时不应该加载磁盘上的数据")
+	}
+	indexer.Close()
+}
+
+func coldStartDocs(n int) []types.Document {
+	rnd := rand.New(rand.NewSource(1))
+	docs := make([]types.Document, 0, n)
+	for i := 1; i <= n; i++ {
+		words := make([]string, 0, 10)
+		for j := rnd.Intn(10); j >= 0; j-- {
+			words = append(words, "w"+strconv.Itoa(rnd.Intn(1000)))
+		}
+		docs = append(docs, newDoc("doc"+strconv.Itoa(i), uint64(i), words...))
+	}
+	return docs
+}
+
+// 冷启动时从磁盘加载段
+func BenchmarkSegmentOpen(b *testing.B) {
+	dir := b.TempDir()
+	indexer := reverseindex.NewSegmentReverseIndex(100000).WithDataDir(dir)
+	if err := indexer.Open(); err != nil {
+		b.Fatal(err)
+	}
+	for _, doc := range coldStartDocs(100000) {
+		indexer.Add(doc)
+	}
+	indexer.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexer := reverseindex.NewSegmentReverseIndex(100000).WithDataDir(dir)
+		if err := indexer.Open(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 对照组：冷启动时逐篇重建跳表(还不包括从正排索引解码文档的耗时)
+func BenchmarkSkipListRebuild(b *testing.B) {
+	docs := coldStartDocs(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexer := reverseindex.NewSkipListReverseIndex(100000)
+		for _, doc := range docs {
+			indexer.Add(doc)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package test
+
+import (
+	"fmt"
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// 用正则表达式作为对照，*对应.*，?对应一个字符
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+	return regexp.MustCompile("^" + expr + "$")
+}
+
+func TestTermDict(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	letters := []string{"a", "b", "go", "语", "言"}
+	randomWord := func() string {
+		sb := strings.Builder{}
+		for i := rnd.Intn(5); i >= 0; i-- {
+			sb.WriteString(letters[rnd.Intn(len(letters))])
+		}
+		return sb.String()
+	}
+	dict := reverseindex.NewTermDict()
+	expect := make(map[string]bool)
+	for i := 0; i < 3000; i++ {
+		field := []string{"title", "author"}[rnd.Intn(2)]
+		key := (&types.Keyword{Field: field, Word: randomWord()}).ToString()
+		if rnd.Intn(3) == 0 {
+			dict.Remove(key)
+			delete(expect, key)
+		} else {
+			dict.Add(key)
+			expect[key] = true
+		}
+	}
+	if dict.Len() != len(expect) {
+		t.Fatalf("字典应有%d个term，实际有%d个", len(expect), dict.Len())
+	}
+
+	words := make([]string, 0, len(expect))
+	for key := range expect {
+		if strings.HasPrefix(key, "title\001") {
+			words = append(words, strings.TrimPrefix(key, "title\001"))
+		}
+	}
+	sort.Strings(words)
+	for round := 0; round < 200; round++ {
+		prefix := randomWord()
+		prefix = prefix[:min(len(prefix), rnd.Intn(3))]
+		want := make([]string, 0)
+		for _, word := range words {
+			if strings.HasPrefix(word, prefix) {
+				want = append(want, word)
+			}
+		}
+		if got := dict.Prefix("title", prefix, 0); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("前缀%q应得到%v，实际得到%v", prefix, want, got)
+		}
+		if limit := 1 + rnd.Intn(5); len(want) > limit {
+			if got := dict.Prefix("title", prefix, limit); fmt.Sprint(got) != fmt.Sprint(want[:limit]) {
+				t.Fatalf("前缀%q最多返回%d个，应得到%v，实际得到%v", prefix, limit, want[:limit], got)
+			}
+		}
+
+		pattern := strings.Map(func(r rune) rune {
+			if rnd.Intn(4) == 0 {
+				return []rune{'*', '?'}[rnd.Intn(2)]
+			}
+			return r
+		}, randomWord())
+		re := wildcardRegexp(pattern)
+		want = want[:0]
+		for _, word := range words {
+			if re.MatchString(word) {
+				want = append(want, word)
+			}
+		}
+		if got := dict.Wildcard("title", pattern, 0); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("通配符%q应得到%v，实际得到%v", pattern, want, got)
+		}
+	}
+}
+
+func TestExpandQuery(t *testing.T) {
+	dict := reverseindex.NewTermDict()
+	for _, word := range []string{"go", "golang", "gopher", "java"} {
+		dict.Add((&types.Keyword{Field: "content", Word: word}).ToString())
+	}
+	query := types.NewPrefixQuery("content", "go").And(types.NewTermQuery("content", "java"))
+	expanded := reverseindex.ExpandQuery(query, dict, 2)
+	if s := expanded.ToString(); s != "((content\001go|content\001golang)&content\001java)" {
+		t.Fatalf("展开结果错误 %s", s)
+	}
+	if query.Must[0].Prefix == nil {
+		t.Fatal("不应修改原来的查询")
+	}
+	plain := types.NewTermQuery("content", "go").Or(types.NewTermQuery("content", "java"))
+	if reverseindex.ExpandQuery(plain, dict, 2) != plain {
+		t.Fatal("没有需要展开的节点时应原样返回")
+	}
+	//Keyword节点下MustNot里的前缀也要展开
+	keywordNot := &types.TermQuery{Keyword: &types.Keyword{Field: "content", Word: "java"}, MustNot: []*types.TermQuery{types.NewPrefixQuery("content", "gop")}}
+	if s := reverseindex.ExpandQuery(keywordNot, dict, 2).ToString(); s != "(content\001java&!content\001gopher)" {
+		t.Fatalf("Keyword节点下的MustNot应展开 %s", s)
+	}
+}
+
+func TestPrefixWildcardQuery(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newDoc("a", 1, "go", "java"))
+		indexer.Add(newDoc("b", 2, "golang"))
+		indexer.Add(newDoc("c", 3, "gopher", "rust"))
+		indexer.Add(newDoc("d", 4, "python"))
+
+		cases := []struct {
+			query  *types.TermQuery
+			expect string
+		}{
+			{types.NewPrefixQuery("content", "go"), "[a b c]"},
+			{types.NewPrefixQuery("content", "gol"), "[b]"},
+			{types.NewPrefixQuery("content", "c++"), "[]"},
+			{types.NewWildcardQuery("content", "go*r"), "[c]"},
+			{types.NewWildcardQuery("content", "?o"), "[a]"},
+			{types.NewWildcardQuery("content", "*a*"), "[a b]"},
+			{types.NewPrefixQuery("content", "go").And(types.NewTermQuery("content", "rust")), "[c]"},
+			{types.NewPrefixQuery("content", "c++").And(types.NewTermQuery("content", "rust")), "[]"}, //没展开出任何keyword时不能被当成空节点忽略
+			{types.NewPrefixQuery("content", "go").Not(types.NewWildcardQuery("content", "j*")), "[b c]"},
+		}
+		for _, c := range cases {
+			if ids := fmt.Sprint(hitIds(indexer.Search(c.query, 0, 0, nil))); ids != c.expect {
+				t.Fatalf("%s 应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+			if ids := fmt.Sprint(hitIds(indexer.SearchTopK(c.query, 10, 0, 0, nil))); ids != c.expect {
+				t.Fatalf("%s SearchTopK应命中%s，实际命中%s", c.query.ToString(), c.expect, ids)
+			}
+		}
+
+		if terms := fmt.Sprint(indexer.Terms("content", "go", 2)); terms != "[go golang]" {
+			t.Fatalf("自动补全结果错误 %s", terms)
+		}
+		indexer.Delete(2, &types.Keyword{Field: "content", Word: "golang"})
+		if terms := fmt.Sprint(indexer.Terms("content", "go", 0)); terms != "[go gopher]" {
+			t.Fatalf("倒排链为空的term不应出现在字典里 %s", terms)
+		}
+	})
+}
+
+// 按字符计算的编辑距离，对照组
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		row := make([]int, len(rb)+1)
+		row[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			row[j+1] = min(row[j]+1, prev[j+1]+1, prev[j]+cost)
+		}
+		prev = row
+	}
+	return prev[len(rb)]
+}
+
+func TestTermDictFuzzy(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	letters := []string{"a", "b", "c", "小", "男", "楠"} //汉字是多字节的，会被劈在前缀树的不同边上
+	randomWord := func() string {
+		sb := strings.Builder{}
+		for i := rnd.Intn(6); i >= 0; i-- {
+			sb.WriteString(letters[rnd.Intn(len(letters))])
+		}
+		return sb.String()
+	}
+	dict := reverseindex.NewTermDict()
+	words := make(map[string]bool)
+	for i := 0; i < 2000; i++ {
+		word := randomWord()
+		dict.Add((&types.Keyword{Field: "author", Word: word}).ToString())
+		dict.Add((&types.Keyword{Field: "title", Word: randomWord()}).ToString())
+		words[word] = true
+	}
+	for round := 0; round < 100; round++ {
+		target := randomWord()
+		maxEdits := rnd.Intn(3)
+		want := make([]reverseindex.FuzzyTerm, 0)
+		for word := range words {
+			if d := levenshtein(word, target); d <= maxEdits {
+				want = append(want, reverseindex.FuzzyTerm{Word: word, Distance: d})
+			}
+		}
+		sort.Slice(want, func(i, j int) bool {
+			if want[i].Distance != want[j].Distance {
+				return want[i].Distance < want[j].Distance
+			}
+			return want[i].Word < want[j].Word
+		})
+		if got := dict.Fuzzy("author", target, maxEdits, 0); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%q编辑距离%d以内应得到%v，实际得到%v", target, maxEdits, want, got)
+		}
+		if len(want) > 3 {
+			if got := dict.Fuzzy("author", target, maxEdits, 3); fmt.Sprint(got) != fmt.Sprint(want[:3]) {
+				t.Fatalf("%q最多返回3个，应得到%v，实际得到%v", target, want[:3], got)
+			}
+		}
+	}
+}
+
+func TestFuzzyQuery(t *testing.T) {
+	results := make([][]reverseindex.SearchHit, 0, len(indexTypes))
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := newReverseIndexer(t, indexType, 100)
+		indexer.Add(newDoc("a", 1, "golang", "入门"))
+		indexer.Add(newDoc("b", 2, "golan", "教程"))
+		indexer.Add(newDoc("c", 3, "linux小男"))
+		indexer.Add(newDoc("d", 4, "linux小楠"))
+		indexer.Add(newDoc("e", 5, "java"))
+
+		if ids := fmt.Sprint(hitIds(indexer.Search(types.NewFuzzyQuery("content", "golnag", 2), 0, 0, nil))); ids != "[a b]" {
+			t.Fatalf("golnag应模糊匹配到golang和golan，实际命中%s", ids)
+		}
+		if ids := fmt.Sprint(hitIds(indexer.Search(types.NewFuzzyQuery("content", "golnag", 1), 0, 0, nil))); ids != "[]" {
+			t.Fatalf("golnag跟golang的编辑距离是2，实际命中%s", ids)
+		}
+		query := types.NewFuzzyQuery("content", "golang", 1)
+		hits := indexer.Search(query, 0, 0, nil)
+		if len(hits) != 2 || hits[0].Id != "a" || hits[1].Id != "b" {
+			t.Fatalf("完全匹配的文档应排在前面 %v", hits)
+		}
+		if !identicalHits(hits[:1], indexer.SearchTopK(query, 1, 0, 0, nil)) {
+			t.Fatal("SearchTopK的结果应跟Search的前k个一致")
+		}
+		hits = indexer.Search(types.NewFuzzyQuery("content", "linux小男", 1).And(types.NewFuzzyQuery("content", "jaba", 0)), 0, 0, nil)
+		if len(hits) != 0 {
+			t.Fatalf("模糊查询没有匹配的term时一个文档都不应命中 %v", hits)
+		}
+		hits = indexer.Search(types.NewFuzzyQuery("content", "linux小男", 1), 0, 0, nil)
+		if len(hits) != 2 || hits[0].Id != "c" {
+			t.Fatalf("linux小男应排在linux小楠前面 %v", hits)
+		}
+		results = append(results, hits)
+	})
+	for i := 1; i < len(results); i++ {
+		if !identicalHits(results[0], results[i]) {
+			t.Fatalf("%s的得分跟跳表不一致", indexTypes[i].name)
+		}
+	}
+}
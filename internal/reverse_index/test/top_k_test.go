@@ -0,0 +1,92 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// 构造一个词频、文档长度都随机的索引。词的分布是倾斜的，小编号的词很常见
+func buildRandomIndex(tb testing.TB, indexType int, docNum int) reverseindex.IReverseIndexer {
+	rnd := rand.New(rand.NewSource(1))
+	indexer := newReverseIndexer(tb, indexType, docNum)
+	for i := 1; i <= docNum; i++ {
+		n := 1 + rnd.Intn(10)
+		words := make([]string, 0, n)
+		for j := 0; j < n; j++ {
+			words = append(words, "w"+strconv.Itoa(int(math.Sqrt(float64(rnd.Intn(400))))))
+		}
+		doc := newDoc("doc"+strconv.Itoa(i), uint64(i), words...)
+		doc.BitsFeature = uint64(rnd.Intn(4))
+		indexer.Add(doc)
+	}
+	return indexer
+}
+
+func sameHits(a, b []reverseindex.SearchHit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Id != b[i].Id || math.Abs(a[i].Score-b[i].Score) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchTopK(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		indexer := buildRandomIndex(t, indexType, 5000)
+		w := func(i int) *types.TermQuery { return types.NewTermQuery("content", "w"+strconv.Itoa(i)) }
+		querys := []*types.TermQuery{
+			w(3),
+			w(1).Or(w(7)),
+			w(0).Or(w(5), w(12), w(19)),
+			new(types.TermQuery).And(w(2)).Or(w(15)), //嵌套的单孩子节点
+			w(4).And(w(9)),
+			w(1).And(w(2), w(18)),
+			w(3).Or(w(6)).And(w(1)), //既非纯析取也非纯合取
+			w(2).Or(w(8)).Not(w(0)),
+			w(99).Or(w(3)), //w99不存在
+		}
+		for _, query := range querys {
+			for _, k := range []int{1, 5, 50, 100000} {
+				for _, orFlags := range [][]uint64{nil, {1}} {
+					expect := indexer.Search(query, 0, 0, orFlags)
+					if len(expect) > k {
+						expect = expect[:k]
+					}
+					got := indexer.SearchTopK(query, k, 0, 0, orFlags)
+					if !sameHits(expect, got) {
+						t.Fatalf("%s k=%d, expect %v, got %v", query.ToString(), k, expect, got)
+					}
+				}
+			}
+		}
+	})
+}
+
+func BenchmarkSearchAll(b *testing.B) {
+	indexer := buildRandomIndex(b, reverseindex.SKIPLIST, 100000)
+	query := types.NewTermQuery("content", "w1").Or(types.NewTermQuery("content", "w2"), types.NewTermQuery("content", "w15"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hits := indexer.Search(query, 0, 0, nil)
+		if len(hits) > 10 {
+			hits = hits[:10]
+		}
+	}
+}
+
+func BenchmarkSearchTopK(b *testing.B) {
+	indexer := buildRandomIndex(b, reverseindex.SKIPLIST, 100000)
+	query := types.NewTermQuery("content", "w1").Or(types.NewTermQuery("content", "w2"), types.NewTermQuery("content", "w15"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		indexer.SearchTopK(query, 10, 0, 0, nil)
+	}
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	reverseindex "github.com/Muoshu/myRadic/internal/reverse_index"
+	"github.com/Muoshu/myRadic/types"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// 随机改动文档：删掉一些keyword、追加一些keyword，偶尔改BitsFeature和数值字段，偶尔清空所有keyword
+func randomUpdate(rnd *rand.Rand, old types.Document) types.Document {
+	doc := old
+	doc.Keywords = make([]*types.Keyword, 0, len(old.Keywords)+2)
+	if rnd.Intn(10) > 0 {
+		for _, keyword := range old.Keywords {
+			if rnd.Intn(4) > 0 {
+				doc.Keywords = append(doc.Keywords, keyword)
+			}
+		}
+		for i := rnd.Intn(3); i > 0; i-- {
+			doc.Keywords = append(doc.Keywords, &types.Keyword{Field: "content", Word: "w" + strconv.Itoa(rnd.Intn(25))})
+		}
+	}
+	if rnd.Intn(3) == 0 {
+		doc.BitsFeature = uint64(rnd.Intn(4))
+	}
+	switch rnd.Intn(4) {
+	case 0:
+		doc.Numerics = nil
+	case 1:
+		doc.Numerics = map[string]int64{"view": int64(rnd.Intn(100))}
+	}
+	return doc
+}
+
+// 比较两个索引上的各种查询：布尔查询、带bits过滤的TopK、短语查询、Range查询和数值字段
+func checkSameAfterUpdate(t *testing.T, stage string, expect, got reverseindex.IReverseIndexer, docs map[uint64]types.Document) {
+	rnd := rand.New(rand.NewSource(3))
+	checkSameResults(t, stage, expect, got)
+	for round := 0; round < 50; round++ {
+		queries := []*types.TermQuery{
+			types.NewPhraseQuery("content", "w"+strconv.Itoa(rnd.Intn(25)), "w"+strconv.Itoa(rnd.Intn(25))),
+			types.NewTermQuery("content", "w"+strconv.Itoa(rnd.Intn(25))).And(types.NewRangeQuery("view", 0, int64(rnd.Intn(100)))),
+		}
+		for _, query := range queries {
+			if !identicalHits(expect.Search(query, 0, 0, nil), got.Search(query, 0, 0, nil)) {
+				t.Fatalf("%s: %s Search结果不一致", stage, query.ToString())
+			}
+		}
+	}
+	for intId := range docs {
+		want, wantOk := expect.Numeric(intId, "view")
+		if value, ok := got.Numeric(intId, "view"); value != want || ok != wantOk {
+			t.Fatalf("%s: 文档%d的view应为%d(%t)，实际为%d(%t)", stage, intId, want, wantOk, value, ok)
+		}
+	}
+}
+
+// 原地更新之后的检索结果(包括得分)，跟删掉旧文档再添加新文档完全一致
+func TestUpdate(t *testing.T) {
+	forEachIndexType(t, func(t *testing.T, indexType int) {
+		rnd := rand.New(rand.NewSource(1))
+		expect := reverseindex.NewSkipListReverseIndex(1000)
+		indexer := newReverseIndexer(t, indexType, 1000)
+		docs := make(map[uint64]types.Document, 1000)
+		for i := 1; i <= 1000; i++ {
+			words := make([]string, 0, 6)
+			for j := rnd.Intn(6); j >= 0; j-- {
+				words = append(words, "w"+strconv.Itoa(rnd.Intn(25)))
+			}
+			doc := newNumericDoc("doc"+strconv.Itoa(i), uint64(i), int64(rnd.Intn(100)), words...)
+			doc.BitsFeature = uint64(rnd.Intn(4))
+			docs[doc.IntId] = doc
+			expect.Add(doc)
+			indexer.Add(doc)
+		}
+		for round := 0; round < 3000; round++ {
+			intId := 1 + uint64(rnd.Intn(1000))
+			old := docs[intId]
+			doc := randomUpdate(rnd, old)
+			docs[intId] = doc
+			expect.DeleteBatch([]*types.Document{&old})
+			expect.Add(doc)
+			indexer.Update(&old, doc)
+		}
+		checkSameAfterUpdate(t, "更新后", expect, indexer, docs)
+	})
+}
+
+// 段式索引上更新的文档分布在多个段上，flush、合并、重启之后结果都不能变
+func TestSegmentUpdate(t *testing.T) {
+	dir := t.TempDir()
+	rnd := rand.New(rand.NewSource(1))
+	expect := reverseindex.NewSkipListReverseIndex(1000)
+	indexer := openSegmentIndex(t, dir)
+	docs := make(map[uint64]types.Document, 2000)
+	var intId uint64
+	for i := 0; i < 2000; i++ {
+		if intId == 0 || rnd.Intn(2) == 0 {
+			intId++
+			words := make([]string, 0, 6)
+			for j := rnd.Intn(6); j >= 0; j-- {
+				words = append(words, "w"+strconv.Itoa(rnd.Intn(25)))
+			}
+			doc := newNumericDoc("doc"+strconv.Itoa(int(intId)), intId, int64(rnd.Intn(100)), words...)
+			doc.BitsFeature = uint64(rnd.Intn(4))
+			docs[intId] = doc
+			expect.Add(doc)
+			indexer.Add(doc)
+			continue
+		}
+		target := 1 + uint64(rnd.Intn(int(intId)))
+		old := docs[target]
+		doc := randomUpdate(rnd, old)
+		docs[target] = doc
+		expect.DeleteBatch([]*types.Document{&old})
+		expect.Add(doc)
+		indexer.Update(&old, doc)
+	}
+	checkSameAfterUpdate(t, "更新后", expect, indexer, docs)
+	if err := indexer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	checkSameAfterUpdate(t, "flush后", expect, indexer, docs)
+	if err := indexer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	indexer = openSegmentIndex(t, dir)
+	if !indexer.Loaded() {
+		t.Fatal("重启后应该从磁盘加载数据")
+	}
+	checkSameAfterUpdate(t, "重启后", expect, indexer, docs)
+	if err := indexer.Merge(); err != nil {
+		t.Fatal(err)
+	}
+	checkSameAfterUpdate(t, "合并后", expect, indexer, docs)
+	if err := indexer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,222 @@
+package reverse_index
+
+import (
+	"container/heap"
+	"github.com/Muoshu/myRadic/types"
+	"sort"
+)
+
+// hitHeap 小顶堆，堆顶是当前top-k里最差的那个结果
+type hitHeap []SearchHit
+
+func (h hitHeap) Len() int { return len(h) }
+func (h hitHeap) Less(i, j int) bool {
+	if h[i].Score != h[j].Score {
+		return h[i].Score < h[j].Score
+	}
+	return h[i].Id > h[j].Id //得分相同时业务Id大的更差，跟SortHits保持一致
+}
+func (h hitHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *hitHeap) Push(x any)   { *h = append(*h, x.(SearchHit)) }
+func (h *hitHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// topKCollector 收集得分最高的k个结果
+type topKCollector struct {
+	k    int
+	hits hitHeap
+}
+
+func newTopKCollector(k int) *topKCollector {
+	return &topKCollector{k: k, hits: make(hitHeap, 0, min(k, 1024))}
+}
+
+func (c *topKCollector) full() bool {
+	return len(c.hits) >= c.k
+}
+
+// threshold 新结果的得分不小于它才可能进入top-k。得分相同时按业务Id比较，跟到来的先后无关，所以得分相等的后来者也可能挤掉先来者
+func (c *topKCollector) threshold() float64 {
+	return c.hits[0].Score
+}
+
+func (c *topKCollector) collect(hit SearchHit) {
+	if !c.full() {
+		heap.Push(&c.hits, hit)
+	} else if hitHeap([]SearchHit{c.hits[0], hit}).Less(0, 1) {
+		c.hits[0] = hit
+		heap.Fix(&c.hits, 0)
+	}
+}
+
+func (c *topKCollector) result() []SearchHit {
+	res := []SearchHit(c.hits)
+	SortHits(res)
+	return res
+}
+
+// 把多层只有一个孩子的Must、Should剥掉。带MustNot的节点不能剥
+func unwrapQuery(q *types.TermQuery) *types.TermQuery {
+	for q.Keyword == nil && len(q.MustNot) == 0 {
+		if len(q.Must) == 1 {
+			q = q.Must[0]
+		} else if len(q.Should) == 1 && len(q.Must) == 0 {
+			q = q.Should[0]
+		} else {
+			break
+		}
+	}
+	return q
+}
+
+// 如果q是由若干个keyword组成的纯析取(should=true)或纯合取(should=false)，返回这些Keyword节点
+func collectLeaves(q *types.TermQuery, should bool, leaves []*types.TermQuery) ([]*types.TermQuery, bool) {
+	q = unwrapQuery(q)
+	if q.Keyword != nil {
+		return append(leaves, q), true
+	}
+	if len(q.MustNot) > 0 {
+		return leaves, false
+	}
+	children := q.Must
+	if should {
+		children = q.Should
+		if len(q.Must) > 0 {
+			return leaves, false
+		}
+	}
+	if len(children) == 0 {
+		return leaves, false
+	}
+	for _, child := range children {
+		var ok bool
+		if leaves, ok = collectLeaves(child, should, leaves); !ok {
+			return leaves, false
+		}
+	}
+	return leaves, true
+}
+
+// 按查询树的结构自底向上、从左往右累加各keyword的得分。跟Search的累加顺序保持一致，保证浮点数结果完全相同
+func sumTreeScore(q *types.TermQuery, scores []float64, pos *int) float64 {
+	q = unwrapQuery(q)
+	if q.Keyword != nil {
+		score := scores[*pos]
+		*pos++
+		return score
+	}
+	children := q.Must
+	if len(children) == 0 {
+		children = q.Should
+	}
+	var sum float64
+	for _, child := range children {
+		sum += sumTreeScore(child, scores, pos)
+	}
+	return sum
+}
+
+// SearchTopK 只返回得分最高的k个结果，结果跟CollectAll的前k个完全一致。
+//
+// 纯析取的查询走WAND算法：每个term有一个得分上界，按当前文档id排好序的迭代器依次累加上界，
+// 累加值首次超过top-k门槛的那个迭代器所指的文档称为pivot，比pivot小的文档不可能进入top-k，前面的迭代器直接跳到pivot。
+// 其他查询遍历迭代器树，得分上界进不了top-k时提前结束
+func SearchTopK(query *types.TermQuery, k int, open LeafOpener) []SearchHit {
+	if query == nil {
+		return nil
+	}
+	if leaves, ok := collectLeaves(query, true, nil); ok {
+		its := make([]PostingIterator, 0, len(leaves))
+		for _, leaf := range leaves {
+			its = append(its, openLeaf(leaf, open, true))
+		}
+		return wand(query, its, k)
+	}
+	return CollectTopK(BuildIterator(query, open), k)
+}
+
+// CollectTopK 遍历迭代器，返回得分最高的k个结果
+func CollectTopK(it PostingIterator, k int) []SearchHit {
+	if it == nil || k <= 0 {
+		return nil
+	}
+	collector := newTopKCollector(k)
+	maxScore := it.MaxScore()
+	for doc := it.Next(); doc != NO_MORE_DOCS; doc = it.Next() {
+		collector.collect(SearchHit{Id: it.Id(), IntId: doc, Score: it.Score()})
+		if collector.full() && maxScore < collector.threshold() { //后面的文档不可能再进入top-k
+			break
+		}
+	}
+	return collector.result()
+}
+
+// wandCursor WAND算法里的一个叶子迭代器
+type wandCursor struct {
+	it  PostingIterator
+	ord int //对应查询里的第几个keyword
+}
+
+func wand(query *types.TermQuery, its []PostingIterator, k int) []SearchHit {
+	cursors := make([]wandCursor, 0, len(its))
+	for i, it := range its {
+		it.Next()
+		cursors = append(cursors, wandCursor{it: it, ord: i})
+	}
+	collector := newTopKCollector(k)
+	scores := make([]float64, len(its))
+	for {
+		//移除已走到尽头的迭代器，剩下的按当前文档id排序
+		alive := cursors[:0]
+		for _, c := range cursors {
+			if c.it.DocId() != NO_MORE_DOCS {
+				alive = append(alive, c)
+			}
+		}
+		cursors = alive
+		if len(cursors) == 0 {
+			break
+		}
+		sort.Slice(cursors, func(i, j int) bool { return cursors[i].it.DocId() < cursors[j].it.DocId() })
+
+		//寻找pivot
+		pivot := -1
+		var acc float64
+		for i, c := range cursors {
+			acc += c.it.MaxScore()
+			if !collector.full() || acc >= collector.threshold() {
+				pivot = i
+				break
+			}
+		}
+		if pivot < 0 { //所有term的上界加起来都进不了top-k，提前结束
+			break
+		}
+		pivotDoc := cursors[pivot].it.DocId()
+		if cursors[0].it.DocId() == pivotDoc {
+			//pivot之前的迭代器都已对齐到pivotDoc，对它完整打分
+			id := cursors[0].it.Id()
+			clear(scores)
+			for _, c := range cursors {
+				if c.it.DocId() != pivotDoc {
+					break
+				}
+				scores[c.ord] = c.it.Score()
+				c.it.Next()
+			}
+			pos := 0
+			collector.collect(SearchHit{Id: id, IntId: pivotDoc, Score: sumTreeScore(query, scores, &pos)})
+		} else {
+			//pivot之前的文档不可能进入top-k，跳过
+			for _, c := range cursors[:pivot] {
+				c.it.Advance(pivotDoc)
+			}
+		}
+	}
+	return collector.result()
+}
@@ -0,0 +1,20 @@
+package reverse_index
+
+import "slices"
+
+// diffPostings 比较文档更新前后每个key上的posting。removed是更新后不再出现的key，changed是新出现或者位置变了的key及其新位置。
+// rewriteAll为true时(比如BitsFeature变了，posting上存着bits的实现需要重写)所有更新后的key都算changed
+func diffPostings(oldPositions, newPositions map[string][]int32, rewriteAll bool) (removed []string, changed map[string][]int32) {
+	changed = make(map[string][]int32, len(newPositions))
+	for key := range oldPositions {
+		if _, ok := newPositions[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	for key, positions := range newPositions {
+		if old, ok := oldPositions[key]; !ok || rewriteAll || !slices.Equal(old, positions) {
+			changed[key] = positions
+		}
+	}
+	return
+}
@@ -0,0 +1,165 @@
+package test
+
+import (
+	"fmt"
+	"github.com/Muoshu/myRadic/internal/wal"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func openWal(t *testing.T, dir string) *wal.WAL {
+	log, err := wal.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func replay(t *testing.T, log *wal.WAL) []string {
+	var records []string
+	if err := log.Replay(func(record []byte) error {
+		records = append(records, string(record))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestAppendReplay(t *testing.T) {
+	dir := t.TempDir()
+	log := openWal(t, dir)
+	if err := log.Append([]byte("a"), []byte("bb")); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Append([]byte("")); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Append([]byte("ccc")); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(replay(t, log), ","); got != "a,bb,,ccc" {
+		t.Errorf("replay got %q", got)
+	}
+	log.Close()
+	if err := log.Append([]byte("d")); err != wal.ErrClosed {
+		t.Errorf("append after close got %v", err)
+	}
+
+	//重新打开后继续追加
+	log = openWal(t, dir)
+	defer log.Close()
+	log.Append([]byte("d"))
+	if got := strings.Join(replay(t, log), ","); got != "a,bb,,ccc,d" {
+		t.Errorf("replay after reopen got %q", got)
+	}
+	if err := log.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if log.Size() != 0 || len(replay(t, log)) != 0 {
+		t.Errorf("wal not empty after reset")
+	}
+}
+
+// 并发的Append都要落盘，同一次Append的记录连续写入
+func TestGroupCommit(t *testing.T) {
+	dir := t.TempDir()
+	log := openWal(t, dir)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := log.Append([]byte(fmt.Sprintf("%02d-1", i)), []byte(fmt.Sprintf("%02d-2", i))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	log.Close()
+
+	log = openWal(t, dir)
+	defer log.Close()
+	records := replay(t, log)
+	if len(records) != 100 {
+		t.Fatalf("replay %d records, expect 100", len(records))
+	}
+	for i := 0; i < len(records); i += 2 {
+		if records[i][:3]+"2" != records[i+1] {
+			t.Errorf("records of one append are not adjacent: %s %s", records[i], records[i+1])
+		}
+	}
+	sort.Strings(records)
+	for i := 0; i < 50; i++ {
+		if records[2*i] != fmt.Sprintf("%02d-1", i) {
+			t.Errorf("missing records of append %d", i)
+		}
+	}
+}
+
+// 写到一半的记录和校验失败的记录在打开时被截掉，之后的追加不受影响
+func TestBrokenTail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wal.log")
+	log := openWal(t, dir)
+	log.Append([]byte("first"), []byte("second"), []byte("third"))
+	log.Close()
+
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+	log = openWal(t, dir)
+	if got := strings.Join(replay(t, log), ","); got != "first,second" {
+		t.Errorf("replay torn wal got %q", got)
+	}
+	log.Append([]byte("fourth"))
+	log.Close()
+
+	//改掉最后一条记录的一个字节
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+	log = openWal(t, dir)
+	defer log.Close()
+	if got := strings.Join(replay(t, log), ","); got != "first,second" {
+		t.Errorf("replay corrupt wal got %q", got)
+	}
+	log.Append([]byte("fifth"))
+	if got := strings.Join(replay(t, log), ","); got != "first,second,fifth" {
+		t.Errorf("replay after repair got %q", got)
+	}
+}
+
+// 长度字段坏了(比如变成一个很大的数)时不能照着它分配内存，当作坏记录截掉
+func TestCorruptLength(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wal.log")
+	log := openWal(t, dir)
+	log.Append([]byte("first"), []byte("second"))
+	log.Close()
+
+	data, _ := os.ReadFile(path)
+	second := 8 + len("first")
+	data[second], data[second+1], data[second+2], data[second+3] = 0xff, 0xff, 0xff, 0xff
+	os.WriteFile(path, data, 0o644)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	log = openWal(t, dir)
+	defer log.Close()
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("allocate %d bytes when opening wal with corrupt length", allocated)
+	}
+	if got := strings.Join(replay(t, log), ","); got != "first" {
+		t.Errorf("replay wal with corrupt length got %q", got)
+	}
+	if size := log.Size(); size != int64(second) {
+		t.Errorf("size %d after truncating corrupt length, expect %d", size, second)
+	}
+}
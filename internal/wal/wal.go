@@ -0,0 +1,262 @@
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"github.com/Muoshu/myRadic/util"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	fileName     = "wal.log"
+	headerSize   = 8       //4个字节的长度加4个字节的crc
+	maxGroupSize = 1 << 10 //一次落盘最多合并这么多个Append请求
+)
+
+var (
+	ErrClosed  = errors.New("wal is closed")
+	crcTable   = crc32.MakeTable(crc32.Castagnoli)
+	errCorrupt = errors.New("corrupt wal record")
+)
+
+// WAL 只追加的预写日志。每条记录是 长度(4字节)+crc32(4字节)+内容，先落盘再修改索引，崩溃后重放。
+// 并发的Append合并成一次写盘和一次fsync(group commit)
+type WAL struct {
+	path     string
+	file     *os.File
+	size     int64      //文件里有效记录的总长度
+	lock     sync.Mutex //写文件、Reset、Close互斥
+	requests chan *appendRequest
+	done     chan struct{} //Close时关闭，通知后台的写协程退出
+	stopped  chan struct{} //后台的写协程退出后关闭
+	closed   bool
+}
+
+type appendRequest struct {
+	records [][]byte
+	err     chan error
+}
+
+// Open 打开dir下的日志文件，不存在时创建。文件末尾不完整或校验失败的记录(写到一半时崩溃)会被截掉
+func Open(dir string) (*WAL, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	size, err := validLength(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info, err := file.Stat(); err == nil && info.Size() > size {
+		util.Log.Printf("truncate %d bytes of broken records at the end of %s", info.Size()-size, path)
+		if err := file.Truncate(size); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	if _, err := file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	w := &WAL{path: path, file: file, size: size, requests: make(chan *appendRequest, maxGroupSize), done: make(chan struct{}), stopped: make(chan struct{})}
+	go w.writeLoop()
+	return w, nil
+}
+
+// 从头读到第一条坏记录为止，返回有效记录的总长度
+func validLength(file *os.File) (int64, error) {
+	var size int64
+	err := readRecords(file, func(record []byte) error {
+		size += headerSize + int64(len(record))
+		return nil
+	})
+	if err == errCorrupt {
+		err = nil
+	}
+	return size, err
+}
+
+// 依次读出每条记录，遇到不完整或校验失败的记录时返回errCorrupt
+func readRecords(file *os.File, fn func(record []byte) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(file)
+	header := make([]byte, headerSize)
+	for offset := int64(0); ; {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errCorrupt //只写了一半的header
+		}
+		offset += headerSize
+		length := int64(binary.LittleEndian.Uint32(header))
+		if length > info.Size()-offset { //长度字段还没校验过，可能是坏的，不能照着它分配内存
+			return errCorrupt
+		}
+		offset += length
+		record := make([]byte, length)
+		if _, err := io.ReadFull(reader, record); err != nil {
+			return errCorrupt
+		}
+		if crc32.Checksum(record, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+			return errCorrupt
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// Replay 按写入的顺序回放全部记录。fn返回error时停止回放
+func (w *WAL) Replay(fn func(record []byte) error) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	defer w.file.Seek(w.size, io.SeekStart)
+	err := readRecords(w.file, fn)
+	if err == errCorrupt { //Open时已经截掉了坏记录，不应该再出现
+		err = nil
+	}
+	return err
+}
+
+// Append 写入records并fsync之后才返回。同一次调用的records连续写入
+func (w *WAL) Append(records ...[]byte) error {
+	if len(records) == 0 {
+		return nil
+	}
+	request := &appendRequest{records: records, err: make(chan error, 1)}
+	select {
+	case w.requests <- request:
+	case <-w.done:
+		return ErrClosed
+	}
+	select {
+	case err := <-request.err:
+		return err
+	case <-w.stopped: //写协程退出前已经回复了它取走的请求，没回复的说明请求还在队列里
+		select {
+		case err := <-request.err:
+			return err
+		default:
+			return ErrClosed
+		}
+	}
+}
+
+// 后台把排队的Append请求合并起来，一次写盘、一次fsync
+func (w *WAL) writeLoop() {
+	defer close(w.stopped)
+	group := make([]*appendRequest, 0, maxGroupSize)
+	for {
+		select {
+		case request := <-w.requests:
+			group = append(group[:0], request)
+		collect:
+			for len(group) < maxGroupSize {
+				select {
+				case request := <-w.requests:
+					group = append(group, request)
+				default:
+					break collect
+				}
+			}
+			err := w.write(group)
+			for _, request := range group {
+				request.err <- err
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *WAL) write(group []*appendRequest) error {
+	length := 0
+	for _, request := range group {
+		for _, record := range request.records {
+			length += headerSize + len(record)
+		}
+	}
+	buf := make([]byte, 0, length)
+	for _, request := range group {
+		for _, record := range request.records {
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(len(record)))
+			buf = binary.LittleEndian.AppendUint32(buf, crc32.Checksum(record, crcTable))
+			buf = append(buf, record...)
+		}
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	_, err := w.file.Write(buf)
+	if err == nil {
+		err = w.file.Sync()
+	}
+	if err != nil {
+		//写了一半的记录不能留下，否则后面的记录都读不出来。fsync失败时这批记录不算写入，也要截掉，让w.size跟文件偏移保持一致
+		w.file.Truncate(w.size)
+		w.file.Seek(w.size, io.SeekStart)
+		return err
+	}
+	w.size += int64(len(buf))
+	return nil
+}
+
+// Size 日志里有效记录的总字节数
+func (w *WAL) Size() int64 {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.size
+}
+
+// Reset 清空日志。调用方需要保证之前的记录都已经持久化到了索引里，且没有并发的Append
+func (w *WAL) Reset() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.closed {
+		return ErrClosed
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.size = 0
+	return w.file.Sync()
+}
+
+// Close 等后台的写协程退出后关闭文件，还在排队的Append返回ErrClosed
+func (w *WAL) Close() error {
+	w.lock.Lock()
+	if w.closed {
+		w.lock.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.lock.Unlock()
+	close(w.done)
+	<-w.stopped
+	return w.file.Close()
+}
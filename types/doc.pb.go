@@ -4,6 +4,7 @@
 package types
 
 import (
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	proto "github.com/gogo/protobuf/proto"
 	io "io"
@@ -75,11 +76,13 @@ func (m *Keyword) GetWord() string {
 }
 
 type Document struct {
-	Id          string     `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
-	IntId       uint64     `protobuf:"varint,2,opt,name=IntId,proto3" json:"IntId,omitempty"`
-	BitsFeature uint64     `protobuf:"varint,3,opt,name=BitsFeature,proto3" json:"BitsFeature,omitempty"`
-	Keywords    []*Keyword `protobuf:"bytes,4,rep,name=Keywords,proto3" json:"Keywords,omitempty"`
-	Bytes       []byte     `protobuf:"bytes,5,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	Id          string           `protobuf:"bytes,1,opt,name=Id,proto3" json:"Id,omitempty"`
+	IntId       uint64           `protobuf:"varint,2,opt,name=IntId,proto3" json:"IntId,omitempty"`
+	BitsFeature uint64           `protobuf:"varint,3,opt,name=BitsFeature,proto3" json:"BitsFeature,omitempty"`
+	Keywords    []*Keyword       `protobuf:"bytes,4,rep,name=Keywords,proto3" json:"Keywords,omitempty"`
+	Bytes       []byte           `protobuf:"bytes,5,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	Score       float64          `protobuf:"fixed64,6,opt,name=Score,proto3" json:"Score,omitempty"`
+	Numerics    map[string]int64 `protobuf:"bytes,7,rep,name=Numerics,proto3" json:"Numerics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
 }
 
 func (m *Document) Reset()         { *m = Document{} }
@@ -150,29 +153,49 @@ func (m *Document) GetBytes() []byte {
 	return nil
 }
 
+func (m *Document) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *Document) GetNumerics() map[string]int64 {
+	if m != nil {
+		return m.Numerics
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Keyword)(nil), "types.Keyword")
 	proto.RegisterType((*Document)(nil), "types.Document")
+	proto.RegisterMapType((map[string]int64)(nil), "types.Document.NumericsEntry")
 }
 
 func init() { proto.RegisterFile("doc.proto", fileDescriptor_37cb16cf10c66117) }
 
 var fileDescriptor_37cb16cf10c66117 = []byte{
-	// 218 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4c, 0xc9, 0x4f, 0xd6,
-	0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2d, 0xa9, 0x2c, 0x48, 0x2d, 0x56, 0x32, 0xe6, 0x62,
-	0xf7, 0x4e, 0xad, 0x2c, 0xcf, 0x2f, 0x4a, 0x11, 0x12, 0xe1, 0x62, 0x75, 0xcb, 0x4c, 0xcd, 0x49,
-	0x91, 0x60, 0x54, 0x60, 0xd4, 0xe0, 0x0c, 0x82, 0x70, 0x84, 0x84, 0xb8, 0x58, 0xc2, 0xf3, 0x8b,
-	0x52, 0x24, 0x98, 0xc0, 0x82, 0x60, 0xb6, 0xd2, 0x14, 0x46, 0x2e, 0x0e, 0x97, 0xfc, 0xe4, 0xd2,
-	0xdc, 0xd4, 0xbc, 0x12, 0x21, 0x3e, 0x2e, 0x26, 0x4f, 0x98, 0x1e, 0x26, 0x4f, 0xb0, 0x31, 0x9e,
-	0x79, 0x25, 0x9e, 0x10, 0x1d, 0x2c, 0x41, 0x10, 0x8e, 0x90, 0x02, 0x17, 0xb7, 0x53, 0x66, 0x49,
-	0xb1, 0x5b, 0x6a, 0x62, 0x49, 0x69, 0x51, 0xaa, 0x04, 0x33, 0x58, 0x0e, 0x59, 0x48, 0x48, 0x8b,
-	0x8b, 0x03, 0xea, 0x92, 0x62, 0x09, 0x16, 0x05, 0x66, 0x0d, 0x6e, 0x23, 0x3e, 0x3d, 0xb0, 0x1b,
-	0xf5, 0xa0, 0xc2, 0x41, 0x70, 0x79, 0x90, 0x1d, 0x4e, 0x95, 0x25, 0xa9, 0xc5, 0x12, 0xac, 0x0a,
-	0x8c, 0x1a, 0x3c, 0x41, 0x10, 0x8e, 0x93, 0xe2, 0x89, 0x47, 0x72, 0x8c, 0x17, 0x1e, 0xc9, 0x31,
-	0x3e, 0x78, 0x24, 0xc7, 0x38, 0xe1, 0xb1, 0x1c, 0xc3, 0x85, 0xc7, 0x72, 0x0c, 0x37, 0x1e, 0xcb,
-	0x31, 0x44, 0xb1, 0xeb, 0x59, 0x83, 0x8d, 0x4a, 0x62, 0x03, 0x7b, 0xde, 0x18, 0x10, 0x00, 0x00,
-	0xff, 0xff, 0xf5, 0x4b, 0xda, 0xf5, 0x09, 0x01, 0x00, 0x00,
+	// 297 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x51, 0xbd, 0x4e, 0xf3, 0x30,
+	0x14, 0xad, 0xf3, 0xd3, 0xa4, 0xee, 0xf7, 0x55, 0xc8, 0x62, 0xb0, 0x90, 0xb0, 0x42, 0xa7, 0x88,
+	0x21, 0x03, 0x5d, 0x80, 0x6e, 0x11, 0x54, 0x8a, 0x90, 0x18, 0xcc, 0x80, 0xc4, 0x56, 0x92, 0x3b,
+	0x44, 0xb4, 0x71, 0xe5, 0x38, 0xa0, 0xbc, 0x05, 0xcf, 0xc0, 0xd3, 0x30, 0x76, 0x64, 0x44, 0xc9,
+	0x8b, 0xa0, 0xd8, 0x49, 0x05, 0xdb, 0x3d, 0xe7, 0xf8, 0x9e, 0x7b, 0x8e, 0x8c, 0x27, 0x99, 0x48,
+	0xa3, 0x9d, 0x14, 0x4a, 0x10, 0x57, 0xd5, 0x3b, 0x28, 0xe7, 0x0b, 0xec, 0xdd, 0x41, 0xfd, 0x26,
+	0x64, 0x46, 0x8e, 0xb1, 0xbb, 0xca, 0x61, 0x93, 0x51, 0x14, 0xa0, 0x70, 0xc2, 0x0d, 0x20, 0x04,
+	0x3b, 0x8f, 0x42, 0x66, 0xd4, 0xd2, 0xa4, 0x9e, 0xe7, 0x1f, 0x16, 0xf6, 0x6f, 0x44, 0x5a, 0x6d,
+	0xa1, 0x50, 0x64, 0x86, 0xad, 0x64, 0xd8, 0xb1, 0x12, 0x6d, 0x93, 0x14, 0x2a, 0x31, 0x1b, 0x0e,
+	0x37, 0x80, 0x04, 0x78, 0x1a, 0xe7, 0xaa, 0x5c, 0xc1, 0x5a, 0x55, 0x12, 0xa8, 0xad, 0xb5, 0xdf,
+	0x14, 0x39, 0xc7, 0x7e, 0x9f, 0xa4, 0xa4, 0x4e, 0x60, 0x87, 0xd3, 0x8b, 0x59, 0xa4, 0x33, 0x46,
+	0x3d, 0xcd, 0x0f, 0x7a, 0x77, 0x23, 0xae, 0x15, 0x94, 0xd4, 0x0d, 0x50, 0xf8, 0x8f, 0x1b, 0xd0,
+	0xb1, 0x0f, 0xa9, 0x90, 0x40, 0xc7, 0x01, 0x0a, 0x11, 0x37, 0x80, 0x5c, 0x61, 0xff, 0xbe, 0xda,
+	0x82, 0xcc, 0xd3, 0x92, 0x7a, 0xda, 0xf7, 0xb4, 0xf7, 0x1d, 0x2a, 0x44, 0x83, 0x7e, 0x5b, 0x28,
+	0x59, 0xf3, 0xc3, 0xf3, 0x93, 0x25, 0xfe, 0xff, 0x47, 0x22, 0x47, 0xd8, 0x7e, 0x81, 0xba, 0x2f,
+	0xdb, 0x8d, 0xdd, 0xcd, 0xd7, 0xf5, 0xa6, 0x02, 0xdd, 0xd6, 0xe6, 0x06, 0x5c, 0x5b, 0x97, 0x28,
+	0x3e, 0xfb, 0x6c, 0x18, 0xda, 0x37, 0x0c, 0x7d, 0x37, 0x0c, 0xbd, 0xb7, 0x6c, 0xb4, 0x6f, 0xd9,
+	0xe8, 0xab, 0x65, 0xa3, 0x27, 0x2f, 0x5a, 0xea, 0x00, 0xcf, 0x63, 0xfd, 0x15, 0x8b, 0x9f, 0x01,
+	0x00, 0xd2, 0xe2, 0x4b, 0x3f, 0x97, 0x01, 0x00, 0x00,
 }
 
 func (m *Keyword) Marshal() (dAtA []byte, err error) {
@@ -232,6 +255,29 @@ func (m *Document) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Numerics) > 0 {
+		for k := range m.Numerics {
+			v := m.Numerics[k]
+			baseI := i
+			i = encodeVarintDoc(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintDoc(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintDoc(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if m.Score != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Score))))
+		i--
+		dAtA[i] = 0x31
+	}
 	if len(m.Bytes) > 0 {
 		i -= len(m.Bytes)
 		copy(dAtA[i:], m.Bytes)
@@ -327,6 +373,17 @@ func (m *Document) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovDoc(uint64(l))
 	}
+	if m.Score != 0 {
+		n += 9
+	}
+	if len(m.Numerics) > 0 {
+		for k, v := range m.Numerics {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovDoc(uint64(len(k))) + 1 + sovDoc(uint64(v))
+			n += mapEntrySize + 1 + sovDoc(uint64(mapEntrySize))
+		}
+	}
 	return n
 }
 
@@ -617,6 +674,130 @@ func (m *Document) Unmarshal(dAtA []byte) error {
 				m.Bytes = []byte{}
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Score", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Score = float64(math.Float64frombits(v))
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Numerics", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDoc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDoc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDoc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Numerics == nil {
+				m.Numerics = make(map[string]int64)
+			}
+			var mapkey string
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowDoc
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowDoc
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthDoc
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthDoc
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowDoc
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipDoc(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthDoc
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Numerics[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipDoc(dAtA[iNdEx:])
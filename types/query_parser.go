@@ -0,0 +1,563 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError 查询语句有语法错误。Pos是出错的位置，按字符(而不是字节)计算，从0开始
+type ParseError struct {
+	Pos  int
+	Msg  string
+	Near string //从出错位置开始的一小段原文
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Near) == 0 {
+		return fmt.Sprintf("syntax error at position %d: %s", e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("syntax error at position %d near %q: %s", e.Pos, e.Near, e.Msg)
+}
+
+// ParseQuery 解析查询语句，例如
+//
+//	content:golang AND (author:"linux小楠" OR content:go) -content:java
+//
+// 语法：
+//   - field:word 匹配keyword，field:word^2 给得分加权，field:go* 前缀，field:g?o* 通配符，field:golang~1 模糊匹配
+//   - field:"w1 w2" 短语，field:"w1 w2"~3 邻近查询；引号里只有一个词时就是普通的keyword
+//   - field:[min,max] 数值区间，*表示不限
+//   - AND、OR、NOT必须大写。相邻的子句之间默认是AND，-x等价于NOT x，优先级NOT > AND > OR，括号改变优先级
+//   - 特殊字符用反斜杠转义，如 content:c\+\+
+//
+// defaultField不为空时，可以省略field。语句为空时返回空查询
+func ParseQuery(s string, defaultField string) (*TermQuery, error) {
+	p := &queryParser{input: []rune(s), defaultField: defaultField}
+	p.skipSpace()
+	if p.eof() {
+		return &TermQuery{}, nil
+	}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.eof() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.input[p.pos])
+	}
+	return q, nil
+}
+
+type queryParser struct {
+	input        []rune
+	pos          int
+	defaultField string
+}
+
+func (p *queryParser) errorf(pos int, format string, args ...any) error {
+	near := p.input[min(pos, len(p.input)):]
+	if len(near) > 10 {
+		near = near[:10]
+	}
+	return &ParseError{Pos: pos, Msg: fmt.Sprintf(format, args...), Near: string(near)}
+}
+
+func (p *queryParser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *queryParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *queryParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+// 一个子句到这里结束
+func (p *queryParser) clauseEnd() bool {
+	return p.eof() || unicode.IsSpace(p.peek()) || p.peek() == '(' || p.peek() == ')'
+}
+
+// 当前位置是否是运算符AND、OR、NOT，运算符后面必须是空白、括号或结尾
+func (p *queryParser) operator(op string) bool {
+	end := p.pos + len(op)
+	if end > len(p.input) || string(p.input[p.pos:end]) != op {
+		return false
+	}
+	return end == len(p.input) || unicode.IsSpace(p.input[end]) || p.input[end] == '(' || p.input[end] == ')'
+}
+
+// OR连接的若干个AND子句，组成Should
+func (p *queryParser) parseOr() (*TermQuery, error) {
+	q, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	should := []*TermQuery{q}
+	for p.skipSpace(); p.operator("OR"); p.skipSpace() {
+		p.pos += len("OR")
+		p.skipSpace()
+		if q, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		should = append(should, q)
+	}
+	if len(should) == 1 {
+		return should[0], nil
+	}
+	return &TermQuery{Should: should}, nil
+}
+
+// AND连接(或直接相邻)的若干个子句，组成Must和MustNot
+func (p *queryParser) parseAnd() (*TermQuery, error) {
+	var must, mustNot []*TermQuery
+	firstNot := -1
+	for {
+		p.skipSpace()
+		if p.eof() || p.peek() == ')' || p.operator("OR") {
+			break
+		}
+		if p.operator("AND") {
+			if len(must)+len(mustNot) == 0 {
+				return nil, p.errorf(p.pos, "AND needs a clause on its left")
+			}
+			p.pos += len("AND")
+			p.skipSpace()
+			if p.eof() || p.peek() == ')' || p.operator("OR") || p.operator("AND") {
+				return nil, p.errorf(p.pos, "AND needs a clause on its right")
+			}
+		}
+		start := p.pos
+		negated := false
+		if p.peek() == '-' {
+			p.pos++
+			negated = true
+		} else if p.operator("NOT") {
+			p.pos += len("NOT")
+			p.skipSpace()
+			negated = true
+		}
+		if negated && (p.eof() || unicode.IsSpace(p.peek()) || p.peek() == ')') {
+			return nil, p.errorf(p.pos, "expected a clause after %s", string(p.input[start:p.pos]))
+		}
+		q, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if negated {
+			if firstNot < 0 {
+				firstNot = start
+			}
+			mustNot = append(mustNot, q)
+		} else {
+			must = append(must, q)
+		}
+	}
+	if len(must) == 0 {
+		if len(mustNot) > 0 {
+			return nil, p.errorf(firstNot, "excluded clauses need at least one clause that is not excluded")
+		}
+		return nil, p.errorf(p.pos, "expected a clause")
+	}
+	if len(must) == 1 && len(mustNot) == 0 {
+		return must[0], nil
+	}
+	return &TermQuery{Must: must, MustNot: mustNot}, nil
+}
+
+// 括号括起来的查询，或者一个field:value
+func (p *queryParser) parsePrimary() (*TermQuery, error) {
+	if p.peek() != '(' {
+		return p.parseTerm()
+	}
+	open := p.pos
+	p.pos++
+	p.skipSpace()
+	if p.peek() == ')' {
+		return nil, p.errorf(open, "empty parentheses")
+	}
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.peek() != ')' {
+		return nil, p.errorf(open, "unclosed parenthesis")
+	}
+	p.pos++
+	return q, nil
+}
+
+const (
+	noWildcard     = iota
+	prefixWildcard //只有末尾一个*
+	anyWildcard
+)
+
+// 读取一个词，遇到没转义的空白或特殊字符时停下。返回去掉转义后的词，以及其中没转义的*、?的情况
+func (p *queryParser) readWord() (string, int, error) {
+	sb := strings.Builder{}
+	var metas []int //没转义的*、?在词里的位置(按字符)
+	n := 0
+	for !p.eof() {
+		r := p.peek()
+		if r == '\\' {
+			if p.pos+1 >= len(p.input) {
+				return "", noWildcard, p.errorf(p.pos, "dangling escape character")
+			}
+			sb.WriteRune(p.input[p.pos+1])
+			p.pos += 2
+			n++
+			continue
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune(`():"^~[]`, r) {
+			break
+		}
+		if r == '*' || r == '?' {
+			metas = append(metas, n)
+		}
+		sb.WriteRune(r)
+		p.pos++
+		n++
+	}
+	wildcard := noWildcard
+	if len(metas) == 1 && metas[0] == n-1 && p.input[p.pos-1] == '*' {
+		wildcard = prefixWildcard
+	} else if len(metas) > 0 {
+		wildcard = anyWildcard
+	}
+	return sb.String(), wildcard, nil
+}
+
+// 读取一个非负整数
+func (p *queryParser) readUint(what string) (uint32, error) {
+	start := p.pos
+	for !p.eof() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	n, err := strconv.ParseUint(string(p.input[start:p.pos]), 10, 32)
+	if err != nil {
+		return 0, p.errorf(start, "expected %s", what)
+	}
+	return uint32(n), nil
+}
+
+// field:value
+func (p *queryParser) parseTerm() (*TermQuery, error) {
+	start := p.pos
+	field := p.defaultField
+	if r := p.peek(); r != '"' && r != '[' {
+		word, _, err := p.readWord()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() == ':' {
+			if len(word) == 0 {
+				return nil, p.errorf(start, "empty field")
+			}
+			field = word
+			p.pos++
+		} else {
+			p.pos = start
+		}
+	}
+	if len(field) == 0 {
+		return nil, p.errorf(start, "missing field, expected field:value")
+	}
+	if p.clauseEnd() {
+		return nil, p.errorf(p.pos, "missing value for field %s", field)
+	}
+	var q *TermQuery
+	var err error
+	switch p.peek() {
+	case '"':
+		q, err = p.parsePhrase(field)
+	case '[':
+		q, err = p.parseRange(field)
+	default:
+		q, err = p.parseWord(field)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == '^' {
+		if q.Keyword == nil {
+			return nil, p.errorf(p.pos, "boost only applies to a keyword")
+		}
+		p.pos++
+		begin := p.pos
+		for !p.eof() && strings.ContainsRune("0123456789.eE+-", p.peek()) {
+			p.pos++
+		}
+		boost, err := strconv.ParseFloat(string(p.input[begin:p.pos]), 32)
+		if err != nil || boost <= 0 {
+			return nil, p.errorf(begin, "expected a positive boost")
+		}
+		q.Boost = float32(boost)
+	}
+	if !p.clauseEnd() {
+		return nil, p.errorf(p.pos, "unexpected %q", p.peek())
+	}
+	return q, nil
+}
+
+// 普通的词、前缀、通配符和模糊查询
+func (p *queryParser) parseWord(field string) (*TermQuery, error) {
+	start := p.pos
+	word, wildcard, err := p.readWord()
+	if err != nil {
+		return nil, err
+	}
+	if len(word) == 0 {
+		return nil, p.errorf(start, "missing value for field %s", field)
+	}
+	if p.peek() == '~' {
+		if wildcard != noWildcard {
+			return nil, p.errorf(p.pos, "fuzzy query can not contain * or ?")
+		}
+		p.pos++
+		maxEdits, err := p.readUint("max edits after ~")
+		if err != nil {
+			return nil, err
+		}
+		return NewFuzzyQuery(field, word, maxEdits), nil
+	}
+	switch wildcard {
+	case prefixWildcard:
+		return NewPrefixQuery(field, strings.TrimSuffix(word, "*")), nil
+	case anyWildcard:
+		return NewWildcardQuery(field, word), nil
+	default:
+		return NewTermQuery(field, word), nil
+	}
+}
+
+// 引号里的短语，后面跟~n时是邻近查询
+func (p *queryParser) parsePhrase(field string) (*TermQuery, error) {
+	open := p.pos
+	p.pos++
+	var words []string
+	sb := strings.Builder{}
+	flush := func() {
+		if sb.Len() > 0 {
+			words = append(words, sb.String())
+			sb.Reset()
+		}
+	}
+	for {
+		if p.eof() {
+			return nil, p.errorf(open, "unclosed quote")
+		}
+		r := p.peek()
+		if r == '"' {
+			p.pos++
+			break
+		}
+		if r == '\\' {
+			if p.pos+1 >= len(p.input) {
+				return nil, p.errorf(open, "unclosed quote")
+			}
+			sb.WriteRune(p.input[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		if unicode.IsSpace(r) {
+			flush()
+		} else {
+			sb.WriteRune(r)
+		}
+		p.pos++
+	}
+	flush()
+	if len(words) == 0 {
+		return nil, p.errorf(open, "empty phrase")
+	}
+	if p.peek() == '~' {
+		p.pos++
+		distance, err := p.readUint("distance after ~")
+		if err != nil {
+			return nil, err
+		}
+		return NewNearQuery(field, distance, words...), nil
+	}
+	if len(words) == 1 {
+		return NewTermQuery(field, words[0]), nil
+	}
+	return NewPhraseQuery(field, words...), nil
+}
+
+// [min,max]，*表示不限
+func (p *queryParser) parseRange(field string) (*TermQuery, error) {
+	open := p.pos
+	p.pos++
+	bound := func(unlimited int64, end rune) (int64, error) {
+		p.skipSpace()
+		start := p.pos
+		for !p.eof() && p.peek() != end && !unicode.IsSpace(p.peek()) {
+			p.pos++
+		}
+		text := string(p.input[start:p.pos])
+		p.skipSpace()
+		if p.peek() != end {
+			return 0, p.errorf(p.pos, "expected %q in range", end)
+		}
+		p.pos++
+		if text == "*" {
+			return unlimited, nil
+		}
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return 0, p.errorf(start, "invalid range bound %q", text)
+		}
+		return n, nil
+	}
+	min, err := bound(math.MinInt64, ',')
+	if err != nil {
+		return nil, err
+	}
+	max, err := bound(math.MaxInt64, ']')
+	if err != nil {
+		return nil, err
+	}
+	if min > max {
+		return nil, p.errorf(open, "range min %d is greater than max %d", min, max)
+	}
+	return NewRangeQuery(field, min, max), nil
+}
+
+// ToQueryString 转成ParseQuery能解析的查询语句。ParseQuery(q.ToQueryString(), "")的ToString()跟q.ToString()相同。
+// 例外是只有排除子句的查询，如(&TermQuery{}).Not(A)会转成-f:a，ParseQuery要求至少有一个不被排除的子句，会拒绝这种语句
+func (q TermQuery) ToQueryString() string {
+	if len(q.MustNot) > 0 {
+		//跟ToString一样，多个Must直接平铺
+		positive := TermQuery{Keyword: q.Keyword, Phrase: q.Phrase, Near: q.Near, Prefix: q.Prefix, Wildcard: q.Wildcard, Fuzzy: q.Fuzzy, Range: q.Range, Boost: q.Boost, Must: q.Must, Should: q.Should}
+		parts := make([]string, 0, len(q.Must)+len(q.MustNot))
+		if len(q.Must) > 1 {
+			for _, e := range q.Must {
+				if s := e.clauseString(); len(s) > 0 {
+					parts = append(parts, s)
+				}
+			}
+		} else if s := positive.clauseString(); len(s) > 0 {
+			parts = append(parts, s)
+		}
+		s := strings.Join(parts, " AND ")
+		for _, e := range q.MustNot {
+			if n := e.clauseString(); len(n) > 0 {
+				s += " -" + n
+			}
+		}
+		return strings.TrimPrefix(s, " ")
+	}
+	if q.Keyword != nil {
+		if len(q.Keyword.Word) == 0 {
+			return ""
+		}
+		s := escapeQueryWord(q.Keyword.Field) + ":" + escapeQueryWord(q.Keyword.Word)
+		if q.Boost > 0 && q.Boost != 1 {
+			s += "^" + strconv.FormatFloat(float64(q.Boost), 'g', -1, 32)
+		}
+		return s
+	} else if len(q.Phrase) > 0 {
+		return phraseQueryString(q.Phrase)
+	} else if q.Near != nil && len(q.Near.Keywords) > 0 {
+		return phraseQueryString(q.Near.Keywords) + "~" + strconv.FormatUint(uint64(q.Near.Distance), 10)
+	} else if q.Prefix != nil {
+		return escapeQueryWord(q.Prefix.Field) + ":" + escapeQueryWord(q.Prefix.Word) + "*"
+	} else if q.Wildcard != nil {
+		return escapeQueryWord(q.Wildcard.Field) + ":" + escapeWildcard(q.Wildcard.Word)
+	} else if q.Fuzzy != nil && q.Fuzzy.Keyword != nil {
+		return escapeQueryWord(q.Fuzzy.Keyword.Field) + ":" + escapeQueryWord(q.Fuzzy.Keyword.Word) + "~" + strconv.FormatUint(uint64(q.Fuzzy.MaxEdits), 10)
+	} else if q.Range != nil {
+		return escapeQueryWord(q.Range.Field) + ":[" + rangeBound(q.Range.Min, math.MinInt64) + "," + rangeBound(q.Range.Max, math.MaxInt64) + "]"
+	} else if len(q.Must) > 0 {
+		return joinClauses(q.Must, " AND ")
+	} else if len(q.Should) > 0 {
+		return joinClauses(q.Should, " OR ")
+	}
+	return ""
+}
+
+func joinClauses(querys []*TermQuery, op string) string {
+	if len(querys) == 1 {
+		return querys[0].ToQueryString()
+	}
+	parts := make([]string, 0, len(querys))
+	for _, e := range querys {
+		if s := e.clauseString(); len(s) > 0 {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, op)
+}
+
+// 作为AND、OR、NOT的一个操作数时的写法，由多个子句组成的查询要加括号
+func (q TermQuery) clauseString() string {
+	s := q.ToQueryString()
+	if len(s) > 0 && !q.singleClause() {
+		return "(" + s + ")"
+	}
+	return s
+}
+
+// 查询是否只由一个field:value组成
+func (q TermQuery) singleClause() bool {
+	if len(q.MustNot) > 0 {
+		return false
+	}
+	if q.Keyword != nil || len(q.Phrase) > 0 || (q.Near != nil && len(q.Near.Keywords) > 0) || q.Prefix != nil || q.Wildcard != nil || (q.Fuzzy != nil && q.Fuzzy.Keyword != nil) || q.Range != nil {
+		return true
+	}
+	if len(q.Must) == 1 {
+		return q.Must[0].singleClause()
+	}
+	if len(q.Must) == 0 && len(q.Should) == 1 {
+		return q.Should[0].singleClause()
+	}
+	return false
+}
+
+func phraseQueryString(keywords []*Keyword) string {
+	words := make([]string, 0, len(keywords))
+	for _, kw := range keywords {
+		words = append(words, escapeQueryWord(kw.Word))
+	}
+	return escapeQueryWord(keywords[0].Field) + `:"` + strings.Join(words, " ") + `"`
+}
+
+func rangeBound(n int64, unlimited int64) string {
+	if n == unlimited {
+		return "*"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// 给空白和特殊字符加上反斜杠
+func escapeQueryWord(word string) string {
+	return escapeQuery(word, `\():"^~[]*?`)
+}
+
+// 通配符里的*和?不转义
+func escapeWildcard(pattern string) string {
+	return escapeQuery(pattern, `\():"^~[]`)
+}
+
+func escapeQuery(word string, specials string) string {
+	sb := strings.Builder{}
+	for i, r := range word {
+		if unicode.IsSpace(r) || strings.ContainsRune(specials, r) || (i == 0 && r == '-') {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
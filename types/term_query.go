@@ -1,14 +1,56 @@
 package types
 
-import "strings"
+import (
+	"strconv"
+	"strings"
+)
 
 func NewTermQuery(field, keyword string) *TermQuery {
 	//TermQuery的一级成员里只有Field-keyword非空，Must和Should都为空
 	return &TermQuery{Keyword: &Keyword{Field: field, Word: keyword}}
 }
 
+// NewPhraseQuery words必须在field里按顺序紧挨着出现
+func NewPhraseQuery(field string, words ...string) *TermQuery {
+	return &TermQuery{Phrase: newKeywords(field, words)}
+}
+
+// NewNearQuery words必须都在field里出现，且位置最多相差distance，不要求顺序
+func NewNearQuery(field string, distance uint32, words ...string) *TermQuery {
+	return &TermQuery{Near: &Near{Keywords: newKeywords(field, words), Distance: distance}}
+}
+
+// NewPrefixQuery 匹配field里所有以prefix开头的keyword
+func NewPrefixQuery(field, prefix string) *TermQuery {
+	return &TermQuery{Prefix: &Keyword{Field: field, Word: prefix}}
+}
+
+// NewWildcardQuery 匹配field里所有符合pattern的keyword。*匹配任意个字符，?匹配一个字符
+func NewWildcardQuery(field, pattern string) *TermQuery {
+	return &TermQuery{Wildcard: &Keyword{Field: field, Word: pattern}}
+}
+
+// NewFuzzyQuery 匹配field里跟word的编辑距离不超过maxEdits的keyword，用于容忍拼写错误
+func NewFuzzyQuery(field, word string, maxEdits uint32) *TermQuery {
+	return &TermQuery{Fuzzy: &Fuzzy{Keyword: &Keyword{Field: field, Word: word}, MaxEdits: maxEdits}}
+}
+
+// NewRangeQuery field的数值在闭区间[min, max]内。只过滤，不影响得分
+func NewRangeQuery(field string, min, max int64) *TermQuery {
+	return &TermQuery{Range: &Range{Field: field, Min: min, Max: max}}
+}
+
+func newKeywords(field string, words []string) []*Keyword {
+	keywords := make([]*Keyword, 0, len(words))
+	for _, word := range words {
+		keywords = append(keywords, &Keyword{Field: field, Word: word})
+	}
+	return keywords
+}
+
 func (q TermQuery) Empty() bool {
-	return q.Keyword == nil && len(q.Must) == 0 && len(q.Should) == 0
+	return q.Keyword == nil && len(q.Phrase) == 0 && (q.Near == nil || len(q.Near.Keywords) == 0) && q.Prefix == nil && q.Wildcard == nil && q.Fuzzy == nil && q.Range == nil &&
+		len(q.Must) == 0 && len(q.Should) == 0 && len(q.MustNot) == 0
 }
 
 // builder模式，方法返回结构体本身
@@ -45,9 +87,75 @@ func (q *TermQuery) Or(querys ...*TermQuery) *TermQuery {
 	return &TermQuery{Should: array}
 }
 
+// Not 在q的基础上排除掉querys中的任意一个能命中的文档
+func (q *TermQuery) Not(querys ...*TermQuery) *TermQuery {
+	if len(querys) == 0 {
+		return q
+	}
+	array := make([]*TermQuery, 0, len(querys))
+	for _, ele := range querys {
+		if !ele.Empty() {
+			array = append(array, ele)
+		}
+	}
+	if len(array) == 0 {
+		return q
+	}
+	res := &TermQuery{MustNot: array}
+	if !q.Empty() {
+		res.Must = []*TermQuery{q}
+	}
+	return res
+}
+
 func (q TermQuery) ToString() string {
+	if len(q.MustNot) > 0 {
+		//排除子句跟正向子句以&相连，排除子句前面加!
+		sb := strings.Builder{}
+		sb.WriteByte('(')
+		positive := TermQuery{Keyword: q.Keyword, Phrase: q.Phrase, Near: q.Near, Prefix: q.Prefix, Wildcard: q.Wildcard, Fuzzy: q.Fuzzy, Range: q.Range, Boost: q.Boost, Must: q.Must, Should: q.Should}
+		if len(q.Must) > 1 { //多个Must直接平铺，避免多出一层括号
+			for _, e := range q.Must {
+				if s := e.ToString(); len(s) > 0 {
+					sb.WriteString(s)
+					sb.WriteByte('&')
+				}
+			}
+		} else if s := positive.ToString(); len(s) > 0 {
+			sb.WriteString(s)
+			sb.WriteByte('&')
+		}
+		for _, e := range q.MustNot {
+			if s := e.ToString(); len(s) > 0 {
+				sb.WriteByte('!')
+				sb.WriteString(s)
+				sb.WriteByte('&')
+			}
+		}
+		s := sb.String()
+		if len(s) == 1 { //子句全都是空的
+			return ""
+		}
+		s = s[0:len(s)-1] + ")"
+		return s
+	}
 	if q.Keyword != nil {
+		if q.Boost > 0 && q.Boost != 1 {
+			return q.Keyword.ToString() + "^" + strconv.FormatFloat(float64(q.Boost), 'g', -1, 32)
+		}
 		return q.Keyword.ToString()
+	} else if len(q.Phrase) > 0 {
+		return keywordsToString(q.Phrase)
+	} else if q.Near != nil && len(q.Near.Keywords) > 0 {
+		return keywordsToString(q.Near.Keywords) + "~" + strconv.FormatUint(uint64(q.Near.Distance), 10)
+	} else if q.Prefix != nil {
+		return q.Prefix.Field + "\001" + q.Prefix.Word + "*"
+	} else if q.Wildcard != nil {
+		return q.Wildcard.Field + "\001" + q.Wildcard.Word
+	} else if q.Fuzzy != nil && q.Fuzzy.Keyword != nil {
+		return q.Fuzzy.Keyword.Field + "\001" + q.Fuzzy.Keyword.Word + "~" + strconv.FormatUint(uint64(q.Fuzzy.MaxEdits), 10)
+	} else if q.Range != nil {
+		return q.Range.Field + "\001[" + strconv.FormatInt(q.Range.Min, 10) + "," + strconv.FormatInt(q.Range.Max, 10) + "]"
 	} else if len(q.Must) > 0 {
 		if len(q.Must) == 1 {
 			return q.Must[0].ToString()
@@ -84,3 +192,17 @@ func (q TermQuery) ToString() string {
 	}
 	return ""
 }
+
+// 短语和邻近查询里的keyword用空格连接，外面加双引号
+func keywordsToString(keywords []*Keyword) string {
+	sb := strings.Builder{}
+	sb.WriteByte('"')
+	for i, kw := range keywords {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(kw.ToString())
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
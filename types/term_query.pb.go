@@ -4,6 +4,7 @@
 package types
 
 import (
+	encoding_binary "encoding/binary"
 	fmt "fmt"
 	proto "github.com/gogo/protobuf/proto"
 	io "io"
@@ -22,17 +23,192 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+// 邻近查询。这些keyword必须出现在同一个field里，且位置最多相差Distance，不要求顺序
+type Near struct {
+	Keywords []*Keyword `protobuf:"bytes,1,rep,name=Keywords,proto3" json:"Keywords,omitempty"`
+	Distance uint32     `protobuf:"varint,2,opt,name=Distance,proto3" json:"Distance,omitempty"`
+}
+
+func (m *Near) Reset()         { *m = Near{} }
+func (m *Near) String() string { return proto.CompactTextString(m) }
+func (*Near) ProtoMessage()    {}
+func (*Near) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cbb9280914c3e3fe, []int{0}
+}
+func (m *Near) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Near) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Near.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Near) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Near.Merge(m, src)
+}
+func (m *Near) XXX_Size() int {
+	return m.Size()
+}
+func (m *Near) XXX_DiscardUnknown() {
+	xxx_messageInfo_Near.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Near proto.InternalMessageInfo
+
+func (m *Near) GetKeywords() []*Keyword {
+	if m != nil {
+		return m.Keywords
+	}
+	return nil
+}
+
+func (m *Near) GetDistance() uint32 {
+	if m != nil {
+		return m.Distance
+	}
+	return 0
+}
+
+// 模糊查询。展开成字典里跟Keyword的编辑距离不超过MaxEdits的keyword
+type Fuzzy struct {
+	Keyword  *Keyword `protobuf:"bytes,1,opt,name=Keyword,proto3" json:"Keyword,omitempty"`
+	MaxEdits uint32   `protobuf:"varint,2,opt,name=MaxEdits,proto3" json:"MaxEdits,omitempty"`
+}
+
+func (m *Fuzzy) Reset()         { *m = Fuzzy{} }
+func (m *Fuzzy) String() string { return proto.CompactTextString(m) }
+func (*Fuzzy) ProtoMessage()    {}
+func (*Fuzzy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cbb9280914c3e3fe, []int{1}
+}
+func (m *Fuzzy) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Fuzzy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Fuzzy.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Fuzzy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fuzzy.Merge(m, src)
+}
+func (m *Fuzzy) XXX_Size() int {
+	return m.Size()
+}
+func (m *Fuzzy) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fuzzy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fuzzy proto.InternalMessageInfo
+
+func (m *Fuzzy) GetKeyword() *Keyword {
+	if m != nil {
+		return m.Keyword
+	}
+	return nil
+}
+
+func (m *Fuzzy) GetMaxEdits() uint32 {
+	if m != nil {
+		return m.MaxEdits
+	}
+	return 0
+}
+
+// 数值范围查询，闭区间[Min, Max]。只过滤不打分
+type Range struct {
+	Field string `protobuf:"bytes,1,opt,name=Field,proto3" json:"Field,omitempty"`
+	Min   int64  `protobuf:"varint,2,opt,name=Min,proto3" json:"Min,omitempty"`
+	Max   int64  `protobuf:"varint,3,opt,name=Max,proto3" json:"Max,omitempty"`
+}
+
+func (m *Range) Reset()         { *m = Range{} }
+func (m *Range) String() string { return proto.CompactTextString(m) }
+func (*Range) ProtoMessage()    {}
+func (*Range) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cbb9280914c3e3fe, []int{2}
+}
+func (m *Range) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Range) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Range.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Range) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Range.Merge(m, src)
+}
+func (m *Range) XXX_Size() int {
+	return m.Size()
+}
+func (m *Range) XXX_DiscardUnknown() {
+	xxx_messageInfo_Range.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Range proto.InternalMessageInfo
+
+func (m *Range) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *Range) GetMin() int64 {
+	if m != nil {
+		return m.Min
+	}
+	return 0
+}
+
+func (m *Range) GetMax() int64 {
+	if m != nil {
+		return m.Max
+	}
+	return 0
+}
+
 type TermQuery struct {
-	Keyword *Keyword     `protobuf:"bytes,1,opt,name=Keyword,proto3" json:"Keyword,omitempty"`
-	Must    []*TermQuery `protobuf:"bytes,2,rep,name=Must,proto3" json:"Must,omitempty"`
-	Should  []*TermQuery `protobuf:"bytes,3,rep,name=Should,proto3" json:"Should,omitempty"`
+	Keyword  *Keyword     `protobuf:"bytes,1,opt,name=Keyword,proto3" json:"Keyword,omitempty"`
+	Must     []*TermQuery `protobuf:"bytes,2,rep,name=Must,proto3" json:"Must,omitempty"`
+	Should   []*TermQuery `protobuf:"bytes,3,rep,name=Should,proto3" json:"Should,omitempty"`
+	MustNot  []*TermQuery `protobuf:"bytes,4,rep,name=MustNot,proto3" json:"MustNot,omitempty"`
+	Phrase   []*Keyword   `protobuf:"bytes,5,rep,name=Phrase,proto3" json:"Phrase,omitempty"`
+	Near     *Near        `protobuf:"bytes,6,opt,name=Near,proto3" json:"Near,omitempty"`
+	Prefix   *Keyword     `protobuf:"bytes,7,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	Wildcard *Keyword     `protobuf:"bytes,8,opt,name=Wildcard,proto3" json:"Wildcard,omitempty"`
+	Fuzzy    *Fuzzy       `protobuf:"bytes,9,opt,name=Fuzzy,proto3" json:"Fuzzy,omitempty"`
+	Boost    float32      `protobuf:"fixed32,10,opt,name=Boost,proto3" json:"Boost,omitempty"`
+	Range    *Range       `protobuf:"bytes,11,opt,name=Range,proto3" json:"Range,omitempty"`
 }
 
 func (m *TermQuery) Reset()         { *m = TermQuery{} }
 func (m *TermQuery) String() string { return proto.CompactTextString(m) }
 func (*TermQuery) ProtoMessage()    {}
 func (*TermQuery) Descriptor() ([]byte, []int) {
-	return fileDescriptor_cbb9280914c3e3fe, []int{0}
+	return fileDescriptor_cbb9280914c3e3fe, []int{3}
 }
 func (m *TermQuery) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -82,28 +258,101 @@ func (m *TermQuery) GetShould() []*TermQuery {
 	return nil
 }
 
+func (m *TermQuery) GetMustNot() []*TermQuery {
+	if m != nil {
+		return m.MustNot
+	}
+	return nil
+}
+
+func (m *TermQuery) GetPhrase() []*Keyword {
+	if m != nil {
+		return m.Phrase
+	}
+	return nil
+}
+
+func (m *TermQuery) GetNear() *Near {
+	if m != nil {
+		return m.Near
+	}
+	return nil
+}
+
+func (m *TermQuery) GetPrefix() *Keyword {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+func (m *TermQuery) GetWildcard() *Keyword {
+	if m != nil {
+		return m.Wildcard
+	}
+	return nil
+}
+
+func (m *TermQuery) GetFuzzy() *Fuzzy {
+	if m != nil {
+		return m.Fuzzy
+	}
+	return nil
+}
+
+func (m *TermQuery) GetBoost() float32 {
+	if m != nil {
+		return m.Boost
+	}
+	return 0
+}
+
+func (m *TermQuery) GetRange() *Range {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
 func init() {
+	proto.RegisterType((*Near)(nil), "types.Near")
+	proto.RegisterType((*Fuzzy)(nil), "types.Fuzzy")
+	proto.RegisterType((*Range)(nil), "types.Range")
 	proto.RegisterType((*TermQuery)(nil), "types.TermQuery")
 }
 
 func init() { proto.RegisterFile("term_query.proto", fileDescriptor_cbb9280914c3e3fe) }
 
 var fileDescriptor_cbb9280914c3e3fe = []byte{
-	// 174 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x28, 0x49, 0x2d, 0xca,
-	0x8d, 0x2f, 0x2c, 0x4d, 0x2d, 0xaa, 0xd4, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0x2d, 0xa9,
-	0x2c, 0x48, 0x2d, 0x96, 0xe2, 0x4c, 0xc9, 0x4f, 0x86, 0x88, 0x28, 0xb5, 0x32, 0x72, 0x71, 0x86,
-	0xa4, 0x16, 0xe5, 0x06, 0x82, 0x54, 0x09, 0x69, 0x70, 0xb1, 0x7b, 0xa7, 0x56, 0x96, 0xe7, 0x17,
-	0xa5, 0x48, 0x30, 0x2a, 0x30, 0x6a, 0x70, 0x1b, 0xf1, 0xe9, 0x81, 0x75, 0xe8, 0x41, 0x45, 0x83,
-	0x60, 0xd2, 0x42, 0x2a, 0x5c, 0x2c, 0xbe, 0xa5, 0xc5, 0x25, 0x12, 0x4c, 0x0a, 0xcc, 0x1a, 0xdc,
-	0x46, 0x02, 0x50, 0x65, 0x70, 0x93, 0x82, 0xc0, 0xb2, 0x42, 0x1a, 0x5c, 0x6c, 0xc1, 0x19, 0xf9,
-	0xa5, 0x39, 0x29, 0x12, 0xcc, 0x38, 0xd4, 0x41, 0xe5, 0x9d, 0x14, 0x4f, 0x3c, 0x92, 0x63, 0xbc,
-	0xf0, 0x48, 0x8e, 0xf1, 0xc1, 0x23, 0x39, 0xc6, 0x09, 0x8f, 0xe5, 0x18, 0x2e, 0x3c, 0x96, 0x63,
-	0xb8, 0xf1, 0x58, 0x8e, 0x21, 0x8a, 0x5d, 0xcf, 0x1a, 0xac, 0x29, 0x89, 0x0d, 0xec, 0x62, 0x63,
-	0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x03, 0x1a, 0x8d, 0xca, 0xd7, 0x00, 0x00, 0x00,
+	// 392 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xb1, 0xce, 0xd3, 0x30,
+	0x14, 0x85, 0xeb, 0xa6, 0x49, 0x9a, 0x5b, 0x40, 0x95, 0xd5, 0xc1, 0xea, 0x10, 0x42, 0x84, 0x50,
+	0xd4, 0x21, 0x03, 0x8c, 0x4c, 0x54, 0xd0, 0x05, 0xa5, 0x02, 0x83, 0x84, 0xc4, 0x82, 0x4c, 0x63,
+	0x68, 0xa4, 0xb6, 0x2e, 0x8e, 0x23, 0x92, 0x3e, 0x05, 0x0f, 0xc2, 0x83, 0x30, 0x76, 0x64, 0x44,
+	0xed, 0x8b, 0xa0, 0x38, 0x4e, 0xa4, 0x5f, 0x4a, 0x87, 0x7f, 0xcb, 0x3d, 0xe7, 0xbb, 0x47, 0xb1,
+	0x8f, 0x61, 0xaa, 0xb8, 0xdc, 0x7f, 0xf9, 0x51, 0x70, 0x59, 0xc5, 0x47, 0x29, 0x94, 0xc0, 0xb6,
+	0xaa, 0x8e, 0x3c, 0x9f, 0x7b, 0xa9, 0xd8, 0x34, 0x4a, 0xb8, 0x86, 0xd1, 0x9a, 0x33, 0x89, 0x17,
+	0x30, 0x7e, 0xcb, 0xab, 0x9f, 0x42, 0xa6, 0x39, 0x41, 0x81, 0x15, 0x4d, 0x9e, 0x3f, 0x8a, 0x35,
+	0x1c, 0x1b, 0x99, 0x76, 0x3e, 0x9e, 0xc3, 0xf8, 0x75, 0x96, 0x2b, 0x76, 0xd8, 0x70, 0x32, 0x0c,
+	0x50, 0xf4, 0x90, 0x76, 0x73, 0x98, 0x80, 0xbd, 0x2a, 0x4e, 0xa7, 0x0a, 0x47, 0xe0, 0x9a, 0x05,
+	0x82, 0x02, 0xd4, 0x93, 0xd7, 0xda, 0x75, 0x5c, 0xc2, 0xca, 0x37, 0x69, 0xa6, 0xf2, 0x36, 0xae,
+	0x9d, 0xc3, 0x57, 0x60, 0x53, 0x76, 0xf8, 0xce, 0xf1, 0x0c, 0xec, 0x55, 0xc6, 0x77, 0x4d, 0x98,
+	0x47, 0x9b, 0x01, 0x4f, 0xc1, 0x4a, 0xb2, 0x83, 0xde, 0xb2, 0x68, 0xfd, 0xa9, 0x15, 0x56, 0x12,
+	0xcb, 0x28, 0xac, 0x0c, 0x7f, 0x5b, 0xe0, 0x7d, 0xe4, 0x72, 0xff, 0xbe, 0xbe, 0x87, 0x7b, 0xfc,
+	0xd6, 0x53, 0x18, 0x25, 0x45, 0xae, 0xc8, 0x50, 0xdf, 0xc6, 0xd4, 0x60, 0x5d, 0x12, 0xd5, 0x2e,
+	0x8e, 0xc0, 0xf9, 0xb0, 0x15, 0xc5, 0x2e, 0x25, 0xd6, 0x0d, 0xce, 0xf8, 0x78, 0x01, 0x6e, 0xbd,
+	0xb1, 0x16, 0x8a, 0x8c, 0x6e, 0xa0, 0x2d, 0x80, 0x9f, 0x81, 0xf3, 0x6e, 0x2b, 0x59, 0xce, 0x89,
+	0xdd, 0xdb, 0x85, 0x71, 0xf1, 0xe3, 0xa6, 0x3d, 0xe2, 0xe8, 0xa3, 0x4c, 0x0c, 0x55, 0x4b, 0xb4,
+	0xa9, 0xb5, 0x0e, 0x92, 0xfc, 0x5b, 0x56, 0x12, 0xb7, 0xf7, 0xb4, 0xc6, 0xad, 0xeb, 0xff, 0x94,
+	0xed, 0xd2, 0x0d, 0x93, 0x29, 0x19, 0xf7, 0x92, 0x9d, 0x8f, 0x43, 0x53, 0x31, 0xf1, 0x34, 0xf8,
+	0xc0, 0x80, 0x5a, 0xa3, 0xa6, 0xfd, 0x19, 0xd8, 0x4b, 0x21, 0x72, 0x45, 0x20, 0x40, 0xd1, 0x90,
+	0x36, 0x03, 0x0e, 0x4d, 0x9b, 0x64, 0x72, 0x67, 0x53, 0x6b, 0xb4, 0xb1, 0x96, 0x4f, 0xfe, 0x5c,
+	0x7c, 0x74, 0xbe, 0xf8, 0xe8, 0xdf, 0xc5, 0x47, 0xbf, 0xae, 0xfe, 0xe0, 0x7c, 0xf5, 0x07, 0x7f,
+	0xaf, 0xfe, 0xe0, 0xb3, 0x1b, 0xbf, 0xd4, 0xfc, 0x57, 0x47, 0x3f, 0xdd, 0x17, 0xff, 0x07, 0x00,
+	0x79, 0xa7, 0x8e, 0xa4, 0xe0, 0x02, 0x00, 0x00,
 }
 
-func (m *TermQuery) Marshal() (dAtA []byte, err error) {
+func (m *Near) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -113,34 +362,25 @@ func (m *TermQuery) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *TermQuery) MarshalTo(dAtA []byte) (int, error) {
+func (m *Near) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *TermQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *Near) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Should) > 0 {
-		for iNdEx := len(m.Should) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Should[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintTermQuery(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0x1a
-		}
+	if m.Distance != 0 {
+		i = encodeVarintTermQuery(dAtA, i, uint64(m.Distance))
+		i--
+		dAtA[i] = 0x10
 	}
-	if len(m.Must) > 0 {
-		for iNdEx := len(m.Must) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.Keywords) > 0 {
+		for iNdEx := len(m.Keywords) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.Must[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.Keywords[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -148,9 +388,37 @@ func (m *TermQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 				i = encodeVarintTermQuery(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0x12
+			dAtA[i] = 0xa
 		}
 	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Fuzzy) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Fuzzy) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Fuzzy) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.MaxEdits != 0 {
+		i = encodeVarintTermQuery(dAtA, i, uint64(m.MaxEdits))
+		i--
+		dAtA[i] = 0x10
+	}
 	if m.Keyword != nil {
 		{
 			size, err := m.Keyword.MarshalToSizedBuffer(dAtA[:i])
@@ -166,31 +434,281 @@ func (m *TermQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintTermQuery(dAtA []byte, offset int, v uint64) int {
-	offset -= sovTermQuery(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Range) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *TermQuery) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *Range) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Range) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Max != 0 {
+		i = encodeVarintTermQuery(dAtA, i, uint64(m.Max))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Min != 0 {
+		i = encodeVarintTermQuery(dAtA, i, uint64(m.Min))
+		i--
+		dAtA[i] = 0x10
 	}
+	if len(m.Field) > 0 {
+		i -= len(m.Field)
+		copy(dAtA[i:], m.Field)
+		i = encodeVarintTermQuery(dAtA, i, uint64(len(m.Field)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TermQuery) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TermQuery) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TermQuery) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Keyword != nil {
-		l = m.Keyword.Size()
-		n += 1 + l + sovTermQuery(uint64(l))
+	if m.Range != nil {
+		{
+			size, err := m.Range.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
 	}
-	if len(m.Must) > 0 {
-		for _, e := range m.Must {
-			l = e.Size()
-			n += 1 + l + sovTermQuery(uint64(l))
+	if m.Boost != 0 {
+		i -= 4
+		encoding_binary.LittleEndian.PutUint32(dAtA[i:], uint32(math.Float32bits(float32(m.Boost))))
+		i--
+		dAtA[i] = 0x55
+	}
+	if m.Fuzzy != nil {
+		{
+			size, err := m.Fuzzy.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.Wildcard != nil {
+		{
+			size, err := m.Wildcard.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.Prefix != nil {
+		{
+			size, err := m.Prefix.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.Near != nil {
+		{
+			size, err := m.Near.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Phrase) > 0 {
+		for iNdEx := len(m.Phrase) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Phrase[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTermQuery(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.MustNot) > 0 {
+		for iNdEx := len(m.MustNot) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MustNot[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTermQuery(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.Should) > 0 {
+		for iNdEx := len(m.Should) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Should[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTermQuery(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Must) > 0 {
+		for iNdEx := len(m.Must) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Must[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTermQuery(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Keyword != nil {
+		{
+			size, err := m.Keyword.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTermQuery(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintTermQuery(dAtA []byte, offset int, v uint64) int {
+	offset -= sovTermQuery(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Near) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Keywords) > 0 {
+		for _, e := range m.Keywords {
+			l = e.Size()
+			n += 1 + l + sovTermQuery(uint64(l))
+		}
+	}
+	if m.Distance != 0 {
+		n += 1 + sovTermQuery(uint64(m.Distance))
+	}
+	return n
+}
+
+func (m *Fuzzy) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Keyword != nil {
+		l = m.Keyword.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.MaxEdits != 0 {
+		n += 1 + sovTermQuery(uint64(m.MaxEdits))
+	}
+	return n
+}
+
+func (m *Range) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Field)
+	if l > 0 {
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.Min != 0 {
+		n += 1 + sovTermQuery(uint64(m.Min))
+	}
+	if m.Max != 0 {
+		n += 1 + sovTermQuery(uint64(m.Max))
+	}
+	return n
+}
+
+func (m *TermQuery) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Keyword != nil {
+		l = m.Keyword.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if len(m.Must) > 0 {
+		for _, e := range m.Must {
+			l = e.Size()
+			n += 1 + l + sovTermQuery(uint64(l))
 		}
 	}
 	if len(m.Should) > 0 {
@@ -199,14 +717,377 @@ func (m *TermQuery) Size() (n int) {
 			n += 1 + l + sovTermQuery(uint64(l))
 		}
 	}
-	return n
-}
+	if len(m.MustNot) > 0 {
+		for _, e := range m.MustNot {
+			l = e.Size()
+			n += 1 + l + sovTermQuery(uint64(l))
+		}
+	}
+	if len(m.Phrase) > 0 {
+		for _, e := range m.Phrase {
+			l = e.Size()
+			n += 1 + l + sovTermQuery(uint64(l))
+		}
+	}
+	if m.Near != nil {
+		l = m.Near.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.Prefix != nil {
+		l = m.Prefix.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.Wildcard != nil {
+		l = m.Wildcard.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.Fuzzy != nil {
+		l = m.Fuzzy.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	if m.Boost != 0 {
+		n += 5
+	}
+	if m.Range != nil {
+		l = m.Range.Size()
+		n += 1 + l + sovTermQuery(uint64(l))
+	}
+	return n
+}
+
+func sovTermQuery(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozTermQuery(x uint64) (n int) {
+	return sovTermQuery(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *Near) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTermQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Near: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Near: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Keywords", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Keywords = append(m.Keywords, &Keyword{})
+			if err := m.Keywords[len(m.Keywords)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Distance", wireType)
+			}
+			m.Distance = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Distance |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTermQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Fuzzy) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTermQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Fuzzy: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Fuzzy: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Keyword", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Keyword == nil {
+				m.Keyword = &Keyword{}
+			}
+			if err := m.Keyword.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxEdits", wireType)
+			}
+			m.MaxEdits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxEdits |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTermQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Range) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTermQuery
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Range: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Range: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Field", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Field = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Min", wireType)
+			}
+			m.Min = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Min |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Max", wireType)
+			}
+			m.Max = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Max |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTermQuery(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
 
-func sovTermQuery(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozTermQuery(x uint64) (n int) {
-	return sovTermQuery(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
 func (m *TermQuery) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
@@ -341,6 +1222,265 @@ func (m *TermQuery) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MustNot", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MustNot = append(m.MustNot, &TermQuery{})
+			if err := m.MustNot[len(m.MustNot)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Phrase", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Phrase = append(m.Phrase, &Keyword{})
+			if err := m.Phrase[len(m.Phrase)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Near", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Near == nil {
+				m.Near = &Near{}
+			}
+			if err := m.Near.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Prefix == nil {
+				m.Prefix = &Keyword{}
+			}
+			if err := m.Prefix.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Wildcard", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Wildcard == nil {
+				m.Wildcard = &Keyword{}
+			}
+			if err := m.Wildcard.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fuzzy", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Fuzzy == nil {
+				m.Fuzzy = &Fuzzy{}
+			}
+			if err := m.Fuzzy.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 5 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Boost", wireType)
+			}
+			var v uint32
+			if (iNdEx + 4) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint32(encoding_binary.LittleEndian.Uint32(dAtA[iNdEx:]))
+			iNdEx += 4
+			m.Boost = float32(math.Float32frombits(v))
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Range", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTermQuery
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTermQuery
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Range == nil {
+				m.Range = &Range{}
+			}
+			if err := m.Range.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTermQuery(dAtA[iNdEx:])
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"errors"
+	"github.com/Muoshu/myRadic/types"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	kw := types.NewTermQuery
+	boosted := kw("content", "go")
+	boosted.Boost = 2.5
+	cases := []struct {
+		input  string
+		expect *types.TermQuery
+	}{
+		{`content:golang AND (author:"linux小楠" OR content:go) -content:java`, &types.TermQuery{
+			Must:    []*types.TermQuery{kw("content", "golang"), kw("author", "linux小楠").Or(kw("content", "go"))},
+			MustNot: []*types.TermQuery{kw("content", "java")},
+		}},
+		{`content:go*`, types.NewPrefixQuery("content", "go")},
+		{`content:g?o*`, types.NewWildcardQuery("content", "g?o*")},
+		{`content:golang~1`, types.NewFuzzyQuery("content", "golang", 1)},
+		{`content:"a b"`, types.NewPhraseQuery("content", "a", "b")},
+		{`content:"a b"~3`, types.NewNearQuery("content", 3, "a", "b")},
+		{`view:[-10,*]`, types.NewRangeQuery("view", -10, math.MaxInt64)},
+		{`content:go^2.5`, boosted},
+		{`content:c\ \+\+ content:\*`, kw("content", "c ++").And(kw("content", "*"))},
+		{`a:b OR c:d e:f`, kw("a", "b").Or(kw("c", "d").And(kw("e", "f")))}, //AND比OR优先
+		{`NOT a:b c:d`, &types.TermQuery{Must: []*types.TermQuery{kw("c", "d")}, MustNot: []*types.TermQuery{kw("a", "b")}}},
+		{`  `, &types.TermQuery{}},
+	}
+	for _, c := range cases {
+		q, err := types.ParseQuery(c.input, "")
+		if err != nil {
+			t.Fatalf("%s 解析失败 %s", c.input, err)
+		}
+		if q.ToString() != c.expect.ToString() {
+			t.Fatalf("%s 解析结果错误\n期望%s\n实际%s", c.input, c.expect.ToString(), q.ToString())
+		}
+	}
+	//省略field时使用默认field
+	q, err := types.ParseQuery(`go -"java script"`, "content")
+	if err != nil || q.ToString() != (&types.TermQuery{Must: []*types.TermQuery{kw("content", "go")}, MustNot: []*types.TermQuery{types.NewPhraseQuery("content", "java", "script")}}).ToString() {
+		t.Fatalf("默认field解析错误 %v %v", q, err)
+	}
+}
+
+func TestParseQueryError(t *testing.T) {
+	cases := []struct {
+		input string
+		pos   int
+	}{
+		{`content:go AND`, 14},
+		{`(content:go`, 0},
+		{`content:go)`, 10},
+		{`go`, 0},
+		{`content:go -content:java OR -content:c`, 28},
+		{`content:"a b`, 8},
+		{`view:[1,x]`, 8},
+		{`content:`, 8},
+		{`content:go^x`, 11},
+		{`作者:小楠 OR`, 8}, //按字符计算位置
+	}
+	for _, c := range cases {
+		_, err := types.ParseQuery(c.input, "")
+		var parseErr *types.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("%s 应该解析失败，实际%v", c.input, err)
+		}
+		if parseErr.Pos != c.pos {
+			t.Fatalf("%s 出错位置应为%d，实际%d: %s", c.input, c.pos, parseErr.Pos, err)
+		}
+	}
+}
+
+// 随机生成一棵包含各种节点的查询树。不生成空节点和只有一个词的短语
+func randomParsableQuery(rnd *rand.Rand, depth int) *types.TermQuery {
+	words := []string{"go", "java", "c++", "a b", "x:y", "-z", "小楠", "q*", `\`, "AND", "(1)"}
+	fields := []string{"content", "author", "-f"}
+	word := func() string { return words[rnd.Intn(len(words))] }
+	field := func() string { return fields[rnd.Intn(len(fields))] }
+	var q *types.TermQuery
+	if depth == 0 || rnd.Intn(3) == 0 {
+		switch rnd.Intn(8) {
+		case 0:
+			q = types.NewPhraseQuery(field(), word(), word())
+		case 1:
+			q = types.NewNearQuery(field(), uint32(rnd.Intn(5)), word(), word(), word())
+		case 2:
+			q = types.NewPrefixQuery(field(), word())
+		case 3:
+			q = types.NewWildcardQuery(field(), "g?"+word()+"*")
+		case 4:
+			q = types.NewFuzzyQuery(field(), word(), uint32(rnd.Intn(3)))
+		case 5:
+			bounds := []int64{math.MinInt64, -5, 0, 7, math.MaxInt64}
+			lo := rnd.Intn(len(bounds))
+			q = types.NewRangeQuery("view", bounds[lo], bounds[lo+rnd.Intn(len(bounds)-lo)])
+		default:
+			q = types.NewTermQuery(field(), word())
+			if rnd.Intn(3) == 0 {
+				q.Boost = float32(rnd.Intn(20)) / 4
+			}
+		}
+	} else {
+		children := make([]*types.TermQuery, 1+rnd.Intn(3))
+		for i := range children {
+			children[i] = randomParsableQuery(rnd, depth-1)
+		}
+		if rnd.Intn(2) == 0 {
+			q = &types.TermQuery{Must: children}
+		} else {
+			q = &types.TermQuery{Should: children}
+		}
+	}
+	if depth > 0 && rnd.Intn(4) == 0 {
+		q.MustNot = []*types.TermQuery{randomParsableQuery(rnd, depth-1)}
+	}
+	return q
+}
+
+// 解析ToQueryString的结果，得到的查询跟原查询的ToString相同
+func TestQueryStringRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		q := randomParsableQuery(rnd, 4)
+		s := q.ToQueryString()
+		parsed, err := types.ParseQuery(s, "")
+		if err != nil {
+			t.Fatalf("%s 解析失败 %s", s, err)
+		}
+		if parsed.ToString() != q.ToString() {
+			t.Fatalf("第%d个查询 %s\n期望%s\n实际%s", i, s, strconv.Quote(q.ToString()), strconv.Quote(parsed.ToString()))
+		}
+	}
+
+	//只有排除子句的查询不在往返保证之内，转出来的语句ParseQuery会拒绝
+	notOnly := (&types.TermQuery{}).Not(types.NewTermQuery("content", "go"))
+	if s := notOnly.ToQueryString(); s != "-content:go" {
+		t.Fatalf("只有排除子句的查询应转成-content:go，实际%s", s)
+	}
+	var parseErr *types.ParseError
+	if _, err := types.ParseQuery(notOnly.ToQueryString(), ""); !errors.As(err, &parseErr) {
+		t.Fatalf("只有排除子句的语句应该解析失败，实际%v", err)
+	}
+}
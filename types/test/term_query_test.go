@@ -2,7 +2,7 @@ package test
 
 import (
 	"fmt"
-	"myRadic/types"
+	"github.com/Muoshu/myRadic/types"
 	"testing"
 )
 
@@ -32,3 +32,54 @@ func TestTermQuery(t *testing.T) {
 	q = A.Or(B).Or(C).And(D).Or(E).And(F.Or(G)).And(H)
 	fmt.Println(q.ToString())
 }
+
+func TestTermQueryNot(t *testing.T) {
+	B := types.NewTermQuery(FIELD, "B")
+	C := types.NewTermQuery(FIELD, "C")
+	D := types.NewTermQuery(FIELD, "D")
+	E := &types.TermQuery{} //空Expression
+
+	if q := B.Not(E); q != B {
+		t.Fatalf("排除空Expression应返回原query")
+	}
+
+	q := B.And(C).Not(D)
+	fmt.Println(q.ToString())
+	if q.ToString() != "((\001B&\001C)&!\001D)" {
+		t.Fatalf("ToString错误 %s", q.ToString())
+	}
+
+	q = B.Or(C).Not(D, E)
+	fmt.Println(q.ToString())
+	if len(q.MustNot) != 1 || q.ToString() != "((\001B|\001C)&!\001D)" {
+		t.Fatalf("ToString错误 %s", q.ToString())
+	}
+}
+
+func TestTermQueryPhrase(t *testing.T) {
+	P := types.NewPhraseQuery("title", "go语言", "入门")
+	N := types.NewNearQuery("title", 3, "go语言", "教程")
+	if P.Empty() || N.Empty() || !types.NewPhraseQuery("title").Empty() {
+		t.Fatalf("Empty错误")
+	}
+	if P.ToString() != "\"title\001go语言 title\001入门\"" {
+		t.Fatalf("ToString错误 %s", P.ToString())
+	}
+	q := P.Or(N).Not(types.NewTermQuery(FIELD, "D"))
+	fmt.Println(q.ToString())
+	if q.ToString() != "((\"title\001go语言 title\001入门\"|\"title\001go语言 title\001教程\"~3)&!\001D)" {
+		t.Fatalf("ToString错误 %s", q.ToString())
+	}
+}
+
+func TestTermQueryRange(t *testing.T) {
+	R := types.NewRangeQuery("view", 100, 2000)
+	if R.Empty() {
+		t.Fatalf("Empty错误")
+	}
+	q := types.NewTermQuery("title", "go语言").And(R).Not(types.NewRangeQuery("like", -5, 0))
+	fmt.Println(q.ToString())
+	if q.ToString() != "((title\001go语言&view\001[100,2000])&!like\001[-5,0])" {
+		t.Fatalf("ToString错误 %s", q.ToString())
+	}
+}